@@ -0,0 +1,12 @@
+package expiry
+
+import "time"
+
+type Config struct {
+	//run the expiry sweeper
+	Enabled bool `mapstructure:"enabled"`
+	//time between two sweeps, e.g. "1h"
+	Interval time.Duration `mapstructure:"interval"`
+	//reminder windows in days before the expiry of a credential
+	Windows []int `mapstructure:"windows"`
+}
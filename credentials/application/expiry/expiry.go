@@ -0,0 +1,66 @@
+package expiry
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/DO-2K23-26/polypass-microservices/credentials/core"
+	"github.com/optique-dev/optique"
+)
+
+// Sweeper looks for expiring and expired credentials at a fixed interval, the first sweep runs on start
+type Sweeper struct {
+	service  core.CredentialsService
+	interval time.Duration
+	windows  []int
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+func NewSweeper(config Config, service core.CredentialsService) *Sweeper {
+	interval := config.Interval
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	windows := config.Windows
+	if len(windows) == 0 {
+		windows = []int{30, 7, 1}
+	}
+	return &Sweeper{
+		service:  service,
+		interval: interval,
+		windows:  windows,
+		stop:     make(chan struct{}),
+	}
+}
+
+func (s *Sweeper) Ignite() error {
+	optique.Info(fmt.Sprintf("Expiry sweeper started, every %s with windows %v days", s.interval, s.windows))
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.sweep()
+		select {
+		case <-ticker.C:
+		case <-s.stop:
+			return nil
+		}
+	}
+}
+
+func (s *Sweeper) sweep() {
+	sweep, err := s.service.SweepExpiringCredentials(time.Now(), s.windows)
+	if err != nil {
+		optique.Error(fmt.Sprintf("expiry sweep failed: %s", err))
+	}
+	if sweep.Expiring > 0 || sweep.Expired > 0 {
+		optique.Info(fmt.Sprintf("expiry sweep: %d expiring and %d expired credentials notified", sweep.Expiring, sweep.Expired))
+	}
+}
+
+func (s *Sweeper) Stop() error {
+	s.stopOnce.Do(func() { close(s.stop) })
+	return nil
+}
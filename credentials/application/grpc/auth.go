@@ -0,0 +1,87 @@
+package grpc
+
+import (
+	"context"
+	"strings"
+
+	"github.com/DO-2K23-26/polypass-microservices/credentials/infrastructure/auth"
+	"github.com/DO-2K23-26/polypass-microservices/credentials/types"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// only the credential service requires a token, reflection stays open to tools such as grpcurl
+const servicePrefix = "/credentials.CredentialService/"
+
+// the principal of an authenticated call is kept in its context under this key
+type principalKey struct{}
+
+// authenticate reads the bearer token of the authorization metadata, like the Authorization header of
+// the HTTP API
+func authenticate(ctx context.Context, authenticator auth.Authenticator) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return nil, status.Error(codes.Unauthenticated, "a bearer token is required")
+	}
+	token, ok := strings.CutPrefix(values[0], "Bearer ")
+	if !ok || token == "" {
+		return nil, status.Error(codes.Unauthenticated, "a bearer token is required")
+	}
+	principal, err := authenticator.Authenticate(token)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	return context.WithValue(ctx, principalKey{}, principal), nil
+}
+
+func unaryAuthenticate(authenticator auth.Authenticator) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if !strings.HasPrefix(info.FullMethod, servicePrefix) {
+			return handler(ctx, req)
+		}
+		ctx, err := authenticate(ctx, authenticator)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func streamAuthenticate(authenticator auth.Authenticator) grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if !strings.HasPrefix(info.FullMethod, servicePrefix) {
+			return handler(srv, stream)
+		}
+		ctx, err := authenticate(stream.Context(), authenticator)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &authenticatedStream{ServerStream: stream, ctx: ctx})
+	}
+}
+
+// authenticatedStream gives the handler the context holding the principal
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
+
+// principal is the caller of the call. Without authentication every caller is trusted as a service,
+// named by the actor of the request.
+func principal(ctx context.Context, actor *string) types.Principal {
+	if principal, ok := ctx.Value(principalKey{}).(types.Principal); ok {
+		return principal
+	}
+	principal := types.Principal{Service: true}
+	if actor != nil {
+		principal.Subject = *actor
+	}
+	return principal
+}
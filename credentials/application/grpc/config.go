@@ -0,0 +1,5 @@
+package grpc
+
+type Config struct {
+	ListenAddr string `mapstructure:"listen_addr"`
+}
@@ -0,0 +1,114 @@
+package grpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/DO-2K23-26/polypass-microservices/credentials/gen/credentials/api"
+	"github.com/DO-2K23-26/polypass-microservices/credentials/types"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// credentialMessage converts a credential to its typed message, the attributes are already masked by
+// the service
+func credentialMessage(credential types.GenericCredential) (*api.Credential, error) {
+	message := &api.Credential{
+		Id:         credential.ID,
+		Title:      credential.Title,
+		Note:       credential.Note,
+		CreatedAt:  timestamp(credential.CreatedAt),
+		UpdatedAt:  timestamp(credential.UpdatedAt),
+		ExpiresAt:  timestamp(credential.ExpiresAt),
+		LastReadAt: timestamp(credential.LastReadAt),
+		UpdatedBy:  credential.UpdatedBy,
+		Version:    int32(credential.Version),
+		Type:       string(credential.Type),
+	}
+	if credential.CustomFields != nil {
+		fields, err := structpb.NewStruct(*credential.CustomFields)
+		if err != nil {
+			return nil, fmt.Errorf("custom fields of %s: %w", credential.ID, err)
+		}
+		message.CustomFields = fields
+	}
+
+	attributes := credential.Attributes
+	switch credential.Type {
+	case types.CredentialTypePassword:
+		message.Attributes = &api.Credential_Password{Password: &api.PasswordAttributes{
+			UserIdentifier: stringOf(attributes, "user_identifier"),
+			Password:       stringOf(attributes, "password"),
+			DomainName:     stringOf(attributes, "domain_name"),
+		}}
+	case types.CredentialTypeCard:
+		message.Attributes = &api.Credential_Card{Card: &api.CardAttributes{
+			UserIdentifier: stringOf(attributes, "user_identifier"),
+			OwnerName:      stringOf(attributes, "owner_name"),
+			Cvc:            stringOf(attributes, "cvc"),
+			ExpirationDate: stringOf(attributes, "expiration_date"),
+			CardNumber:     stringOf(attributes, "card_number"),
+			Brand:          stringOf(attributes, "brand"),
+			LastFour:       stringOf(attributes, "last_four"),
+		}}
+	case types.CredentialTypeSSHKey:
+		message.Attributes = &api.Credential_SshKey{SshKey: &api.SSHKeyAttributes{
+			UserIdentifier: stringOf(attributes, "user_identifier"),
+			PrivateKey:     stringOf(attributes, "private_key"),
+			PublicKey:      stringOf(attributes, "public_key"),
+			Hostname:       stringOf(attributes, "hostname"),
+			Passphrase:     stringOf(attributes, "passphrase"),
+			Fingerprint:    stringOf(attributes, "fingerprint"),
+			KeyType:        stringOf(attributes, "key_type"),
+			Bits:           int32Of(attributes, "bits"),
+		}}
+	case types.CredentialTypeTOTP:
+		message.Attributes = &api.Credential_Totp{Totp: &api.TOTPAttributes{
+			Issuer:               stringOf(attributes, "issuer"),
+			AccountName:          stringOf(attributes, "account_name"),
+			Digits:               int32Of(attributes, "digits"),
+			Period:               int32Of(attributes, "period"),
+			Algorithm:            stringOf(attributes, "algorithm"),
+			PasswordCredentialId: stringOf(attributes, "password_credential_id"),
+		}}
+	default:
+		return nil, fmt.Errorf("credential type %s has no message", credential.Type)
+	}
+	return message, nil
+}
+
+func stringOf(attributes map[string]any, name string) string {
+	value, _ := attributes[name].(string)
+	return value
+}
+
+func int32Of(attributes map[string]any, name string) int32 {
+	switch value := attributes[name].(type) {
+	case int:
+		return int32(value)
+	case int32:
+		return value
+	case int64:
+		return int32(value)
+	case json.Number:
+		number, _ := value.Int64()
+		return int32(number)
+	}
+	return 0
+}
+
+func timestamp(at *time.Time) *timestamppb.Timestamp {
+	if at == nil {
+		return nil
+	}
+	return timestamppb.New(*at)
+}
+
+func timeOf(at *timestamppb.Timestamp) *time.Time {
+	if at == nil {
+		return nil
+	}
+	value := at.AsTime()
+	return &value
+}
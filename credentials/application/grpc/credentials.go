@@ -0,0 +1,162 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+
+	"github.com/DO-2K23-26/polypass-microservices/credentials/core"
+	"github.com/DO-2K23-26/polypass-microservices/credentials/gen/credentials/api"
+	"github.com/DO-2K23-26/polypass-microservices/credentials/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// credentials are read from the service by pages of this size while they are streamed
+	listPageSize        = 100
+	defaultExpiringDays = 30
+)
+
+type CredentialsServer struct {
+	api.UnimplementedCredentialServiceServer
+	service core.CredentialsService
+}
+
+func NewCredentialsServer(service core.CredentialsService) *CredentialsServer {
+	return &CredentialsServer{
+		service: service,
+	}
+}
+
+// BatchGet reads credentials of any type in one call, the reads are recorded in the access log like
+// the ones of the HTTP API
+func (s *CredentialsServer) BatchGet(ctx context.Context, request *api.BatchGetRequest) (*api.BatchGetResponse, error) {
+	if len(request.Ids) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "ids is required")
+	}
+
+	credentials, err := s.service.GetCredentials(request.Ids, access(ctx, request.Actor))
+	if err != nil {
+		return nil, errorStatus(err)
+	}
+
+	response := &api.BatchGetResponse{Credentials: make([]*api.Credential, 0, len(credentials))}
+	found := make(map[string]bool, len(credentials))
+	for _, credential := range credentials {
+		message, err := credentialMessage(credential)
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		response.Credentials = append(response.Credentials, message)
+		found[credential.ID] = true
+	}
+	for _, id := range request.Ids {
+		if !found[id] {
+			response.MissingIds = append(response.MissingIds, id)
+		}
+	}
+	return response, nil
+}
+
+// List streams the credentials matching the request. They are read page by page with the cursors of
+// ListCredentials, so a long listing does not hold every credential in memory.
+func (s *CredentialsServer) List(request *api.ListRequest, stream api.CredentialService_ListServer) error {
+	query, err := credentialQuery(request)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	reader := access(stream.Context(), request.Actor)
+
+	sent, limit := 0, int(request.Limit)
+	for {
+		query.Limit = listPageSize
+		if limit > 0 && limit-sent < listPageSize {
+			query.Limit = limit - sent
+		}
+		page, err := s.service.ListCredentials(query, reader)
+		if err != nil {
+			return errorStatus(err)
+		}
+		for _, credential := range page.Credentials {
+			message, err := credentialMessage(credential)
+			if err != nil {
+				return status.Error(codes.Internal, err.Error())
+			}
+			if err := stream.Send(message); err != nil {
+				return err
+			}
+			sent++
+		}
+		if page.NextCursor == "" || (limit > 0 && sent >= limit) {
+			return nil
+		}
+		if err := stream.Context().Err(); err != nil {
+			return status.FromContextError(err).Err()
+		}
+		query.Cursor = page.NextCursor
+	}
+}
+
+func credentialQuery(request *api.ListRequest) (types.CredentialQuery, error) {
+	query := types.CredentialQuery{
+		Type:         types.CredentialType(request.Type),
+		TitlePrefix:  request.TitlePrefix,
+		Expiry:       types.ExpiryStatus(request.Expiry),
+		CustomFields: request.CustomFields,
+		Sort:         types.CredentialSort(request.Sort),
+		Descending:   request.Descending,
+	}
+	if request.Limit < 0 {
+		return query, errors.New("invalid limit")
+	}
+	if len(request.Attributes) > 0 {
+		query.Attributes = request.Attributes
+	}
+
+	days := request.ExpiringDays
+	if days == 0 {
+		days = defaultExpiringDays
+	}
+	if days < 0 {
+		return query, errors.New("invalid expiring_days")
+	}
+	query.ExpiringWithin = time.Duration(days) * 24 * time.Hour
+
+	query.CreatedAfter = timeOf(request.CreatedAfter)
+	query.CreatedBefore = timeOf(request.CreatedBefore)
+	query.UpdatedAfter = timeOf(request.UpdatedAfter)
+	query.UpdatedBefore = timeOf(request.UpdatedBefore)
+	return query, nil
+}
+
+// access describes who reads credentials in this call, it is written to the access log. The actor is
+// given by the request as the gRPC API is called by other services, or by the token when the call is
+// authenticated. Users only read the credentials they own.
+func access(ctx context.Context, actor *string) types.AccessContext {
+	principal := principal(ctx, actor)
+	access := types.AccessContext{AuthTime: principal.AuthTime, Owner: principal.Owner()}
+	if principal.Subject != "" {
+		access.Actor = &principal.Subject
+	}
+	if p, ok := peer.FromContext(ctx); ok {
+		access.SourceIP, _, _ = net.SplitHostPort(p.Addr.String())
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if agents := md.Get("user-agent"); len(agents) > 0 {
+			access.UserAgent = agents[0]
+		}
+	}
+	return access
+}
+
+func errorStatus(err error) error {
+	switch {
+	case errors.Is(err, core.ERR_INVALID_QUERY), errors.Is(err, core.ERR_INVALID_CREDENTIAL_TYPE):
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
@@ -0,0 +1,52 @@
+package grpc
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/DO-2K23-26/polypass-microservices/credentials/core"
+	"github.com/DO-2K23-26/polypass-microservices/credentials/gen/credentials/api"
+	"github.com/DO-2K23-26/polypass-microservices/credentials/infrastructure/auth"
+	"github.com/optique-dev/optique"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+)
+
+// server serves the gRPC API next to the HTTP one, see proto/credentials.proto
+type server struct {
+	listen_addr string
+	server      *grpc.Server
+}
+
+// NewGrpc serves the credential service, calls require a bearer token when authenticator is not nil
+func NewGrpc(config Config, service core.CredentialsService, authenticator auth.Authenticator) *server {
+	var options []grpc.ServerOption
+	if authenticator != nil {
+		options = append(options,
+			grpc.ChainUnaryInterceptor(unaryAuthenticate(authenticator)),
+			grpc.ChainStreamInterceptor(streamAuthenticate(authenticator)),
+		)
+	}
+	s := grpc.NewServer(options...)
+	api.RegisterCredentialServiceServer(s, NewCredentialsServer(service))
+	// lets tools such as grpcurl discover the service
+	reflection.Register(s)
+	return &server{
+		listen_addr: config.ListenAddr,
+		server:      s,
+	}
+}
+
+func (m *server) Ignite() error {
+	listener, err := net.Listen("tcp", m.listen_addr)
+	if err != nil {
+		return err
+	}
+	optique.Info(fmt.Sprintf("gRPC server listening on %s", listener.Addr()))
+	return m.server.Serve(listener)
+}
+
+func (m *server) Stop() error {
+	m.server.GracefulStop()
+	return nil
+}
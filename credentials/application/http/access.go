@@ -0,0 +1,63 @@
+package http
+
+import (
+	"github.com/DO-2K23-26/polypass-microservices/credentials/types"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	defaultAccessLogLimit = 100
+	maxAccessLogLimit     = 1000
+)
+
+// access describes who reads credentials in this request, it is written to the access log. Users only
+// read the credentials they own.
+func access(ctx *fiber.Ctx) types.AccessContext {
+	principal := principal(ctx)
+	return types.AccessContext{
+		Actor:     actor(ctx),
+		SourceIP:  ctx.IP(),
+		UserAgent: ctx.Get(fiber.HeaderUserAgent),
+		AuthTime:  principal.AuthTime,
+		Owner:     principal.Owner(),
+	}
+}
+
+// GetCredentialAccessLog godoc
+//
+//	@Summary		Get credential access log
+//	@Description	Get who read a credential, when, which fields and from where, newest first
+//	@Tags			credentials
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string	true	"Credential ID"
+//	@Param			limit	query		int		false	"Maximum number of entries (default 100, at most 1000)"
+//	@Param			offset	query		int		false	"Number of entries to skip"
+//	@Success		200		{object}	[]types.CredentialAccess
+//	@Failure		400		{object}	fiber.Map
+//	@Failure		404		{object}	fiber.Map
+//	@Failure		500		{object}	fiber.Map
+//	@Router			/credentials/{id}/access-log [get]
+func (c *CredentialsController) GetCredentialAccessLog() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		limit, offset := ctx.QueryInt("limit", defaultAccessLogLimit), ctx.QueryInt("offset")
+		if limit <= 0 || limit > maxAccessLogLimit || offset < 0 {
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "invalid limit or offset",
+			})
+		}
+
+		if err := c.service.Authorize(principal(ctx), []string{ctx.Params("id")}); err != nil {
+			return ctx.Status(authorizeErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		entries, err := c.service.GetCredentialAccessLog(ctx.Params("id"), limit, offset)
+		if err != nil {
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		return ctx.Status(fiber.StatusOK).JSON(entries)
+	}
+}
@@ -0,0 +1,188 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/DO-2K23-26/polypass-microservices/credentials/archive"
+	"github.com/DO-2K23-26/polypass-microservices/credentials/core"
+	"github.com/DO-2K23-26/polypass-microservices/credentials/types"
+	"github.com/gofiber/fiber/v2"
+)
+
+type ExportArchiveOpts struct {
+	BaseValidator
+	Passphrase string `json:"passphrase" validate:"required"`
+}
+
+func (c *ExportArchiveOpts) Validate(ctx *fiber.Ctx) error {
+	return c.BaseValidator.Validate(ctx, c)
+}
+
+func archiveErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, archive.ERR_PASSPHRASE_TOO_SHORT),
+		errors.Is(err, archive.ERR_INVALID_ARCHIVE),
+		errors.Is(err, archive.ERR_WRONG_PASSPHRASE):
+		return fiber.StatusBadRequest
+	case errors.Is(err, core.ERR_OWNER_REQUIRED),
+		errors.Is(err, core.ERR_FOREIGN_OWNER):
+		return fiber.StatusForbidden
+	case errors.Is(err, core.ERR_HISTORY_CONFLICT):
+		return fiber.StatusConflict
+	case errors.Is(err, archive.ERR_ARCHIVE_TOO_LARGE):
+		return fiber.StatusRequestEntityTooLarge
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
+// ExportArchive godoc
+//
+//	@Summary		Export archive
+//	@Description	Export the credentials of the caller with their custom fields and version history into one archive encrypted with a key derived from the passphrase (Argon2id, AES-256-GCM). Service identities name the owner to export, or export the whole vault from /credentials/admin/export.
+//	@Tags			archive
+//	@Accept			json
+//	@Produce		octet-stream
+//	@Param			payload	body		ExportArchiveOpts	true	"Passphrase of at least 12 characters"
+//	@Param			owner	query		string				false	"Owner to export, for service identities only"
+//	@Success		200		{file}		binary
+//	@Failure		400		{object}	fiber.Map
+//	@Failure		403		{object}	fiber.Map
+//	@Failure		500		{object}	fiber.Map
+//	@Router			/credentials/export [post]
+func (c *CredentialsController) ExportArchive() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		return c.exportArchive(ctx, func(passphrase string, exportAccess types.AccessContext) ([]byte, error) {
+			exportAccess.Owner = archiveOwner(ctx)
+			return c.service.ExportArchive(passphrase, exportAccess)
+		})
+	}
+}
+
+// archiveOwner is the owner an archive is exported or imported for: the caller, or the owner named by
+// a service identity
+func archiveOwner(ctx *fiber.Ctx) *string {
+	caller := principal(ctx)
+	if owner := ctx.Query("owner"); caller.Service && owner != "" {
+		return &owner
+	}
+	return caller.Owner()
+}
+
+// ExportVault godoc
+//
+//	@Summary		Export vault archive
+//	@Description	Export every credential of every owner into one archive, like /credentials/export. Only service identities may export the vault.
+//	@Tags			archive
+//	@Accept			json
+//	@Produce		octet-stream
+//	@Param			payload	body		ExportArchiveOpts	true	"Passphrase of at least 12 characters"
+//	@Success		200		{file}		binary
+//	@Failure		400		{object}	fiber.Map
+//	@Failure		403		{object}	fiber.Map
+//	@Failure		500		{object}	fiber.Map
+//	@Router			/credentials/admin/export [post]
+func (c *CredentialsController) ExportVault() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		if !principal(ctx).Service {
+			return ctx.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": ERR_SERVICE_SCOPE_REQUIRED.Error()})
+		}
+		return c.exportArchive(ctx, c.service.ExportVault)
+	}
+}
+
+func (c *CredentialsController) exportArchive(ctx *fiber.Ctx, export func(passphrase string, access types.AccessContext) ([]byte, error)) error {
+	payload := new(ExportArchiveOpts)
+	if err := payload.Validate(ctx); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	data, err := export(payload.Passphrase, access(ctx))
+	if err != nil {
+		return ctx.Status(archiveErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	ctx.Attachment(fmt.Sprintf("polypass-vault-%s.ppv", time.Now().UTC().Format("20060102-150405")))
+	ctx.Set(fiber.HeaderContentType, fiber.MIMEOctetStream)
+	return ctx.Status(fiber.StatusOK).Send(data)
+}
+
+// ImportArchive godoc
+//
+//	@Summary		Import archive
+//	@Description	Restore the credentials of an archive made by the export for the caller, or for the owner named by a service identity, with their ids, timestamps and version history. Credentials already stored are reported as duplicates. An archive holding credentials of another owner is rejected.
+//	@Tags			archive
+//	@Accept			multipart/form-data
+//	@Produce		json
+//	@Param			archive		formData	file	true	"Archive"
+//	@Param			passphrase	formData	string	true	"Passphrase of the archive"
+//	@Param			owner		query		string	false	"Owner to import for, for service identities only"
+//	@Success		201			{object}	types.ImportReport
+//	@Failure		400			{object}	fiber.Map
+//	@Failure		403			{object}	fiber.Map
+//	@Failure		409			{object}	fiber.Map
+//	@Failure		413			{object}	fiber.Map
+//	@Failure		500			{object}	fiber.Map
+//	@Router			/credentials/import/archive [post]
+func (c *CredentialsController) ImportArchive() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		return c.importArchive(ctx, func(data []byte, passphrase string) (types.ImportReport, error) {
+			return c.service.ImportArchive(data, passphrase, archiveOwner(ctx))
+		})
+	}
+}
+
+// ImportVault godoc
+//
+//	@Summary		Import vault archive
+//	@Description	Restore an archive with the owners it holds, like /credentials/import/archive. Only service identities may import a vault.
+//	@Tags			archive
+//	@Accept			multipart/form-data
+//	@Produce		json
+//	@Param			archive		formData	file	true	"Archive"
+//	@Param			passphrase	formData	string	true	"Passphrase of the archive"
+//	@Success		201			{object}	types.ImportReport
+//	@Failure		400			{object}	fiber.Map
+//	@Failure		403			{object}	fiber.Map
+//	@Failure		409			{object}	fiber.Map
+//	@Failure		413			{object}	fiber.Map
+//	@Failure		500			{object}	fiber.Map
+//	@Router			/credentials/admin/import/archive [post]
+func (c *CredentialsController) ImportVault() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		if !principal(ctx).Service {
+			return ctx.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": ERR_SERVICE_SCOPE_REQUIRED.Error()})
+		}
+		return c.importArchive(ctx, c.service.ImportVault)
+	}
+}
+
+func (c *CredentialsController) importArchive(ctx *fiber.Ctx, restore func(data []byte, passphrase string) (types.ImportReport, error)) error {
+	header, err := ctx.FormFile("archive")
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "archive is required",
+		})
+	}
+	file, err := header.Open()
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	report, err := restore(data, ctx.FormValue("passphrase"))
+	if err != nil {
+		return ctx.Status(archiveErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusCreated).JSON(report)
+}
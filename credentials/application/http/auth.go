@@ -0,0 +1,58 @@
+package http
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/DO-2K23-26/polypass-microservices/credentials/core"
+	"github.com/DO-2K23-26/polypass-microservices/credentials/infrastructure/auth"
+	"github.com/DO-2K23-26/polypass-microservices/credentials/types"
+	"github.com/gofiber/fiber/v2"
+)
+
+var ERR_SERVICE_SCOPE_REQUIRED error = errors.New("only service identities can reach every credential")
+
+// the principal of an authenticated request is kept in the locals under this key
+const principalKey = "principal"
+
+// authenticate requires a bearer token and keeps its principal for the handlers
+func authenticate(authenticator auth.Authenticator) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		token, ok := strings.CutPrefix(ctx.Get(fiber.HeaderAuthorization), "Bearer ")
+		if !ok || token == "" {
+			ctx.Set(fiber.HeaderWWWAuthenticate, "Bearer")
+			return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "a bearer token is required",
+			})
+		}
+		principal, err := authenticator.Authenticate(token)
+		if err != nil {
+			ctx.Set(fiber.HeaderWWWAuthenticate, `Bearer error="invalid_token"`)
+			return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		ctx.Locals(principalKey, principal)
+		return ctx.Next()
+	}
+}
+
+// principal is the caller of the request. Without authentication, in development only, every caller is
+// trusted as a service named by the X-Actor header. Its authentication time is unknown: a header cannot
+// prove a recent authentication.
+func principal(ctx *fiber.Ctx) types.Principal {
+	if principal, ok := ctx.Locals(principalKey).(types.Principal); ok {
+		return principal
+	}
+	return types.Principal{
+		Subject: ctx.Get("X-Actor"),
+		Service: true,
+	}
+}
+
+func authorizeErrorStatus(err error) int {
+	if errors.Is(err, core.ERR_CREDENTIAL_NOT_FOUND) {
+		return fiber.StatusNotFound
+	}
+	return fiber.StatusInternalServerError
+}
@@ -0,0 +1,76 @@
+package http
+
+import (
+	"github.com/DO-2K23-26/polypass-microservices/credentials/types"
+	"github.com/gofiber/fiber/v2"
+)
+
+// BatchOpts is the body of a batch. Without atomic, the valid operations are written even if others fail.
+type BatchOpts struct {
+	BaseValidator
+	Atomic     bool                 `json:"atomic"`
+	Operations []BatchOperationOpts `json:"operations" validate:"required,min=1,max=1000,dive"`
+}
+
+// BatchOperationOpts is an operation of a batch. Updates and deletes give the id, updates also give the
+// version they are based on, as returned in the ETag. Creates and updates give the credential as in the
+// body of create and update.
+type BatchOperationOpts struct {
+	Op         types.CredentialOperationKind `json:"op" validate:"required"`
+	Type       types.CredentialType          `json:"type" validate:"required"`
+	ID         string                        `json:"id"`
+	Version    int                           `json:"version"`
+	Credential *CredentialOpts               `json:"credential"`
+}
+
+func (b *BatchOpts) Validate(ctx *fiber.Ctx) error {
+	return b.BaseValidator.Validate(ctx, b)
+}
+
+func (b *BatchOpts) operations(ctx *fiber.Ctx) []types.CredentialOperation {
+	operations := make([]types.CredentialOperation, 0, len(b.Operations))
+	for _, operation := range b.Operations {
+		credential := types.GenericCredential{Type: operation.Type}
+		if operation.Credential != nil {
+			credential = operation.Credential.credential(ctx, operation.Type)
+		}
+		credential.ID, credential.Version, credential.UpdatedBy = operation.ID, operation.Version, actor(ctx)
+		operations = append(operations, types.CredentialOperation{Kind: operation.Op, Credential: credential})
+	}
+	return operations
+}
+
+// WriteCredentials godoc
+//
+//	@Summary		Write credentials in a batch
+//	@Description	Create, update and delete credentials of any type in one request, with a result per operation. The operations are written in one transaction and produce the same events as single writes. With atomic, nothing is written if one operation fails and the request fails with 400.
+//	@Tags			credentials
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		BatchOpts	true	"Operations (create, update or delete) and mode"
+//	@Success		200		{object}	types.CredentialBatchReport
+//	@Failure		400		{object}	types.CredentialBatchReport
+//	@Failure		500		{object}	fiber.Map
+//	@Router			/credentials/batch [post]
+func (c *CredentialsController) WriteCredentials() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		payload := new(BatchOpts)
+		if err := payload.Validate(ctx); err != nil {
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		report, err := c.service.WriteCredentials(payload.operations(ctx), payload.Atomic, principal(ctx).Owner())
+		if err != nil {
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		if report.Atomic && report.Failed > 0 {
+			return ctx.Status(fiber.StatusBadRequest).JSON(report)
+		}
+		return ctx.Status(fiber.StatusOK).JSON(report)
+	}
+}
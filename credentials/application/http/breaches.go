@@ -0,0 +1,123 @@
+package http
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/DO-2K23-26/polypass-microservices/credentials/core"
+	"github.com/gofiber/fiber/v2"
+)
+
+type PasswordBreachOpts struct {
+	BaseValidator
+	Password string `json:"password" validate:"required"`
+}
+
+func (c *PasswordBreachOpts) Validate(ctx *fiber.Ctx) error {
+	return c.BaseValidator.Validate(ctx, c)
+}
+
+func breachErrorStatus(err error) int {
+	if errors.Is(err, core.ERR_BREACH_CHECK_DISABLED) {
+		return fiber.StatusServiceUnavailable
+	}
+	return fiber.StatusInternalServerError
+}
+
+// CheckPasswordBreach godoc
+//
+//	@Summary		Check password breach
+//	@Description	Count the occurrences of a password in the offline breach dataset, nothing is sent outside the service
+//	@Tags			passwords
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		PasswordBreachOpts	true	"Password to check"
+//	@Success		200		{object}	fiber.Map
+//	@Failure		400		{object}	fiber.Map
+//	@Failure		503		{object}	fiber.Map
+//	@Router			/passwords/breaches [post]
+func (c *CredentialsController) CheckPasswordBreach() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		payload := new(PasswordBreachOpts)
+		if err := payload.Validate(ctx); err != nil {
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		occurrences, err := c.service.CountPasswordBreaches(payload.Password)
+		if err != nil {
+			return ctx.Status(breachErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
+			"occurrences": occurrences,
+			"compromised": occurrences > 0,
+		})
+	}
+}
+
+// GetPasswordBreaches godoc
+//
+//	@Summary		Get password breaches
+//	@Description	Get the last breach check of password credentials, credentials never checked are left out
+//	@Tags			passwords
+//	@Accept			json
+//	@Produce		json
+//	@Param			ids	query		string	true	"Comma-separated list of credential IDs"
+//	@Success		200	{object}	[]types.PasswordBreach
+//	@Failure		400	{object}	fiber.Map
+//	@Failure		404	{object}	fiber.Map
+//	@Failure		500	{object}	fiber.Map
+//	@Router			/credentials/password/breaches [get]
+func (c *CredentialsController) GetPasswordBreaches() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		ids_query := ctx.Query("ids")
+		if ids_query == "" {
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "ids is required",
+			})
+		}
+
+		ids := strings.Split(ids_query, ",")
+		if err := c.service.Authorize(principal(ctx), ids); err != nil {
+			return ctx.Status(authorizeErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		breaches, err := c.service.GetPasswordBreaches(ids)
+		if err != nil {
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		return ctx.Status(fiber.StatusOK).JSON(breaches)
+	}
+}
+
+// ScanPasswordBreaches godoc
+//
+//	@Summary		Scan password breaches
+//	@Description	Check every password credential of the vault against the breach dataset and store the results
+//	@Tags			passwords
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	types.PasswordBreachScan
+//	@Failure		403	{object}	fiber.Map
+//	@Failure		500	{object}	fiber.Map
+//	@Failure		503	{object}	fiber.Map
+//	@Router			/credentials/password/breaches/scan [post]
+func (c *CredentialsController) ScanPasswordBreaches() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		if !principal(ctx).Service {
+			return ctx.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": ERR_SERVICE_SCOPE_REQUIRED.Error()})
+		}
+
+		scan, err := c.service.ScanPasswordBreaches()
+		if err != nil {
+			return ctx.Status(breachErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		return ctx.Status(fiber.StatusOK).JSON(scan)
+	}
+}
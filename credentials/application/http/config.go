@@ -2,4 +2,6 @@ package http
 
 type Config struct {
 	ListenAddr string `mapstructure:"listen_addr"`
+	//comma-separated origins allowed by CORS, every origin when empty
+	AllowOrigins string `mapstructure:"allow_origins"`
 }
@@ -1,346 +1,223 @@
 package http
 
 import (
+	"errors"
 	"strings"
 
 	"github.com/DO-2K23-26/polypass-microservices/credentials/core"
+	"github.com/DO-2K23-26/polypass-microservices/credentials/infrastructure/auth"
+	"github.com/DO-2K23-26/polypass-microservices/credentials/registry"
 	"github.com/DO-2K23-26/polypass-microservices/credentials/types"
 	"github.com/gofiber/fiber/v2"
 )
 
 type CredentialsController struct {
 	service core.CredentialsService
+	// nil when authentication is disabled
+	authenticator auth.Authenticator
 }
 
-func NewCredentialsController(service core.CredentialsService) *CredentialsController {
+func NewCredentialsController(service core.CredentialsService, authenticator auth.Authenticator) *CredentialsController {
 	return &CredentialsController{
-		service: service,
+		service:       service,
+		authenticator: authenticator,
 	}
 }
 
-// GetPasswordCredentials godoc
-//
-//	@Summary		Get password credentials
-//	@Description	Get a list of password credentials
-//	@Tags			credentials
-//	@Accept			json
-//	@Produce		json
-//	@Param			ids	query		string	true	"Comma-separated list of credential IDs"
-//	@Success		200	{object}	[]types.PasswordCredential
-//	@Failure		404	{object}	fiber.Map
-//	@Router			/credentials/password [get]
-func (c *CredentialsController) GetPasswordCredentials() fiber.Handler {
-	return func(ctx *fiber.Ctx) error {
-		ids_query := ctx.Query("ids")
-		if ids_query == "" {
-			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "ids is required",
-			})
-		}
-
-		ids := strings.Split(ids_query, ",")
-		credentials, err := c.service.GetPasswordCredentials(ids)
-		if err != nil {
-			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": err.Error(),
-			})
-		}
+// CredentialOpts is the body of create and update. The attributes of the credential type are given
+// next to the shared fields, e.g. password and domain_name for a password credential.
+type CredentialOpts struct {
+	BaseValidator
+	types.GenericCredential
+}
 
-		return ctx.Status(fiber.StatusOK).JSON(credentials)
-	}
+func (c *CredentialOpts) Validate(ctx *fiber.Ctx) error {
+	return c.BaseValidator.Validate(ctx, c)
 }
 
-type CreatePasswordCredentialOpts struct {
-	BaseValidator
-	Title        string         `json:"title" db:"title"`
-	Note         string         `json:"note" db:"note"`
-	CustomFields map[string]any `json:"custom_fields" db:"custom_fields"`
-	types.PasswordAttributes
-	types.UserIdentifierAttribute
+// credential keeps the fields a client is allowed to write, the owner is only written on create
+func (c *CredentialOpts) credential(ctx *fiber.Ctx, credentialType types.CredentialType) types.GenericCredential {
+	return types.GenericCredential{
+		Credential: types.Credential{
+			Title:        c.Title,
+			Note:         c.Note,
+			ExpiresAt:    c.ExpiresAt,
+			CustomFields: c.CustomFields,
+			UpdatedBy:    actor(ctx),
+			Owner:        actor(ctx),
+		},
+		Type:       credentialType,
+		Attributes: c.Attributes,
+	}
 }
 
-func (c *CreatePasswordCredentialOpts) Validate(ctx *fiber.Ctx) error {
-	return c.BaseValidator.Validate(ctx, c)
+// credentialDefinition resolves the :type path segment, e.g. password, card or sshkey
+func credentialDefinition(ctx *fiber.Ctx) (registry.Definition, error) {
+	return registry.ByPath(ctx.Params("type"))
 }
 
-// CreatePasswordCredential godoc
+// GetCredentialsOfType godoc
 //
-//	@Summary		Create password credential
-//	@Description	Create a password credential
+//	@Summary		Get credentials of a type
+//	@Description	Get a list of credentials of one type, secret attributes are masked
 //	@Tags			credentials
 //	@Accept			json
 //	@Produce		json
-//	@Param			payload	body		CreatePasswordCredentialOpts	true	"Create password credential options"
-//	@Success		201		{object}	types.PasswordCredential
+//	@Param			type	path		string	true	"Credential type (password, card, sshkey or totp)"
+//	@Param			ids		query		string	true	"Comma-separated list of credential IDs"
+//	@Success		200		{object}	[]map[string]any
+//	@Header			200		{string}	ETag	"Version of the credential, when a single one is returned"
 //	@Failure		400		{object}	fiber.Map
 //	@Failure		500		{object}	fiber.Map
-//	@Router			/credentials/password [post]
-func (c *CredentialsController) CreatePasswordCredential() fiber.Handler {
+//	@Router			/credentials/{type} [get]
+func (c *CredentialsController) GetCredentialsOfType() fiber.Handler {
 	return func(ctx *fiber.Ctx) error {
-		payload := new(CreatePasswordCredentialOpts)
-		if err := payload.Validate(ctx); err != nil {
-			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": err.Error(),
-			})
+		definition, err := credentialDefinition(ctx)
+		if err != nil {
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
 		}
 
-		if err := c.service.CheckCredentialValidity(&types.CreateCredentialOpts{
-			Type:               types.CredentialTypePassword,
-			Title:              payload.Title,
-			Note:               payload.Note,
-			CustomFields:       payload.CustomFields,
-			PasswordAttributes: payload.PasswordAttributes,
-			UserIdentifierAttribute: payload.UserIdentifierAttribute,
-		}); err != nil {
-			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		ids_query := ctx.Query("ids")
+		if ids_query == "" {
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "ids is required",
+			})
 		}
 
-		cred, err := c.service.CreatePasswordCredential(types.PasswordCredential{
-			Credential: types.Credential{
-				Title:        payload.Title,
-				Note:         payload.Note,
-				CustomFields: &payload.CustomFields,
-			},
-			PasswordAttributes: payload.PasswordAttributes,
-			UserIdentifierAttribute: payload.UserIdentifierAttribute,
-		})
+		ids := strings.Split(ids_query, ",")
+		credentials, err := c.service.GetCredentialsOfType(definition.Type, ids, access(ctx))
 		if err != nil {
 			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 				"error": err.Error(),
 			})
 		}
 
-		return ctx.Status(fiber.StatusCreated).JSON(cred)
+		setETag(ctx, credentials...)
+		return ctx.Status(fiber.StatusOK).JSON(credentials)
 	}
 }
 
-// UpdatePasswordCredential godoc
+// CreateCredentialOfType godoc
 //
-//	@Summary		Update password credential
-//	@Description	Update a password credential
+//	@Summary		Create credential of a type
+//	@Description	Create a credential of the type given in the path, its attributes are given next to title, note and custom_fields
 //	@Tags			credentials
 //	@Accept			json
 //	@Produce		json
-//	@Param			payload	body		CreatePasswordCredentialOpts	true	"Update password credential options"
-//	@Param			id		path		string							true	"Credential ID"
-//	@Success		200		{object}	types.PasswordCredential
+//	@Param			type	path		string			true	"Credential type (password, card, sshkey or totp)"
+//	@Param			payload	body		CredentialOpts	true	"Create credential options"
+//	@Success		201		{object}	map[string]any
 //	@Failure		400		{object}	fiber.Map
 //	@Failure		500		{object}	fiber.Map
-//	@Router			/credentials/password/:id [put]
-func (c *CredentialsController) UpdatePasswordCredential() fiber.Handler {
+//	@Router			/credentials/{type} [post]
+func (c *CredentialsController) CreateCredentialOfType() fiber.Handler {
 	return func(ctx *fiber.Ctx) error {
-		payload := new(CreatePasswordCredentialOpts)
-		if err := payload.Validate(ctx); err != nil {
-			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": err.Error(),
-			})
-		}
-
-		cred, err := c.service.UpdatePasswordCredential(types.PasswordCredential{
-			Credential: types.Credential{
-				ID:           ctx.Params("id"),
-				Title:        payload.Title,
-				Note:         payload.Note,
-				CustomFields: &payload.CustomFields,
-			},
-			PasswordAttributes: payload.PasswordAttributes,
-		})
+		definition, err := credentialDefinition(ctx)
 		if err != nil {
-			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": err.Error(),
-			})
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
 		}
-
-		return ctx.Status(fiber.StatusOK).JSON(cred)
+		return c.createCredential(ctx, definition.Type)
 	}
 }
 
-// DeletePasswordCredentials godoc
+// UpdateCredential godoc
 //
-//	@Summary		Delete password credentials
-//	@Description	Delete a list of password credentials
+//	@Summary		Update credential
+//	@Description	Replace a credential of the type given in the path. If-Match must give the ETag returned when the credential was read, the update fails with 412 if another one happened since.
 //	@Tags			credentials
 //	@Accept			json
 //	@Produce		json
-//	@Param			ids	query		string	true	"Comma-separated list of credential IDs"
-//	@Success		200	{object}	fiber.Map
-//	@Failure		400	{object}	fiber.Map
-//	@Failure		500	{object}	fiber.Map
-//	@Router			/credentials/password [delete]
-func (c *CredentialsController) DeletePasswordCredentials() fiber.Handler {
+//	@Param			type		path		string			true	"Credential type (password, card, sshkey or totp)"
+//	@Param			id			path		string			true	"Credential ID"
+//	@Param			If-Match	header		string			true	"ETag of the credential as read, or * to overwrite any version"
+//	@Param			payload		body		CredentialOpts	true	"Update credential options"
+//	@Success		200			{object}	map[string]any
+//	@Header			200			{string}	ETag	"Version of the updated credential"
+//	@Failure		400			{object}	fiber.Map
+//	@Failure		404			{object}	fiber.Map
+//	@Failure		412			{object}	fiber.Map	"The credential was updated since it was read, the body gives the current version"
+//	@Failure		428			{object}	fiber.Map
+//	@Failure		500			{object}	fiber.Map
+//	@Router			/credentials/{type}/{id} [put]
+func (c *CredentialsController) UpdateCredential() fiber.Handler {
 	return func(ctx *fiber.Ctx) error {
-		ids_query := ctx.Query("ids")
-		if ids_query == "" {
-			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "ids is required",
-			})
-		}
-
-		ids := strings.Split(ids_query, ",")
-		err := c.service.DeletePasswordCredentials(ids)
+		definition, err := credentialDefinition(ctx)
 		if err != nil {
-			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": err.Error(),
-			})
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
 		}
 
-		return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
-			"message": "success",
-		})
-	}
-}
-
-// GetCardCredentials godoc
-//
-//	@Summary		Get card credentials
-//	@Description	Get a list of card credentials
-//	@Tags			card
-//	@Accept			json
-//	@Produce		json
-//	@Param			ids	query		string	true	"Comma-separated list of card IDs"
-//	@Success		200	{object}	[]types.CardCredential
-//	@Failure		404	{object}	fiber.Map
-//	@Router			/credentials/card [get]
-func (c *CredentialsController) GetCardCredentials() fiber.Handler {
-	return func(ctx *fiber.Ctx) error {
-		ids_query := ctx.Query("ids")
-		if ids_query == "" {
-			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "ids is required",
-			})
+		version, err := ifMatch(ctx)
+		if errors.Is(err, ERR_IF_MATCH_REQUIRED) {
+			return ctx.Status(fiber.StatusPreconditionRequired).JSON(fiber.Map{"error": err.Error()})
 		}
-
-		ids := strings.Split(ids_query, ",")
-		credentials, err := c.service.GetCardCredentials(ids)
 		if err != nil {
-			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": err.Error(),
-			})
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
 		}
 
-		return ctx.Status(fiber.StatusOK).JSON(credentials)
-	}
-}
-
-type CreateCardCredentialOpts struct {
-	BaseValidator
-	Title        string         `json:"title" db:"title"`
-	Note         string         `json:"note" db:"note"`
-	CustomFields map[string]any `json:"custom_fields" db:"custom_fields"`
-	types.CardAttributes
-}
-
-func (c *CreateCardCredentialOpts) Validate(ctx *fiber.Ctx) error {
-	return c.BaseValidator.Validate(ctx, c)
-}
-
-// CreateCardCredential godoc
-//
-//	@Summary		Create card credential
-//	@Description	Create a card credential
-//	@Tags			credentials
-//	@Accept			json
-//	@Produce		json
-//	@Param			payload	body		CreateCardCredentialOpts	true	"Create card credential options"
-//	@Success		201		{object}	types.CardCredential
-//	@Failure		400		{object}	fiber.Map
-//	@Failure		500		{object}	fiber.Map
-//	@Router			/credentials/card [post]
-func (c *CredentialsController) CreateCardCredential() fiber.Handler {
-	return func(ctx *fiber.Ctx) error {
-		payload := new(CreateCardCredentialOpts)
+		payload := new(CredentialOpts)
 		if err := payload.Validate(ctx); err != nil {
 			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 				"error": err.Error(),
 			})
 		}
 
-		if err := c.service.CheckCredentialValidity(&types.CreateCredentialOpts{
-			Type:           types.CredentialTypeCard,
-			Title:          payload.Title,
-			Note:           payload.Note,
-			CustomFields:   payload.CustomFields,
-			CardAttributes: payload.CardAttributes,
-			UserIdentifierAttribute: types.UserIdentifierAttribute{
-				UserIdentifier: "",
-			},
-		}); err != nil {
-			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		credential := payload.credential(ctx, definition.Type)
+		credential.ID, credential.Version = ctx.Params("id"), version
+		if err := c.service.Authorize(principal(ctx), []string{credential.ID}); err != nil {
+			return ctx.Status(authorizeErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+		}
+		if err := c.service.CheckTOTPLink(credential, principal(ctx).Owner()); err != nil {
+			return ctx.Status(totpLinkErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
 		}
+		warning, _ := c.service.CheckPasswordPolicy(&credential)
 
-		cred, err := c.service.CreateCardCredential(types.CardCredential{
-			Credential: types.Credential{
-				Title:        payload.Title,
-				Note:         payload.Note,
-				CustomFields: &payload.CustomFields,
-			},
-			CardAttributes: payload.CardAttributes,
-		})
-		if err != nil {
+		// the service normalizes and validates the credential before writing it
+		cred, err := c.service.UpdateCredential(credential)
+		switch {
+		case errors.Is(err, core.ERR_INVALID_CREDENTIAL):
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		case errors.Is(err, core.ERR_VERSION_CONFLICT):
+			// the client reads the credential again, or overwrites it with the current version
+			setETag(ctx, cred)
+			return ctx.Status(fiber.StatusPreconditionFailed).JSON(fiber.Map{
+				"error":   err.Error(),
+				"version": cred.Version,
+			})
+		case errors.Is(err, core.ERR_CREDENTIAL_NOT_FOUND):
+			return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		case err != nil:
 			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 				"error": err.Error(),
 			})
 		}
 
-		return ctx.Status(fiber.StatusCreated).JSON(cred)
+		passwordWarning(ctx, warning)
+		setETag(ctx, cred)
+		return ctx.Status(fiber.StatusOK).JSON(cred)
 	}
 }
 
-// UpdateCardCredential godoc
+// DeleteCredentials godoc
 //
-//	@Summary		Update card credential
-//	@Description	Update a card credential
+//	@Summary		Delete credentials
+//	@Description	Move a list of credentials of one type to the trash, they are purged after trash.retention
 //	@Tags			credentials
 //	@Accept			json
 //	@Produce		json
-//	@Param			payload	body		CreateCardCredentialOpts	true	"Update card credential options"
-//	@Param			id		path		string						true	"Credential ID"
-//	@Success		200		{object}	types.CardCredential
+//	@Param			type	path		string	true	"Credential type (password, card, sshkey or totp)"
+//	@Param			ids		query		string	true	"Comma-separated list of credential IDs"
+//	@Success		200		{object}	fiber.Map
 //	@Failure		400		{object}	fiber.Map
+//	@Failure		404		{object}	fiber.Map
 //	@Failure		500		{object}	fiber.Map
-//	@Router			/credentials/card/:id [put]
-func (c *CredentialsController) UpdateCardCredential() fiber.Handler {
+//	@Router			/credentials/{type} [delete]
+func (c *CredentialsController) DeleteCredentials() fiber.Handler {
 	return func(ctx *fiber.Ctx) error {
-		payload := new(CreateCardCredentialOpts)
-		if err := payload.Validate(ctx); err != nil {
-			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": err.Error(),
-			})
-		}
-
-		cred, err := c.service.UpdateCardCredential(types.CardCredential{
-			Credential: types.Credential{
-				ID:           ctx.Params("id"),
-				Title:        payload.Title,
-				Note:         payload.Note,
-				CustomFields: &payload.CustomFields,
-			},
-			CardAttributes: payload.CardAttributes,
-		})
+		definition, err := credentialDefinition(ctx)
 		if err != nil {
-			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": err.Error(),
-			})
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
 		}
 
-		return ctx.Status(fiber.StatusOK).JSON(cred)
-	}
-}
-
-// DeleteCardCredentials godoc
-//
-//	@Summary		Delete card credentials
-//	@Description	Delete a list of Card credentials
-//	@Tags			credentials
-//	@Accept			json
-//	@Produce		json
-//	@Param			ids	query		string	true	"Comma-separated list of credential IDs"
-//	@Success		200	{object}	fiber.Map
-//	@Failure		400	{object}	fiber.Map
-//	@Failure		500	{object}	fiber.Map
-//	@Router			/credentials/card [delete]
-func (c *CredentialsController) DeleteCardCredentials() fiber.Handler {
-	return func(ctx *fiber.Ctx) error {
 		ids_query := ctx.Query("ids")
 		if ids_query == "" {
 			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
@@ -349,7 +226,10 @@ func (c *CredentialsController) DeleteCardCredentials() fiber.Handler {
 		}
 
 		ids := strings.Split(ids_query, ",")
-		err := c.service.DeleteCardCredentials(ids)
+		if err := c.service.Authorize(principal(ctx), ids); err != nil {
+			return ctx.Status(authorizeErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+		}
+		err = c.service.DeleteCredentials(definition.Type, ids, actor(ctx))
 		if err != nil {
 			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 				"error": err.Error(),
@@ -362,18 +242,20 @@ func (c *CredentialsController) DeleteCardCredentials() fiber.Handler {
 	}
 }
 
-// GetSSHKeyCredentials godoc
+// GetCredentials godoc
 //
-//	@Summary		Get SSHKey credentials
-//	@Description	Get a list of SSHKey credentials
+//	@Summary		Get credentials of any type
+//	@Description	Get a list of credentials whatever their type, each one carries a type field. Secret attributes are masked.
 //	@Tags			credentials
 //	@Accept			json
 //	@Produce		json
 //	@Param			ids	query		string	true	"Comma-separated list of credential IDs"
-//	@Success		200	{object}	[]types.SSHKeyCredential
-//	@Failure		404	{object}	fiber.Map
-//	@Router			/credentials/sshkey [get]
-func (c *CredentialsController) GetSSHKeyCredentials() fiber.Handler {
+//	@Success		200	{object}	[]map[string]any
+//	@Header			200	{string}	ETag	"Version of the credential, when a single one is returned"
+//	@Failure		400	{object}	fiber.Map
+//	@Failure		500	{object}	fiber.Map
+//	@Router			/credentials [get]
+func (c *CredentialsController) GetCredentials() fiber.Handler {
 	return func(ctx *fiber.Ctx) error {
 		ids_query := ctx.Query("ids")
 		if ids_query == "" {
@@ -383,169 +265,103 @@ func (c *CredentialsController) GetSSHKeyCredentials() fiber.Handler {
 		}
 
 		ids := strings.Split(ids_query, ",")
-		credentials, err := c.service.GetSSHKeyCredentials(ids)
+		credentials, err := c.service.GetCredentials(ids, access(ctx))
 		if err != nil {
 			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 				"error": err.Error(),
 			})
 		}
 
+		setETag(ctx, credentials...)
 		return ctx.Status(fiber.StatusOK).JSON(credentials)
 	}
 }
 
-type CreateSSHCredentialOpts struct {
-	BaseValidator
-	Title        string         `json:"title" db:"title"`
-	Note         string         `json:"note" db:"note"`
-	CustomFields map[string]any `json:"custom_fields" db:"custom_fields"`
-	types.SSHKeyAttributes
-}
-
-func (c *CreateSSHCredentialOpts) Validate(ctx *fiber.Ctx) error {
-	return c.BaseValidator.Validate(ctx, c)
-}
-
-// CreateSSHKeyCredential godoc
+// CreateCredential godoc
 //
-//	@Summary		Create SSHKey credential
-//	@Description	Create a SSHKey credential
+//	@Summary		Create credential
+//	@Description	Create a credential of the type given by the type field (password, card, ssh_key or totp)
 //	@Tags			credentials
 //	@Accept			json
 //	@Produce		json
-//	@Param			payload	body		CreateSSHCredentialOpts	true	"Create SSHKey credential options"
-//	@Success		201		{object}	types.SSHKeyCredential
+//	@Param			payload	body		CredentialOpts	true	"Create credential options"
+//	@Success		201		{object}	map[string]any
 //	@Failure		400		{object}	fiber.Map
 //	@Failure		500		{object}	fiber.Map
-//	@Router			/credentials/sshkey [post]
-func (c *CredentialsController) CreateSSHKeyCredential() fiber.Handler {
+//	@Router			/credentials [post]
+func (c *CredentialsController) CreateCredential() fiber.Handler {
 	return func(ctx *fiber.Ctx) error {
-		payload := new(CreateSSHCredentialOpts)
-		if err := payload.Validate(ctx); err != nil {
-			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": err.Error(),
-			})
-		}
-
-		// ajout du check de validité
-		if err := c.service.CheckCredentialValidity(&types.CreateCredentialOpts{
-			Type:             types.CredentialTypeSSHKey,
-			Title:            payload.Title,
-			Note:             payload.Note,
-			CustomFields:     payload.CustomFields,
-			SSHKeyAttributes: payload.SSHKeyAttributes,
-			UserIdentifierAttribute: types.UserIdentifierAttribute{
-				UserIdentifier: "",
-			},
-		}); err != nil {
-			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
-		}
-
-		cred, err := c.service.CreateSSHKeyCredential(types.SSHKeyCredential{
-			Credential: types.Credential{
-				Title:        payload.Title,
-				Note:         payload.Note,
-				CustomFields: &payload.CustomFields,
-			},
-			SSHKeyAttributes: payload.SSHKeyAttributes,
-		})
-		if err != nil {
-			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": err.Error(),
-			})
-		}
-
-		return ctx.Status(fiber.StatusCreated).JSON(cred)
+		return c.createCredential(ctx, "")
 	}
 }
 
-// UpdateSSHKeyCredential godoc
-//
-//	@Summary		Update SSHKey credential
-//	@Description	Update a SSHKey credential
-//	@Tags			credentials
-//	@Accept			json
-//	@Produce		json
-//	@Param			payload	body		CreateSSHCredentialOpts	true	"Update SSHKey credential options"
-//	@Param			id		path		string						true	"Credential ID"
-//	@Success		200		{object}	types.SSHKeyCredential
-//	@Failure		400		{object}	fiber.Map
-//	@Failure		500		{object}	fiber.Map
-//	@Router			/credentials/sshkey/:id [put]
-func (c *CredentialsController) UpdateSSHKeyCredential() fiber.Handler {
-	return func(ctx *fiber.Ctx) error {
-		payload := new(CreateSSHCredentialOpts)
-		if err := payload.Validate(ctx); err != nil {
-			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": err.Error(),
-			})
-		}
-
-		cred, err := c.service.UpdateSSHKeyCredential(types.SSHKeyCredential{
-			Credential: types.Credential{
-				ID:           ctx.Params("id"),
-				Title:        payload.Title,
-				Note:         payload.Note,
-				CustomFields: &payload.CustomFields,
-			},
-			SSHKeyAttributes: payload.SSHKeyAttributes,
+// createCredential reads the type from the body when credentialType is empty
+func (c *CredentialsController) createCredential(ctx *fiber.Ctx, credentialType types.CredentialType) error {
+	payload := new(CredentialOpts)
+	if err := payload.Validate(ctx); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
 		})
-		if err != nil {
-			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": err.Error(),
-			})
-		}
-
-		return ctx.Status(fiber.StatusOK).JSON(cred)
 	}
-}
-
-// DeleteSSHKeyCredentials godoc
-//
-//	@Summary		Delete SSHKey credentials
-//	@Description	Delete a list of SSHKey credentials
-//	@Tags			credentials
-//	@Accept			json
-//	@Produce		json
-//	@Param			ids	query		string	true	"Comma-separated list of credential IDs"
-//	@Success		200	{object}	fiber.Map
-//	@Failure		400	{object}	fiber.Map
-//	@Failure		500	{object}	fiber.Map
-//	@Router			/credentials/sshkey [delete]
-func (c *CredentialsController) DeleteSSHKeyCredentials() fiber.Handler {
-	return func(ctx *fiber.Ctx) error {
-		ids_query := ctx.Query("ids")
-		if ids_query == "" {
-			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "ids is required",
-			})
-		}
+	if credentialType == "" {
+		credentialType = payload.Type
+	}
 
-		ids := strings.Split(ids_query, ",")
-		err := c.service.DeleteSSHKeyCredentials(ids)
-		if err != nil {
-			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": err.Error(),
-			})
-		}
+	credential := payload.credential(ctx, credentialType)
+	if err := c.service.CheckTOTPLink(credential, principal(ctx).Owner()); err != nil {
+		return ctx.Status(totpLinkErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	warning, _ := c.service.CheckPasswordPolicy(&credential)
 
-		return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
-			"message": "success",
+	// the service normalizes and validates the credential before writing it
+	cred, err := c.service.CreateCredential(credential)
+	if errors.Is(err, core.ERR_INVALID_CREDENTIAL) {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
 		})
 	}
+
+	passwordWarning(ctx, warning)
+	setETag(ctx, cred)
+	return ctx.Status(fiber.StatusCreated).JSON(cred)
 }
 
 func (c *CredentialsController) Register(app *fiber.App) {
-	app.Get("/credentials/password", c.GetPasswordCredentials())
-	app.Post("/credentials/password", c.CreatePasswordCredential())
-	app.Put("/credentials/password/:id", c.UpdatePasswordCredential())
-	app.Delete("/credentials/password", c.DeletePasswordCredentials())
-	app.Get("/credentials/card", c.GetCardCredentials())
-	app.Post("/credentials/card", c.CreateCardCredential())
-	app.Put("/credentials/card/:id", c.UpdateCardCredential())
-	app.Delete("/credentials/card", c.DeleteCardCredentials())
-	app.Get("/credentials/sshkey", c.GetSSHKeyCredentials())
-	app.Post("/credentials/sshkey", c.CreateSSHKeyCredential())
-	app.Put("/credentials/sshkey/:id", c.UpdateSSHKeyCredential())
-	app.Delete("/credentials/sshkey", c.DeleteSSHKeyCredentials())
+	if c.authenticator != nil {
+		app.Use([]string{"/credentials", "/passwords", "/sshkeys"}, authenticate(c.authenticator))
+	}
+	app.Get("/credentials", c.GetCredentials())
+	app.Get("/credentials/list", c.ListCredentials())
+	app.Get("/credentials/trash", c.GetTrash())
+	app.Post("/credentials/trash/restore", c.RestoreDeletedCredentials())
+	app.Post("/credentials", c.CreateCredential())
+	app.Post("/passwords/generate", c.GeneratePassword())
+	app.Post("/passwords/passphrase", c.GeneratePassphrase())
+	app.Post("/passwords/strength", c.EstimatePasswordStrength())
+	app.Post("/passwords/breaches", c.CheckPasswordBreach())
+	app.Post("/sshkeys/generate", c.GenerateSSHKey())
+	app.Get("/credentials/password/breaches", c.GetPasswordBreaches())
+	app.Post("/credentials/password/breaches/scan", c.ScanPasswordBreaches())
+	app.Post("/credentials/batch", c.WriteCredentials())
+	app.Post("/credentials/import", c.ImportCredentials())
+	app.Post("/credentials/import/archive", c.ImportArchive())
+	app.Post("/credentials/export", c.ExportArchive())
+	app.Post("/credentials/admin/import/archive", c.ImportVault())
+	app.Post("/credentials/admin/export", c.ExportVault())
+	app.Get("/credentials/sshkey/search", c.FindSSHKeys())
+	app.Post("/credentials/totp/import", c.ImportTOTPCredential())
+	app.Get("/credentials/totp/:id/code", c.GetTOTPCode())
+	app.Get("/credentials/:id/access-log", c.GetCredentialAccessLog())
+	app.Get("/credentials/:type", c.GetCredentialsOfType())
+	app.Get("/credentials/:type/list", c.ListCredentialsOfType())
+	app.Post("/credentials/:type", c.CreateCredentialOfType())
+	app.Put("/credentials/:type/:id", c.UpdateCredential())
+	app.Post("/credentials/:type/:id/reveal", c.RevealCredential())
+	app.Delete("/credentials/:type", c.DeleteCredentials())
+	app.Get("/credentials/:type/:id/versions", c.GetCredentialVersions())
+	app.Get("/credentials/:type/:id/versions/diff", c.DiffCredentialVersions())
+	app.Post("/credentials/:type/:id/versions/:version/restore", c.RestoreCredentialVersion())
 }
@@ -0,0 +1,64 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const testCard = `{"title": "Visa", "owner_name": "Alice", "cvc": "123", "expiration_date": "12/99", "card_number": "4111 1111 1111 1111"}`
+
+func TestUpdateCredentialValidation(t *testing.T) {
+	app, repository := newTestAppWithRepository()
+	status, body := request(t, app, fiber.MethodPost, "/credentials/card", "alice", testCard)
+	if status != fiber.StatusCreated {
+		t.Fatalf("create status = %d: %s", status, body)
+	}
+	var created struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(body, &created); err != nil {
+		t.Fatalf("decode created credential: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		body     string
+		status   int
+		lastFour string
+	}{
+		{"invalid card number", strings.Replace(testCard, "4111 1111 1111 1111", "4111 1111 1111 1112", 1), fiber.StatusBadRequest, "1111"},
+		{"empty title", strings.Replace(testCard, `"Visa"`, `""`, 1), fiber.StatusBadRequest, "1111"},
+		{"invalid expiry", strings.Replace(testCard, "12/99", "13/99", 1), fiber.StatusBadRequest, "1111"},
+		// the service normalizes the number before it is written
+		{"other card", strings.Replace(testCard, "4111 1111 1111 1111", "5555-5555-5555-4444", 1), fiber.StatusOK, "4444"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(fiber.MethodPut, "/credentials/card/"+created.ID, strings.NewReader(tt.body))
+			req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+			req.Header.Set(fiber.HeaderAuthorization, "Bearer alice")
+			req.Header.Set(fiber.HeaderIfMatch, "*")
+			if status, body := send(t, app, req); status != tt.status {
+				t.Fatalf("update status = %d, want %d: %s", status, tt.status, body)
+			}
+			if lastFour := repository.credentials[created.ID].Attributes["last_four"]; lastFour != tt.lastFour {
+				t.Fatalf("stored last four = %v, want %s", lastFour, tt.lastFour)
+			}
+		})
+	}
+}
+
+func TestCreateCredentialValidation(t *testing.T) {
+	app, repository := newTestAppWithRepository()
+	status, body := request(t, app, fiber.MethodPost, "/credentials/card", "alice", strings.Replace(testCard, "123", "12", 1))
+	if status != fiber.StatusBadRequest {
+		t.Fatalf("create status = %d, want %d: %s", status, fiber.StatusBadRequest, body)
+	}
+	if len(repository.credentials) != 0 {
+		t.Fatalf("%d credentials written, want none", len(repository.credentials))
+	}
+}
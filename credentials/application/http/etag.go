@@ -0,0 +1,49 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/DO-2K23-26/polypass-microservices/credentials/types"
+	"github.com/gofiber/fiber/v2"
+)
+
+var (
+	ERR_IF_MATCH_REQUIRED error = errors.New("If-Match is required, give the ETag of the credential as read")
+	ERR_INVALID_IF_MATCH  error = errors.New(`If-Match must be an ETag such as "3", or *`)
+)
+
+// etag is the version of the credential, as a strong entity tag
+func etag(credential types.GenericCredential) string {
+	return fmt.Sprintf(`"%d"`, credential.Version)
+}
+
+// setETag tags a response holding a single credential, lists have no ETag
+func setETag(ctx *fiber.Ctx, credentials ...types.GenericCredential) {
+	if len(credentials) == 1 {
+		ctx.Set(fiber.HeaderETag, etag(credentials[0]))
+	}
+}
+
+// ifMatch reads the version an update is based on from the If-Match header. * matches any version and
+// gives 0.
+func ifMatch(ctx *fiber.Ctx) (int, error) {
+	value := strings.TrimSpace(ctx.Get(fiber.HeaderIfMatch))
+	switch value {
+	case "":
+		return 0, ERR_IF_MATCH_REQUIRED
+	case "*":
+		return 0, nil
+	}
+	// weak tags such as W/"3" are rejected, they cannot be used for updates
+	if len(value) < 2 || value[0] != '"' || value[len(value)-1] != '"' {
+		return 0, ERR_INVALID_IF_MATCH
+	}
+	version, err := strconv.Atoi(value[1 : len(value)-1])
+	if err != nil || version <= 0 {
+		return 0, ERR_INVALID_IF_MATCH
+	}
+	return version, nil
+}
@@ -1,28 +1,157 @@
 package http
 
 import (
+	"context"
+	"sync"
+	"time"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/optique-dev/optique"
 )
 
+// every readiness check must answer within this delay, a slow dependency is reported down
+const readinessTimeout = 2 * time.Second
+
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+
+	StatusReady    = "ready"
+	StatusNotReady = "not_ready"
+)
+
+// Lifecycle tells whether the service is running, it is implemented by the cycle
+type Lifecycle interface {
+	// State is starting, running or stopping
+	State() string
+	Ready() bool
+}
+
+// ReadinessCheck verifies that one dependency of the service can be used
+type ReadinessCheck struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+// DependencyStatus is the result of one readiness check
+type DependencyStatus struct {
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// ReadinessReport tells whether the service may be given traffic, with the status of every dependency
+type ReadinessReport struct {
+	Status    string                      `json:"status"`
+	Lifecycle string                      `json:"lifecycle"`
+	Checks    map[string]DependencyStatus `json:"checks"`
+}
+
 type HealthController interface {
-	Health() fiber.Handler
+	Live() fiber.Handler
+	Ready() fiber.Handler
 	Register(app *fiber.App)
 }
 
-type healthController struct{}
+type healthController struct {
+	lifecycle Lifecycle
+	checks    []ReadinessCheck
+}
 
-func NewHealthController() HealthController {
-	return healthController{}
+func NewHealthController(lifecycle Lifecycle, checks []ReadinessCheck) HealthController {
+	return healthController{
+		lifecycle: lifecycle,
+		checks:    checks,
+	}
 }
 
-func (h healthController) Health() fiber.Handler {
+// Live godoc
+//
+//	@Summary		Liveness probe
+//	@Description	Answer as long as the process serves requests, dependencies are not checked so that an outage of the database does not restart the service
+//	@Tags			health
+//	@Produce		plain
+//	@Success		200	{string}	string	"ok"
+//	@Router			/livez [get]
+func (h healthController) Live() fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		return c.SendString("Healthy")
+		return c.SendString("ok")
+	}
+}
+
+// Ready godoc
+//
+//	@Summary		Readiness probe
+//	@Description	Check the database pool, the migration version, Kafka and the key provider, with the status and latency of each. The service is not ready while it starts or stops.
+//	@Tags			health
+//	@Produce		json
+//	@Success		200	{object}	ReadinessReport
+//	@Failure		503	{object}	ReadinessReport
+//	@Router			/readyz [get]
+func (h healthController) Ready() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(c.UserContext(), readinessTimeout)
+		defer cancel()
+
+		report := ReadinessReport{
+			Status:    StatusReady,
+			Lifecycle: h.lifecycle.State(),
+			Checks:    make(map[string]DependencyStatus, len(h.checks)),
+		}
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+		for _, check := range h.checks {
+			wg.Add(1)
+			go func(check ReadinessCheck) {
+				defer wg.Done()
+				status := runCheck(ctx, check)
+				mu.Lock()
+				defer mu.Unlock()
+				report.Checks[check.Name] = status
+			}(check)
+		}
+		wg.Wait()
+
+		ready := h.lifecycle.Ready()
+		for _, status := range report.Checks {
+			ready = ready && status.Status == StatusUp
+		}
+		if !ready {
+			report.Status = StatusNotReady
+			return c.Status(fiber.StatusServiceUnavailable).JSON(report)
+		}
+		return c.Status(fiber.StatusOK).JSON(report)
+	}
+}
+
+// runCheck times a check, a check still running at the deadline of the context is reported down
+func runCheck(ctx context.Context, check ReadinessCheck) DependencyStatus {
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		done <- check.Check(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	status := DependencyStatus{
+		Status:    StatusUp,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		status.Status, status.Error = StatusDown, err.Error()
 	}
+	return status
 }
 
 func (h healthController) Register(app *fiber.App) {
 	optique.Info("Registering health handler")
-	app.Get("/health", h.Health())
+	app.Get("/livez", h.Live())
+	app.Get("/readyz", h.Ready())
+	// kept for the probes configured before /livez
+	app.Get("/health", h.Live())
 }
@@ -1,6 +1,8 @@
 package http
 
 import (
+	"sync"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/logger"
@@ -13,16 +15,21 @@ type Http interface {
 }
 
 type http struct {
-	listen_addr string
-	app         *fiber.App
-	handlers    []Handler
+	listen_addr   string
+	allow_origins string
+	app           *fiber.App
+	handlers      []Handler
+
+	// handlers are registered once, Ignite is called again when the cycle restarts the server
+	register sync.Once
 }
 
 func NewHttp(config Config) (*http, error) {
 	return &http{
-		listen_addr: config.ListenAddr,
-		app:         fiber.New(),
-		handlers:    []Handler{},
+		listen_addr:   config.ListenAddr,
+		allow_origins: config.AllowOrigins,
+		app:           fiber.New(),
+		handlers:      []Handler{},
 	}, nil
 }
 
@@ -31,17 +38,26 @@ func (m *http) WithHandler(handler Handler) {
 }
 
 func (m *http) Ignite() error {
+	m.register.Do(m.registerHandlers)
+	return m.app.Listen(m.listen_addr)
+}
+
+func (m *http) registerHandlers() {
+	allowOrigins := m.allow_origins
+	if allowOrigins == "" {
+		allowOrigins = "*"
+	}
 	m.app.Use(cors.New(cors.Config{
-		AllowOrigins: "*",
+		AllowOrigins:  allowOrigins,
+		ExposeHeaders: fiber.HeaderWarning + ", " + fiber.HeaderETag + ", " + fiber.HeaderWWWAuthenticate,
 	}))
 
 	m.app.Use(logger.New())
+	m.app.Use(instrument())
 
 	for _, handler := range m.handlers {
 		handler.Register(m.app)
 	}
-
-	return m.app.Listen(m.listen_addr)
 }
 
 func (m *http) Stop() error {
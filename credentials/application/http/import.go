@@ -0,0 +1,45 @@
+package http
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/DO-2K23-26/polypass-microservices/credentials/core"
+	"github.com/DO-2K23-26/polypass-microservices/credentials/importer"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ImportCredentials godoc
+//
+//	@Summary		Import credentials
+//	@Description	Import the export of another password manager given as request body: KeePass 2.x XML, Bitwarden unencrypted JSON or 1Password/Chrome CSV. Duplicates and invalid entries are skipped, the others are created in one transaction. With dry_run nothing is created.
+//	@Tags			credentials
+//	@Accept			plain
+//	@Produce		json
+//	@Param			format	query		string	true	"Export format (keepass, bitwarden or csv)"
+//	@Param			dry_run	query		bool	false	"Only report what would be created"
+//	@Param			payload	body		string	true	"Export file"
+//	@Success		200		{object}	types.ImportReport
+//	@Success		201		{object}	types.ImportReport
+//	@Failure		400		{object}	fiber.Map
+//	@Failure		500		{object}	fiber.Map
+//	@Router			/credentials/import [post]
+func (c *CredentialsController) ImportCredentials() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		dryRun := ctx.QueryBool("dry_run")
+		report, err := c.service.ImportCredentials(importer.Format(ctx.Query("format")), bytes.NewReader(ctx.Body()), dryRun, principal(ctx))
+		if errors.Is(err, core.ERR_INVALID_EXPORT) {
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		if err != nil {
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		if dryRun {
+			return ctx.Status(fiber.StatusOK).JSON(report)
+		}
+		return ctx.Status(fiber.StatusCreated).JSON(report)
+	}
+}
@@ -0,0 +1,167 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/DO-2K23-26/polypass-microservices/credentials/core"
+	"github.com/DO-2K23-26/polypass-microservices/credentials/types"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	defaultListLimit    = 100
+	maxListLimit        = 1000
+	defaultExpiringDays = 30
+)
+
+// attributes that listings can be filtered on, by query parameter
+var listAttributes = []string{"domain_name", "hostname"}
+
+// ListCredentials godoc
+//
+//	@Summary		List credentials
+//	@Description	List credentials of every type page by page, each one carries a type field. Secret attributes are masked.
+//	@Tags			credentials
+//	@Accept			json
+//	@Produce		json
+//	@Param			cursor			query		string	false	"next_cursor of the previous page"
+//	@Param			limit			query		int		false	"Maximum number of credentials (default 100, at most 1000)"
+//	@Param			sort			query		string	false	"title, created_at (default), updated_at or last_read_at"
+//	@Param			order			query		string	false	"asc (default) or desc"
+//	@Param			title_prefix	query		string	false	"Case-insensitive prefix of the title"
+//	@Param			domain_name		query		string	false	"Domain name of password credentials"
+//	@Param			hostname		query		string	false	"Hostname of SSH keys"
+//	@Param			created_after	query		string	false	"RFC 3339 time"
+//	@Param			created_before	query		string	false	"RFC 3339 time"
+//	@Param			updated_after	query		string	false	"RFC 3339 time"
+//	@Param			updated_before	query		string	false	"RFC 3339 time"
+//	@Param			expiry			query		string	false	"expired, expiring, valid or none"
+//	@Param			expiring_days	query		int		false	"Window of the expiring status in days (default 30)"
+//	@Param			custom_fields	query		string	false	"Comma-separated list of custom field keys the credentials must have"
+//	@Success		200				{object}	types.CredentialPage
+//	@Failure		400				{object}	fiber.Map
+//	@Failure		500				{object}	fiber.Map
+//	@Router			/credentials/list [get]
+func (c *CredentialsController) ListCredentials() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		return c.listCredentials(ctx, "")
+	}
+}
+
+// ListCredentialsOfType godoc
+//
+//	@Summary		List credentials of a type
+//	@Description	List credentials of one type page by page, secret attributes are masked
+//	@Tags			credentials
+//	@Accept			json
+//	@Produce		json
+//	@Param			type			path		string	true	"Credential type (password, card, sshkey or totp)"
+//	@Param			cursor			query		string	false	"next_cursor of the previous page"
+//	@Param			limit			query		int		false	"Maximum number of credentials (default 100, at most 1000)"
+//	@Param			sort			query		string	false	"title, created_at (default), updated_at or last_read_at"
+//	@Param			order			query		string	false	"asc (default) or desc"
+//	@Param			title_prefix	query		string	false	"Case-insensitive prefix of the title"
+//	@Param			domain_name		query		string	false	"Domain name of password credentials"
+//	@Param			hostname		query		string	false	"Hostname of SSH keys"
+//	@Param			created_after	query		string	false	"RFC 3339 time"
+//	@Param			created_before	query		string	false	"RFC 3339 time"
+//	@Param			updated_after	query		string	false	"RFC 3339 time"
+//	@Param			updated_before	query		string	false	"RFC 3339 time"
+//	@Param			expiry			query		string	false	"expired, expiring, valid or none"
+//	@Param			expiring_days	query		int		false	"Window of the expiring status in days (default 30)"
+//	@Param			custom_fields	query		string	false	"Comma-separated list of custom field keys the credentials must have"
+//	@Success		200				{object}	types.CredentialPage
+//	@Failure		400				{object}	fiber.Map
+//	@Failure		500				{object}	fiber.Map
+//	@Router			/credentials/{type}/list [get]
+func (c *CredentialsController) ListCredentialsOfType() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		definition, err := credentialDefinition(ctx)
+		if err != nil {
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.listCredentials(ctx, definition.Type)
+	}
+}
+
+func (c *CredentialsController) listCredentials(ctx *fiber.Ctx, credentialType types.CredentialType) error {
+	query, err := credentialQuery(ctx, credentialType)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	page, err := c.service.ListCredentials(query, access(ctx))
+	if err != nil {
+		status := fiber.StatusInternalServerError
+		if errors.Is(err, core.ERR_INVALID_QUERY) {
+			status = fiber.StatusBadRequest
+		}
+		return ctx.Status(status).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(page)
+}
+
+// credentialQuery reads the filters, sort and page of a listing from the query parameters
+func credentialQuery(ctx *fiber.Ctx, credentialType types.CredentialType) (types.CredentialQuery, error) {
+	query := types.CredentialQuery{
+		Type:        credentialType,
+		TitlePrefix: ctx.Query("title_prefix"),
+		Expiry:      types.ExpiryStatus(ctx.Query("expiry")),
+		Sort:        types.CredentialSort(ctx.Query("sort")),
+		Limit:       ctx.QueryInt("limit", defaultListLimit),
+		Cursor:      ctx.Query("cursor"),
+	}
+	if query.Limit <= 0 || query.Limit > maxListLimit {
+		return query, errors.New("invalid limit")
+	}
+
+	switch ctx.Query("order", "asc") {
+	case "asc":
+	case "desc":
+		query.Descending = true
+	default:
+		return query, errors.New("order must be asc or desc")
+	}
+
+	days := ctx.QueryInt("expiring_days", defaultExpiringDays)
+	if days <= 0 {
+		return query, errors.New("invalid expiring_days")
+	}
+	query.ExpiringWithin = time.Duration(days) * 24 * time.Hour
+
+	for _, name := range listAttributes {
+		if value := ctx.Query(name); value != "" {
+			if query.Attributes == nil {
+				query.Attributes = make(map[string]string)
+			}
+			query.Attributes[name] = value
+		}
+	}
+	if keys := ctx.Query("custom_fields"); keys != "" {
+		query.CustomFields = strings.Split(keys, ",")
+	}
+
+	times := map[string]**time.Time{
+		"created_after":  &query.CreatedAfter,
+		"created_before": &query.CreatedBefore,
+		"updated_after":  &query.UpdatedAfter,
+		"updated_before": &query.UpdatedBefore,
+	}
+	for name, field := range times {
+		value := ctx.Query(name)
+		if value == "" {
+			continue
+		}
+		at, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return query, fmt.Errorf("%s must be an RFC 3339 time", name)
+		}
+		at = at.UTC()
+		*field = &at
+	}
+	return query, nil
+}
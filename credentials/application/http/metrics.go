@@ -0,0 +1,75 @@
+package http
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/optique-dev/optique"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/DO-2K23-26/polypass-microservices/credentials/application/http")
+
+var requestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "credentials_http_request_duration_seconds",
+	Help:    "Latency of the HTTP requests, by method, route and status code",
+	Buckets: prometheus.DefBuckets,
+}, []string{"method", "route", "status"})
+
+// instrument times every request by route and traces it, continuing the trace of the caller given in
+// the traceparent header. The span is kept in the user context of the request.
+func instrument() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		start := time.Now()
+		parent := otel.GetTextMapPropagator().Extract(ctx.UserContext(), propagation.HeaderCarrier(ctx.GetReqHeaders()))
+		spanContext, span := tracer.Start(parent, ctx.Method(), trace.WithSpanKind(trace.SpanKindServer))
+		defer span.End()
+		ctx.SetUserContext(spanContext)
+
+		err := ctx.Next()
+
+		// the error of a handler is only written to the response by the error handler of the app, later
+		status := ctx.Response().StatusCode()
+		var fiberErr *fiber.Error
+		if errors.As(err, &fiberErr) {
+			status = fiberErr.Code
+		} else if err != nil {
+			status = fiber.StatusInternalServerError
+		}
+		// the path of the route, e.g. /credentials/:type/:id, keeps the number of series bounded
+		route := ctx.Route().Path
+		span.SetName(ctx.Method() + " " + route)
+		span.SetAttributes(
+			attribute.String("http.request.method", ctx.Method()),
+			attribute.String("http.route", route),
+			attribute.Int("http.response.status_code", status),
+		)
+		if status >= fiber.StatusInternalServerError {
+			span.SetStatus(codes.Error, strconv.Itoa(status))
+		}
+		requestDuration.WithLabelValues(ctx.Method(), route, strconv.Itoa(status)).Observe(time.Since(start).Seconds())
+		return err
+	}
+}
+
+// MetricsController exposes the Prometheus metrics of the service, with the ones of the Go runtime
+type MetricsController struct{}
+
+func NewMetricsController() MetricsController {
+	return MetricsController{}
+}
+
+func (m MetricsController) Register(app *fiber.App) {
+	optique.Info("Registering metrics handler")
+	app.Get("/metrics", adaptor.HTTPHandler(promhttp.Handler()))
+}
@@ -0,0 +1,139 @@
+package http
+
+import (
+	"fmt"
+
+	"github.com/DO-2K23-26/polypass-microservices/credentials/password"
+	"github.com/gofiber/fiber/v2"
+)
+
+type GeneratePasswordOpts struct {
+	BaseValidator
+	password.GeneratorOptions
+}
+
+func (c *GeneratePasswordOpts) Validate(ctx *fiber.Ctx) error {
+	return c.BaseValidator.Validate(ctx, c)
+}
+
+type GeneratePassphraseOpts struct {
+	BaseValidator
+	password.PassphraseOptions
+}
+
+func (c *GeneratePassphraseOpts) Validate(ctx *fiber.Ctx) error {
+	return c.BaseValidator.Validate(ctx, c)
+}
+
+type PasswordStrengthOpts struct {
+	BaseValidator
+	Password       string `json:"password" validate:"required"`
+	Title          string `json:"title"`
+	DomainName     string `json:"domain_name"`
+	UserIdentifier string `json:"user_identifier"`
+}
+
+func (c *PasswordStrengthOpts) Validate(ctx *fiber.Ctx) error {
+	return c.BaseValidator.Validate(ctx, c)
+}
+
+type GeneratedPassword struct {
+	Password string            `json:"password"`
+	Strength password.Strength `json:"strength"`
+}
+
+// passwordWarning forwards the warning of the password policy, if any, in a Warning header
+func passwordWarning(ctx *fiber.Ctx, warning string) {
+	if warning != "" {
+		ctx.Append(fiber.HeaderWarning, fmt.Sprintf("199 - %q", warning))
+	}
+}
+
+// GeneratePassword godoc
+//
+//	@Summary		Generate password
+//	@Description	Generate a random password, every character class is used when none is selected
+//	@Tags			passwords
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		GeneratePasswordOpts	true	"Generator options"
+//	@Success		200		{object}	GeneratedPassword
+//	@Failure		400		{object}	fiber.Map
+//	@Router			/passwords/generate [post]
+func (c *CredentialsController) GeneratePassword() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		payload := new(GeneratePasswordOpts)
+		if err := payload.Validate(ctx); err != nil {
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		generated, err := c.service.GeneratePassword(payload.GeneratorOptions)
+		if err != nil {
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		return ctx.Status(fiber.StatusOK).JSON(GeneratedPassword{
+			Password: generated,
+			Strength: c.service.EstimatePasswordStrength(generated),
+		})
+	}
+}
+
+// GeneratePassphrase godoc
+//
+//	@Summary		Generate passphrase
+//	@Description	Generate a passphrase from the EFF diceware wordlist
+//	@Tags			passwords
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		GeneratePassphraseOpts	true	"Passphrase options"
+//	@Success		200		{object}	GeneratedPassword
+//	@Failure		400		{object}	fiber.Map
+//	@Router			/passwords/passphrase [post]
+func (c *CredentialsController) GeneratePassphrase() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		payload := new(GeneratePassphraseOpts)
+		if err := payload.Validate(ctx); err != nil {
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		generated, err := c.service.GeneratePassphrase(payload.PassphraseOptions)
+		if err != nil {
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		return ctx.Status(fiber.StatusOK).JSON(GeneratedPassword{
+			Password: generated,
+			Strength: c.service.EstimatePasswordStrength(generated),
+		})
+	}
+}
+
+// EstimatePasswordStrength godoc
+//
+//	@Summary		Estimate password strength
+//	@Description	Estimate the entropy of a password and flag dictionary words, sequences, keyboard patterns and reuse of the title, domain or user identifier
+//	@Tags			passwords
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		PasswordStrengthOpts	true	"Password and its context"
+//	@Success		200		{object}	password.Strength
+//	@Failure		400		{object}	fiber.Map
+//	@Router			/passwords/strength [post]
+func (c *CredentialsController) EstimatePasswordStrength() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		payload := new(PasswordStrengthOpts)
+		if err := payload.Validate(ctx); err != nil {
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		strength := c.service.EstimatePasswordStrength(payload.Password, payload.Title, payload.DomainName, payload.UserIdentifier)
+		return ctx.Status(fiber.StatusOK).JSON(strength)
+	}
+}
@@ -0,0 +1,57 @@
+package http
+
+import (
+	"errors"
+
+	"github.com/DO-2K23-26/polypass-microservices/credentials/core"
+	"github.com/gofiber/fiber/v2"
+)
+
+func revealErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, core.ERR_REAUTHENTICATION_REQUIRED):
+		return fiber.StatusUnauthorized
+	case errors.Is(err, core.ERR_CREDENTIAL_NOT_FOUND):
+		return fiber.StatusNotFound
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
+// RevealCredential godoc
+//
+//	@Summary		Reveal credential
+//	@Description	Get one credential with its secret attributes in clear, the read is recorded in the access log. When reveal.max_auth_age is set, the auth_time claim of the token must tell that the caller authenticated recently.
+//	@Tags			credentials
+//	@Accept			json
+//	@Produce		json
+//	@Param			type		path		string	true	"Credential type (password, card, sshkey or totp)"
+//	@Param			id			path		string	true	"Credential ID"
+//	@Success		200			{object}	map[string]any
+//	@Failure		400			{object}	fiber.Map
+//	@Failure		401			{object}	fiber.Map
+//	@Failure		404			{object}	fiber.Map
+//	@Failure		500			{object}	fiber.Map
+//	@Router			/credentials/{type}/{id}/reveal [post]
+func (c *CredentialsController) RevealCredential() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		definition, err := credentialDefinition(ctx)
+		if err != nil {
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		if err := c.service.Authorize(principal(ctx), []string{ctx.Params("id")}); err != nil {
+			return ctx.Status(authorizeErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		credential, err := c.service.RevealCredential(definition.Type, ctx.Params("id"), access(ctx))
+		if err != nil {
+			return ctx.Status(revealErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		// secrets in clear must not be kept by browsers or proxies
+		ctx.Set(fiber.HeaderCacheControl, "no-store")
+		setETag(ctx, credential)
+		return ctx.Status(fiber.StatusOK).JSON(credential)
+	}
+}
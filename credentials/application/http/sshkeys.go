@@ -0,0 +1,76 @@
+package http
+
+import (
+	"github.com/DO-2K23-26/polypass-microservices/credentials/sshkey"
+	"github.com/gofiber/fiber/v2"
+)
+
+type GenerateSSHKeyOpts struct {
+	BaseValidator
+	sshkey.GenerateOptions
+}
+
+func (c *GenerateSSHKeyOpts) Validate(ctx *fiber.Ctx) error {
+	return c.BaseValidator.Validate(ctx, c)
+}
+
+// GenerateSSHKey godoc
+//
+//	@Summary		Generate SSH key pair
+//	@Description	Generate an ed25519, ECDSA or RSA key pair, the private key is in OpenSSH format and encrypted when a passphrase is given. The pair is not stored.
+//	@Tags			sshkeys
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		GenerateSSHKeyOpts	true	"Key options"
+//	@Success		200		{object}	sshkey.KeyPair
+//	@Failure		400		{object}	fiber.Map
+//	@Router			/sshkeys/generate [post]
+func (c *CredentialsController) GenerateSSHKey() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		payload := new(GenerateSSHKeyOpts)
+		if err := payload.Validate(ctx); err != nil {
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		pair, err := c.service.GenerateSSHKey(payload.GenerateOptions)
+		if err != nil {
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		return ctx.Status(fiber.StatusOK).JSON(pair)
+	}
+}
+
+// FindSSHKeys godoc
+//
+//	@Summary		Find SSH keys by fingerprint
+//	@Description	Get the ssh key credentials with the given SHA256 fingerprint, e.g. SHA256:uNiVztksCsDhcc0u9e8BujQXVUpKZIDTMczCvj3tD2s
+//	@Tags			sshkeys
+//	@Accept			json
+//	@Produce		json
+//	@Param			fingerprint	query		string	true	"SHA256 fingerprint"
+//	@Success		200			{object}	[]map[string]any
+//	@Failure		400			{object}	fiber.Map
+//	@Failure		500			{object}	fiber.Map
+//	@Router			/credentials/sshkey/search [get]
+func (c *CredentialsController) FindSSHKeys() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		fingerprint := ctx.Query("fingerprint")
+		if fingerprint == "" {
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "fingerprint is required",
+			})
+		}
+
+		credentials, err := c.service.FindSSHKeys(fingerprint, access(ctx))
+		if err != nil {
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		return ctx.Status(fiber.StatusOK).JSON(credentials)
+	}
+}
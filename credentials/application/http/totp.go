@@ -0,0 +1,109 @@
+package http
+
+import (
+	"errors"
+
+	"github.com/DO-2K23-26/polypass-microservices/credentials/core"
+	"github.com/DO-2K23-26/polypass-microservices/credentials/types"
+	"github.com/gofiber/fiber/v2"
+)
+
+type ImportTOTPCredentialOpts struct {
+	BaseValidator
+	URI                  string         `json:"uri" validate:"required"`
+	Title                string         `json:"title"`
+	Note                 string         `json:"note"`
+	CustomFields         map[string]any `json:"custom_fields"`
+	PasswordCredentialID string         `json:"password_credential_id"`
+}
+
+func (c *ImportTOTPCredentialOpts) Validate(ctx *fiber.Ctx) error {
+	return c.BaseValidator.Validate(ctx, c)
+}
+
+func totpLinkErrorStatus(err error) int {
+	if errors.Is(err, core.ERR_INVALID_PASSWORD_LINK) {
+		return fiber.StatusBadRequest
+	}
+	return fiber.StatusInternalServerError
+}
+
+// ImportTOTPCredential godoc
+//
+//	@Summary		Import TOTP credential
+//	@Description	Create a totp credential from an otpauth:// URI, the title defaults to the issuer of the URI
+//	@Tags			totp
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		ImportTOTPCredentialOpts	true	"Import TOTP credential options"
+//	@Success		201		{object}	types.TOTPCredential
+//	@Failure		400		{object}	fiber.Map
+//	@Failure		500		{object}	fiber.Map
+//	@Router			/credentials/totp/import [post]
+func (c *CredentialsController) ImportTOTPCredential() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		payload := new(ImportTOTPCredentialOpts)
+		if err := payload.Validate(ctx); err != nil {
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		credential, err := c.service.ParseTOTPURI(payload.URI, types.GenericCredential{
+			Credential: types.Credential{
+				Title:        payload.Title,
+				Note:         payload.Note,
+				CustomFields: &payload.CustomFields,
+				UpdatedBy:    actor(ctx),
+				Owner:        actor(ctx),
+			},
+			Attributes: map[string]any{"password_credential_id": payload.PasswordCredentialID},
+		})
+		if err != nil {
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		if err := c.service.CheckTOTPLink(credential, principal(ctx).Owner()); err != nil {
+			return ctx.Status(totpLinkErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		cred, err := c.service.CreateCredential(credential)
+		if errors.Is(err, core.ERR_INVALID_CREDENTIAL) {
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		if err != nil {
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		return ctx.Status(fiber.StatusCreated).JSON(cred)
+	}
+}
+
+// GetTOTPCode godoc
+//
+//	@Summary		Get TOTP code
+//	@Description	Get the current code of a totp credential and the seconds it stays valid
+//	@Tags			totp
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		string	true	"Credential ID"
+//	@Success		200	{object}	totp.Code
+//	@Failure		404	{object}	fiber.Map
+//	@Failure		500	{object}	fiber.Map
+//	@Router			/credentials/totp/{id}/code [get]
+func (c *CredentialsController) GetTOTPCode() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		code, err := c.service.GenerateTOTPCode(ctx.Params("id"), access(ctx))
+		if errors.Is(err, core.ERR_CREDENTIAL_NOT_FOUND) {
+			return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		if err != nil {
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		return ctx.Status(fiber.StatusOK).JSON(code)
+	}
+}
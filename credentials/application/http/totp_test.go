@@ -0,0 +1,163 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	nethttp "net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DO-2K23-26/polypass-microservices/credentials/core"
+	"github.com/DO-2K23-26/polypass-microservices/credentials/infrastructure/sql"
+	"github.com/DO-2K23-26/polypass-microservices/credentials/types"
+	"github.com/gofiber/fiber/v2"
+)
+
+// memorySql keeps the credentials in memory, only the methods reached by the tests are implemented
+type memorySql struct {
+	sql.Sql
+	credentials map[string]types.GenericCredential
+}
+
+func (m *memorySql) CreateCredential(credential types.GenericCredential) (types.GenericCredential, error) {
+	credential.ID = fmt.Sprintf("00000000-0000-0000-0000-%012d", len(m.credentials)+1)
+	m.credentials[credential.ID] = credential
+	return credential, nil
+}
+
+func (m *memorySql) UpdateCredential(credential types.GenericCredential) (types.GenericCredential, error) {
+	stored, ok := m.credentials[credential.ID]
+	if !ok {
+		return credential, sql.ERR_NOT_FOUND
+	}
+	credential.Owner, credential.Version = stored.Owner, stored.Version+1
+	m.credentials[credential.ID] = credential
+	return credential, nil
+}
+
+func (m *memorySql) GetCredentialOwners(ids []string) (map[string]*string, error) {
+	owners := make(map[string]*string, len(ids))
+	for _, id := range ids {
+		if credential, ok := m.credentials[id]; ok {
+			owners[id] = credential.Owner
+		}
+	}
+	return owners, nil
+}
+
+func (m *memorySql) GetCredentials(credentialType types.CredentialType, ids []string) ([]types.GenericCredential, error) {
+	var credentials []types.GenericCredential
+	for _, id := range ids {
+		if credential, ok := m.credentials[id]; ok && credential.Type == credentialType {
+			credentials = append(credentials, credential)
+		}
+	}
+	return credentials, nil
+}
+
+func (m *memorySql) RecordCredentialAccess(accesses []types.CredentialAccess) error {
+	return nil
+}
+
+// tokenAuthenticator accepts the tokens it was given, each one standing for a principal
+type tokenAuthenticator map[string]types.Principal
+
+func (a tokenAuthenticator) Authenticate(token string) (types.Principal, error) {
+	principal, ok := a[token]
+	if !ok {
+		return types.Principal{}, errors.New("unknown token")
+	}
+	return principal, nil
+}
+
+func newTestApp() *fiber.App {
+	app, _ := newTestAppWithRepository()
+	return app
+}
+
+func newTestAppWithRepository() (*fiber.App, *memorySql) {
+	repository := &memorySql{credentials: map[string]types.GenericCredential{}}
+	service := core.NewCredentialService(repository, core.PasswordPolicyConfig{}, nil, core.RevealConfig{})
+	controller := NewCredentialsController(service, tokenAuthenticator{
+		"alice":   {Subject: "alice"},
+		"bob":     {Subject: "bob"},
+		"backend": {Subject: "backend", Service: true},
+	})
+	app := fiber.New()
+	controller.Register(app)
+	return app, repository
+}
+
+func request(t *testing.T, app *fiber.App, method string, path string, token string, body string) (int, []byte) {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	req.Header.Set(fiber.HeaderAuthorization, "Bearer "+token)
+	return send(t, app, req)
+}
+
+func send(t *testing.T, app *fiber.App, req *nethttp.Request) (int, []byte) {
+	t.Helper()
+	method, path := req.Method, req.URL.Path
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+	var data json.RawMessage
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		t.Fatalf("%s %s: decode response: %v", method, path, err)
+	}
+	return resp.StatusCode, data
+}
+
+func TestImportTOTPCredentialOwner(t *testing.T) {
+	app := newTestApp()
+	status, body := request(t, app, fiber.MethodPost, "/credentials/totp/import", "alice",
+		`{"uri": "otpauth://totp/Example:alice@example.com?secret=JBSWY3DPEHPK3PXP&issuer=Example"}`)
+	if status != fiber.StatusCreated {
+		t.Fatalf("import status = %d: %s", status, body)
+	}
+	var created struct {
+		ID    string  `json:"id"`
+		Owner *string `json:"owner"`
+	}
+	if err := json.Unmarshal(body, &created); err != nil {
+		t.Fatalf("decode created credential: %v", err)
+	}
+	if created.Owner == nil || *created.Owner != "alice" {
+		t.Fatalf("owner = %v, want alice", created.Owner)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		found bool
+	}{
+		{"importer", "alice", true},
+		{"other user", "bob", false},
+		{"service identity", "backend", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, body := request(t, app, fiber.MethodGet, "/credentials/totp?ids="+created.ID, tt.token, "")
+			if status != fiber.StatusOK {
+				t.Fatalf("read status = %d: %s", status, body)
+			}
+			var credentials []map[string]any
+			if err := json.Unmarshal(body, &credentials); err != nil {
+				t.Fatalf("decode credentials: %v", err)
+			}
+			if found := len(credentials) == 1; found != tt.found {
+				t.Fatalf("read %d credentials, want found %v", len(credentials), tt.found)
+			}
+
+			status, body = request(t, app, fiber.MethodGet, "/credentials/totp/"+created.ID+"/code", tt.token, "")
+			if want := map[bool]int{true: fiber.StatusOK, false: fiber.StatusNotFound}[tt.found]; status != want {
+				t.Fatalf("code status = %d, want %d: %s", status, want, body)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package http
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetTrash godoc
+//
+//	@Summary		Get trash
+//	@Description	Get the deleted credentials of every type, the last deleted first. They are purged after trash.retention.
+//	@Tags			credentials
+//	@Accept			json
+//	@Produce		json
+//	@Param			limit	query		int		false	"Maximum number of entries (default 100, at most 1000)"
+//	@Param			offset	query		int		false	"Number of entries to skip"
+//	@Success		200		{object}	[]types.TrashedCredential
+//	@Failure		400		{object}	fiber.Map
+//	@Failure		500		{object}	fiber.Map
+//	@Router			/credentials/trash [get]
+func (c *CredentialsController) GetTrash() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		limit, offset := ctx.QueryInt("limit", defaultListLimit), ctx.QueryInt("offset")
+		if limit <= 0 || limit > maxListLimit || offset < 0 {
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "invalid limit or offset",
+			})
+		}
+
+		trash, err := c.service.GetTrash(principal(ctx).Owner(), limit, offset)
+		if err != nil {
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		return ctx.Status(fiber.StatusOK).JSON(trash)
+	}
+}
+
+// RestoreDeletedCredentials godoc
+//
+//	@Summary		Restore deleted credentials
+//	@Description	Take credentials of any type out of the trash, a creation event is produced for each one. Ids that are not in the trash are left out.
+//	@Tags			credentials
+//	@Accept			json
+//	@Produce		json
+//	@Param			ids	query		string	true	"Comma-separated list of credential IDs"
+//	@Success		200	{object}	[]map[string]any
+//	@Failure		400	{object}	fiber.Map
+//	@Failure		404	{object}	fiber.Map
+//	@Failure		500	{object}	fiber.Map
+//	@Router			/credentials/trash/restore [post]
+func (c *CredentialsController) RestoreDeletedCredentials() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		ids_query := ctx.Query("ids")
+		if ids_query == "" {
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "ids is required",
+			})
+		}
+
+		ids := strings.Split(ids_query, ",")
+		if err := c.service.Authorize(principal(ctx), ids); err != nil {
+			return ctx.Status(authorizeErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		credentials, err := c.service.RestoreDeletedCredentials(ids)
+		if err != nil {
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		return ctx.Status(fiber.StatusOK).JSON(credentials)
+	}
+}
@@ -0,0 +1,168 @@
+package http
+
+import (
+	"errors"
+
+	"github.com/DO-2K23-26/polypass-microservices/credentials/core"
+	"github.com/DO-2K23-26/polypass-microservices/credentials/types"
+	"github.com/gofiber/fiber/v2"
+)
+
+func credentialTypeParam(ctx *fiber.Ctx) (types.CredentialType, error) {
+	definition, err := credentialDefinition(ctx)
+	return definition.Type, err
+}
+
+// actor returns the subject of the principal, if any
+func actor(ctx *fiber.Ctx) *string {
+	value := principal(ctx).Subject
+	if value == "" {
+		return nil
+	}
+	return &value
+}
+
+func versionErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, core.ERR_CREDENTIAL_VERSION_NOT_FOUND):
+		return fiber.StatusNotFound
+	case errors.Is(err, core.ERR_INVALID_CREDENTIAL_TYPE):
+		return fiber.StatusBadRequest
+	case errors.Is(err, core.ERR_REAUTHENTICATION_REQUIRED):
+		return fiber.StatusUnauthorized
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
+// GetCredentialVersions godoc
+//
+//	@Summary		Get credential versions
+//	@Description	Get the version history of a credential, oldest first
+//	@Tags			versions
+//	@Accept			json
+//	@Produce		json
+//	@Param			type	path		string	true	"Credential type (password, card, sshkey or totp)"
+//	@Param			id		path		string	true	"Credential ID"
+//	@Success		200		{object}	[]types.CredentialVersion
+//	@Failure		400		{object}	fiber.Map
+//	@Failure		404		{object}	fiber.Map
+//	@Router			/credentials/{type}/{id}/versions [get]
+func (c *CredentialsController) GetCredentialVersions() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		credentialType, err := credentialTypeParam(ctx)
+		if err != nil {
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		if err := c.service.Authorize(principal(ctx), []string{ctx.Params("id")}); err != nil {
+			return ctx.Status(authorizeErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		versions, err := c.service.GetCredentialVersions(credentialType, ctx.Params("id"))
+		if err != nil {
+			return ctx.Status(versionErrorStatus(err)).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		return ctx.Status(fiber.StatusOK).JSON(versions)
+	}
+}
+
+// DiffCredentialVersions godoc
+//
+//	@Summary		Diff credential versions
+//	@Description	Compare two versions of a credential field by field, secret fields are masked unless reveal is true. A revealed diff is recorded in the access log like a reveal, and requires a recent authentication when reveal.max_auth_age is set.
+//	@Tags			versions
+//	@Accept			json
+//	@Produce		json
+//	@Param			type	path		string	true	"Credential type (password, card, sshkey or totp)"
+//	@Param			id		path		string	true	"Credential ID"
+//	@Param			from	query		int		true	"Version to compare from"
+//	@Param			to		query		int		true	"Version to compare to"
+//	@Param			reveal	query		bool	false	"Show secret fields in clear"
+//	@Success		200		{object}	types.CredentialVersionDiff
+//	@Failure		400		{object}	fiber.Map
+//	@Failure		401		{object}	fiber.Map
+//	@Failure		404		{object}	fiber.Map
+//	@Router			/credentials/{type}/{id}/versions/diff [get]
+func (c *CredentialsController) DiffCredentialVersions() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		credentialType, err := credentialTypeParam(ctx)
+		if err != nil {
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		from, to := ctx.QueryInt("from"), ctx.QueryInt("to")
+		if from <= 0 || to <= 0 {
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "from and to are required",
+			})
+		}
+
+		if err := c.service.Authorize(principal(ctx), []string{ctx.Params("id")}); err != nil {
+			return ctx.Status(authorizeErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		diffAccess := access(ctx)
+		diffAccess.Reveal = ctx.QueryBool("reveal")
+		diff, err := c.service.DiffCredentialVersions(credentialType, ctx.Params("id"), from, to, diffAccess)
+		if err != nil {
+			return ctx.Status(versionErrorStatus(err)).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		if diffAccess.Reveal {
+			// secrets in clear must not be kept by browsers or proxies
+			ctx.Set(fiber.HeaderCacheControl, "no-store")
+		}
+
+		return ctx.Status(fiber.StatusOK).JSON(diff)
+	}
+}
+
+// RestoreCredentialVersion godoc
+//
+//	@Summary		Restore credential version
+//	@Description	Write an old version of a credential back, it is recorded as a new version
+//	@Tags			versions
+//	@Accept			json
+//	@Produce		json
+//	@Param			type	path		string	true	"Credential type (password, card, sshkey or totp)"
+//	@Param			id		path		string	true	"Credential ID"
+//	@Param			version	path		int		true	"Version to restore"
+//	@Success		200		{object}	fiber.Map
+//	@Failure		400		{object}	fiber.Map
+//	@Failure		404		{object}	fiber.Map
+//	@Failure		500		{object}	fiber.Map
+//	@Router			/credentials/{type}/{id}/versions/{version}/restore [post]
+func (c *CredentialsController) RestoreCredentialVersion() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		credentialType, err := credentialTypeParam(ctx)
+		if err != nil {
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		version, err := ctx.ParamsInt("version")
+		if err != nil || version <= 0 {
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "invalid version",
+			})
+		}
+
+		if err := c.service.Authorize(principal(ctx), []string{ctx.Params("id")}); err != nil {
+			return ctx.Status(authorizeErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		cred, err := c.service.RestoreCredentialVersion(credentialType, ctx.Params("id"), version, actor(ctx))
+		if err != nil {
+			return ctx.Status(versionErrorStatus(err)).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		return ctx.Status(fiber.StatusOK).JSON(cred)
+	}
+}
@@ -0,0 +1,12 @@
+package outbox
+
+import "time"
+
+type Config struct {
+	//time between two relays when the outbox is empty, e.g. "1s"
+	Interval time.Duration `mapstructure:"interval"`
+	//number of messages published per transaction
+	BatchSize int `mapstructure:"batch_size"`
+	//how long sent messages are kept in the outbox, e.g. "168h"
+	Retention time.Duration `mapstructure:"retention"`
+}
@@ -0,0 +1,103 @@
+package outbox
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/optique-dev/optique"
+)
+
+// Outbox is the table credential events are written to with the change they describe
+type Outbox interface {
+	RelayOutbox(batchSize int) (int, error)
+	PurgeOutbox(before time.Time) (int64, error)
+}
+
+// Relay publishes the messages of the outbox to Kafka. Full batches are relayed one after the other,
+// then the relay waits for the interval. Sent messages are purged once a day.
+type Relay struct {
+	outbox    Outbox
+	interval  time.Duration
+	batchSize int
+	retention time.Duration
+	purgedAt  time.Time
+	stop      chan struct{}
+	stopOnce  sync.Once
+}
+
+func NewRelay(config Config, outbox Outbox) *Relay {
+	interval := config.Interval
+	if interval <= 0 {
+		interval = time.Second
+	}
+	batchSize := config.BatchSize
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+	retention := config.Retention
+	if retention <= 0 {
+		retention = 7 * 24 * time.Hour
+	}
+	return &Relay{
+		outbox:    outbox,
+		interval:  interval,
+		batchSize: batchSize,
+		retention: retention,
+		stop:      make(chan struct{}),
+	}
+}
+
+func (r *Relay) Ignite() error {
+	optique.Info(fmt.Sprintf("Outbox relay started, every %s by batches of %d", r.interval, r.batchSize))
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		r.relay()
+		r.purge()
+		select {
+		case <-ticker.C:
+		case <-r.stop:
+			return nil
+		}
+	}
+}
+
+func (r *Relay) relay() {
+	for {
+		sent, err := r.outbox.RelayOutbox(r.batchSize)
+		if err != nil {
+			optique.Error(fmt.Sprintf("outbox relay failed: %s", err))
+			return
+		}
+		if sent < r.batchSize {
+			return
+		}
+		select {
+		case <-r.stop:
+			return
+		default:
+		}
+	}
+}
+
+func (r *Relay) purge() {
+	if time.Since(r.purgedAt) < 24*time.Hour {
+		return
+	}
+	purged, err := r.outbox.PurgeOutbox(time.Now().Add(-r.retention))
+	if err != nil {
+		optique.Error(fmt.Sprintf("outbox purge failed: %s", err))
+		return
+	}
+	r.purgedAt = time.Now()
+	if purged > 0 {
+		optique.Info(fmt.Sprintf("outbox purge: %d sent messages deleted", purged))
+	}
+}
+
+func (r *Relay) Stop() error {
+	r.stopOnce.Do(func() { close(r.stop) })
+	return nil
+}
@@ -0,0 +1,12 @@
+package trash
+
+import "time"
+
+type Config struct {
+	//how long deleted credentials stay in the trash before they are purged, e.g. "720h"
+	Retention time.Duration `mapstructure:"retention"`
+	//time between two purges, e.g. "1h"
+	Interval time.Duration `mapstructure:"interval"`
+	//number of credentials purged per transaction
+	BatchSize int `mapstructure:"batch_size"`
+}
@@ -0,0 +1,73 @@
+package trash
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/DO-2K23-26/polypass-microservices/credentials/core"
+	"github.com/optique-dev/optique"
+)
+
+// Purger hard deletes the credentials that stayed in the trash longer than the retention, the first
+// purge runs on start
+type Purger struct {
+	service   core.CredentialsService
+	retention time.Duration
+	interval  time.Duration
+	batchSize int
+	stop      chan struct{}
+	stopOnce  sync.Once
+}
+
+func NewPurger(config Config, service core.CredentialsService) *Purger {
+	retention := config.Retention
+	if retention <= 0 {
+		retention = 30 * 24 * time.Hour
+	}
+	interval := config.Interval
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	batchSize := config.BatchSize
+	if batchSize <= 0 {
+		batchSize = 1000
+	}
+	return &Purger{
+		service:   service,
+		retention: retention,
+		interval:  interval,
+		batchSize: batchSize,
+		stop:      make(chan struct{}),
+	}
+}
+
+func (p *Purger) Ignite() error {
+	optique.Info(fmt.Sprintf("Trash purger started, every %s with a retention of %s", p.interval, p.retention))
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		p.purge()
+		select {
+		case <-ticker.C:
+		case <-p.stop:
+			return nil
+		}
+	}
+}
+
+func (p *Purger) purge() {
+	purged, err := p.service.PurgeTrash(time.Now().Add(-p.retention), p.batchSize)
+	if err != nil {
+		optique.Error(fmt.Sprintf("trash purge failed: %s", err))
+	}
+	if purged > 0 {
+		optique.Info(fmt.Sprintf("trash purge: %d credentials deleted", purged))
+	}
+}
+
+func (p *Purger) Stop() error {
+	p.stopOnce.Do(func() { close(p.stop) })
+	return nil
+}
@@ -0,0 +1,181 @@
+// Package archive seals a whole vault into a single file protected by a passphrase. The key is derived
+// with Argon2id and the content is encrypted with AES-256-GCM, the header is authenticated too.
+//
+// Layout: magic (8 bytes) | format version (1) | argon2 time (4) | argon2 memory in KiB (4) |
+// argon2 threads (1) | salt (16) | nonce (12) | ciphertext of the gzipped JSON vault
+package archive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/DO-2K23-26/polypass-microservices/credentials/types"
+	"golang.org/x/crypto/argon2"
+)
+
+var (
+	ERR_INVALID_ARCHIVE      error = errors.New("invalid archive")
+	ERR_WRONG_PASSPHRASE     error = errors.New("wrong passphrase or corrupted archive")
+	ERR_PASSPHRASE_TOO_SHORT error = errors.New("passphrase must be at least 12 characters")
+	ERR_ARCHIVE_TOO_LARGE    error = errors.New("archive content is too large")
+)
+
+const (
+	magic         = "PPVAULT\x00"
+	formatVersion = 1
+	headerSize    = len(magic) + 1 + 4 + 4 + 1 + saltSize + nonceSize
+	saltSize      = 16
+	nonceSize     = 12
+	keySize       = 32
+
+	MinPassphraseLength = 12
+)
+
+// Params are the Argon2id cost parameters, they are written in the header of every archive
+type Params struct {
+	Time    uint32
+	Memory  uint32
+	Threads uint8
+}
+
+// DefaultParams follow the second recommended option of RFC 9106
+var DefaultParams = Params{Time: 3, Memory: 64 * 1024, Threads: 4}
+
+// limits on the parameters read from an archive, so that a crafted header cannot exhaust the service:
+// every import derives a key with them, they stay close to DefaultParams
+const (
+	maxTime    = 4
+	maxMemory  = 128 * 1024
+	maxThreads = 8
+)
+
+// maxContentSize bounds the decompressed content of an archive, so that a small gzip stream cannot
+// expand without limit. Seal refuses the vaults that would not open.
+var maxContentSize int64 = 256 << 20
+
+// Vault is the content of an archive
+type Vault struct {
+	FormatVersion int                        `json:"format_version"`
+	ExportedAt    time.Time                  `json:"exported_at"`
+	Credentials   []types.ArchivedCredential `json:"credentials"`
+}
+
+// Seal encrypts a vault with a key derived from the passphrase
+func Seal(vault Vault, passphrase string, params Params) ([]byte, error) {
+	if len([]rune(passphrase)) < MinPassphraseLength {
+		return nil, ERR_PASSPHRASE_TOO_SHORT
+	}
+	vault.FormatVersion = formatVersion
+
+	content, err := json.Marshal(vault)
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(content)) > maxContentSize {
+		return nil, ERR_ARCHIVE_TOO_LARGE
+	}
+	var plaintext bytes.Buffer
+	compressor := gzip.NewWriter(&plaintext)
+	if _, err := compressor.Write(content); err != nil {
+		return nil, err
+	}
+	if err := compressor.Close(); err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, 0, headerSize)
+	header = append(header, magic...)
+	header = append(header, formatVersion)
+	header = binary.BigEndian.AppendUint32(header, params.Time)
+	header = binary.BigEndian.AppendUint32(header, params.Memory)
+	header = append(header, params.Threads)
+	random := make([]byte, saltSize+nonceSize)
+	if _, err := rand.Read(random); err != nil {
+		return nil, err
+	}
+	header = append(header, random...)
+
+	aead, err := newAEAD(passphrase, header)
+	if err != nil {
+		return nil, err
+	}
+	nonce := header[headerSize-nonceSize:]
+	return aead.Seal(header, nonce, plaintext.Bytes(), header), nil
+}
+
+// Open decrypts an archive made by Seal
+func Open(data []byte, passphrase string) (Vault, error) {
+	var vault Vault
+	if len(data) < headerSize || string(data[:len(magic)]) != magic || data[len(magic)] != formatVersion {
+		return vault, ERR_INVALID_ARCHIVE
+	}
+	header := data[:headerSize]
+	aead, err := newAEAD(passphrase, header)
+	if err != nil {
+		return vault, err
+	}
+	plaintext, err := aead.Open(nil, header[headerSize-nonceSize:], data[headerSize:], header)
+	if err != nil {
+		return vault, ERR_WRONG_PASSPHRASE
+	}
+
+	decompressor, err := gzip.NewReader(bytes.NewReader(plaintext))
+	if err != nil {
+		return vault, ERR_INVALID_ARCHIVE
+	}
+	defer decompressor.Close()
+	decoder := json.NewDecoder(&limitedReader{reader: decompressor, remaining: maxContentSize})
+	decoder.UseNumber()
+	if err := decoder.Decode(&vault); errors.Is(err, ERR_ARCHIVE_TOO_LARGE) {
+		return vault, err
+	} else if err != nil {
+		return vault, ERR_INVALID_ARCHIVE
+	}
+	return vault, nil
+}
+
+// newAEAD derives the key from the passphrase with the parameters and salt of the header
+func newAEAD(passphrase string, header []byte) (cipher.AEAD, error) {
+	params := header[len(magic)+1:]
+	time := binary.BigEndian.Uint32(params[0:4])
+	memory := binary.BigEndian.Uint32(params[4:8])
+	threads := params[8]
+	if time == 0 || time > maxTime || memory < 8*uint32(threads) || memory > maxMemory || threads == 0 || threads > maxThreads {
+		return nil, ERR_INVALID_ARCHIVE
+	}
+	salt := params[9 : 9+saltSize]
+
+	key := argon2.IDKey([]byte(passphrase), salt, time, memory, threads, keySize)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// limitedReader fails with ERR_ARCHIVE_TOO_LARGE once more than remaining bytes were asked for, where
+// io.LimitReader would end the content as if it were complete
+type limitedReader struct {
+	reader    io.Reader
+	remaining int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.remaining <= 0 {
+		return 0, ERR_ARCHIVE_TOO_LARGE
+	}
+	if int64(len(p)) > l.remaining {
+		p = p[:l.remaining]
+	}
+	n, err := l.reader.Read(p)
+	l.remaining -= int64(n)
+	return n, err
+}
@@ -0,0 +1,144 @@
+package archive
+
+import (
+	"encoding/binary"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DO-2K23-26/polypass-microservices/credentials/types"
+)
+
+// testParams keep the key derivation cheap, the parameters are read back from the header anyway
+var testParams = Params{Time: 1, Memory: 64, Threads: 1}
+
+const testPassphrase = "correct horse battery staple"
+
+func testVault() Vault {
+	return Vault{
+		ExportedAt: time.Date(2026, time.October, 18, 12, 0, 0, 0, time.UTC),
+		Credentials: []types.ArchivedCredential{{
+			Credential: types.GenericCredential{
+				Credential: types.Credential{Title: "Mail", Note: "personal"},
+				Type:       types.CredentialTypePassword,
+				Attributes: map[string]any{"user_identifier": "alice", "password": "hunter2", "domain_name": "mail.example.com"},
+			},
+			Versions: []types.ArchivedVersion{{Data: []byte(`{"password":"hunter1"}`)}},
+		}},
+	}
+}
+
+func TestSealOpenRoundTrip(t *testing.T) {
+	tests := []struct {
+		name       string
+		passphrase string
+	}{
+		{"ascii", testPassphrase},
+		{"unicode", "phrase de passe très longue 🔐"},
+		{"minimum length", "123456789012"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := Seal(testVault(), tt.passphrase, testParams)
+			if err != nil {
+				t.Fatalf("seal: %v", err)
+			}
+			vault, err := Open(data, tt.passphrase)
+			if err != nil {
+				t.Fatalf("open: %v", err)
+			}
+			if vault.FormatVersion != formatVersion {
+				t.Fatalf("format version = %d, want %d", vault.FormatVersion, formatVersion)
+			}
+			if !vault.ExportedAt.Equal(testVault().ExportedAt) {
+				t.Fatalf("exported at = %s, want %s", vault.ExportedAt, testVault().ExportedAt)
+			}
+			if len(vault.Credentials) != 1 {
+				t.Fatalf("got %d credentials, want 1", len(vault.Credentials))
+			}
+			credential := vault.Credentials[0]
+			if credential.Credential.Title != "Mail" || credential.Credential.Attributes["password"] != "hunter2" {
+				t.Fatalf("credential = %+v", credential.Credential)
+			}
+			if len(credential.Versions) != 1 || string(credential.Versions[0].Data) != `{"password":"hunter1"}` {
+				t.Fatalf("versions = %+v", credential.Versions)
+			}
+		})
+	}
+}
+
+func TestSealShortPassphrase(t *testing.T) {
+	// the length is counted in characters, not bytes
+	for _, passphrase := range []string{"", "12345678901", "ééééééééééé"} {
+		if _, err := Seal(testVault(), passphrase, testParams); !errors.Is(err, ERR_PASSPHRASE_TOO_SHORT) {
+			t.Fatalf("Seal(%q) error = %v, want %v", passphrase, err, ERR_PASSPHRASE_TOO_SHORT)
+		}
+	}
+}
+
+func TestOpenErrors(t *testing.T) {
+	data, err := Seal(testVault(), testPassphrase, testParams)
+	if err != nil {
+		t.Fatalf("seal: %v", err)
+	}
+	modified := func(change func([]byte)) []byte {
+		copied := append([]byte(nil), data...)
+		change(copied)
+		return copied
+	}
+	paramsOffset := len(magic) + 1
+
+	tests := []struct {
+		name       string
+		data       []byte
+		passphrase string
+		err        error
+	}{
+		{"wrong passphrase", data, "wrong horse battery staple", ERR_WRONG_PASSPHRASE},
+		{"empty passphrase", data, "", ERR_WRONG_PASSPHRASE},
+		{"flipped ciphertext", modified(func(b []byte) { b[len(b)-20] ^= 0x01 }), testPassphrase, ERR_WRONG_PASSPHRASE},
+		{"flipped salt", modified(func(b []byte) { b[paramsOffset+9] ^= 0x01 }), testPassphrase, ERR_WRONG_PASSPHRASE},
+		{"truncated", data[:len(data)-1], testPassphrase, ERR_WRONG_PASSPHRASE},
+		{"header only", data[:headerSize], testPassphrase, ERR_WRONG_PASSPHRASE},
+		{"shorter than the header", data[:headerSize-1], testPassphrase, ERR_INVALID_ARCHIVE},
+		{"empty", nil, testPassphrase, ERR_INVALID_ARCHIVE},
+		{"other magic", modified(func(b []byte) { b[0] = 'X' }), testPassphrase, ERR_INVALID_ARCHIVE},
+		{"other format version", modified(func(b []byte) { b[len(magic)] = formatVersion + 1 }), testPassphrase, ERR_INVALID_ARCHIVE},
+		{"zero time", modified(func(b []byte) { binary.BigEndian.PutUint32(b[paramsOffset:], 0) }), testPassphrase, ERR_INVALID_ARCHIVE},
+		{"huge memory", modified(func(b []byte) { binary.BigEndian.PutUint32(b[paramsOffset+4:], maxMemory+1) }), testPassphrase, ERR_INVALID_ARCHIVE},
+		{"zero threads", modified(func(b []byte) { b[paramsOffset+8] = 0 }), testPassphrase, ERR_INVALID_ARCHIVE},
+		{"too many passes", modified(func(b []byte) { binary.BigEndian.PutUint32(b[paramsOffset:], maxTime+1) }), testPassphrase, ERR_INVALID_ARCHIVE},
+		{"too many threads", modified(func(b []byte) { b[paramsOffset+8] = maxThreads + 1 }), testPassphrase, ERR_INVALID_ARCHIVE},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Open(tt.data, tt.passphrase); !errors.Is(err, tt.err) {
+				t.Fatalf("open error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestContentSizeLimit(t *testing.T) {
+	vault := testVault()
+	for i := 0; i < 200; i++ {
+		vault.Credentials = append(vault.Credentials, vault.Credentials[0])
+	}
+	data, err := Seal(vault, testPassphrase, testParams)
+	if err != nil {
+		t.Fatalf("seal: %v", err)
+	}
+
+	defer func(limit int64) { maxContentSize = limit }(maxContentSize)
+	maxContentSize = 4096
+	// the content compresses well below the limit, it is only caught once decompressed
+	if len(data) >= int(maxContentSize) {
+		t.Fatalf("archive of %d bytes does not compress below the limit", len(data))
+	}
+	if _, err := Open(data, testPassphrase); !errors.Is(err, ERR_ARCHIVE_TOO_LARGE) {
+		t.Fatalf("open error = %v, want %v", err, ERR_ARCHIVE_TOO_LARGE)
+	}
+	if _, err := Seal(vault, testPassphrase, testParams); !errors.Is(err, ERR_ARCHIVE_TOO_LARGE) {
+		t.Fatalf("seal error = %v, want %v", err, ERR_ARCHIVE_TOO_LARGE)
+	}
+}
@@ -0,0 +1,213 @@
+// Package card holds the rules of payment cards: Luhn checksum, brand detection from the issuer
+// identification number and MM/YY expiry dates.
+package card
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ERR_INVALID_NUMBER error = errors.New("invalid card number: must be 12 to 19 digits")
+	ERR_INVALID_LUHN   error = errors.New("invalid card number: checksum does not match")
+	ERR_INVALID_EXPIRY error = errors.New("invalid expiry date format: expected MM/YY")
+	ERR_EXPIRED        error = errors.New("expired card")
+)
+
+type Brand string
+
+const (
+	BrandVisa       Brand = "visa"
+	BrandMastercard Brand = "mastercard"
+	BrandAmex       Brand = "amex"
+	BrandDiscover   Brand = "discover"
+	BrandDiners     Brand = "diners"
+	BrandJCB        Brand = "jcb"
+	BrandUnionPay   Brand = "unionpay"
+	BrandMaestro    Brand = "maestro"
+	BrandUnknown    Brand = "unknown"
+)
+
+// brandRange is a range of issuer identification numbers, given on their first digits
+type brandRange struct {
+	brand    Brand
+	from, to int
+	digits   int
+	lengths  []int
+}
+
+// ranges are checked in order, the more specific ones first
+var ranges = []brandRange{
+	{BrandAmex, 34, 34, 2, []int{15}},
+	{BrandAmex, 37, 37, 2, []int{15}},
+	{BrandDiners, 300, 305, 3, []int{14, 16, 17, 18, 19}},
+	{BrandDiners, 36, 36, 2, []int{14, 15, 16, 17, 18, 19}},
+	{BrandDiners, 38, 39, 2, []int{16, 17, 18, 19}},
+	{BrandDiscover, 6011, 6011, 4, []int{16, 17, 18, 19}},
+	{BrandDiscover, 644, 649, 3, []int{16, 17, 18, 19}},
+	{BrandDiscover, 65, 65, 2, []int{16, 17, 18, 19}},
+	{BrandJCB, 3528, 3589, 4, []int{16, 17, 18, 19}},
+	{BrandMastercard, 2221, 2720, 4, []int{16}},
+	{BrandMastercard, 51, 55, 2, []int{16}},
+	{BrandUnionPay, 62, 62, 2, []int{16, 17, 18, 19}},
+	{BrandMaestro, 5018, 5018, 4, []int{12, 13, 14, 15, 16, 17, 18, 19}},
+	{BrandMaestro, 5020, 5020, 4, []int{12, 13, 14, 15, 16, 17, 18, 19}},
+	{BrandMaestro, 5038, 5038, 4, []int{12, 13, 14, 15, 16, 17, 18, 19}},
+	{BrandMaestro, 5893, 5893, 4, []int{12, 13, 14, 15, 16, 17, 18, 19}},
+	{BrandMaestro, 6304, 6304, 4, []int{12, 13, 14, 15, 16, 17, 18, 19}},
+	{BrandMaestro, 6759, 6763, 4, []int{12, 13, 14, 15, 16, 17, 18, 19}},
+	{BrandVisa, 4, 4, 1, []int{13, 16, 19}},
+}
+
+// Normalize removes the spaces and dashes a card number is often written with
+func Normalize(number string) string {
+	return strings.NewReplacer(" ", "", "-", "").Replace(strings.TrimSpace(number))
+}
+
+// Validate checks the length and the Luhn checksum of a normalized card number
+func Validate(number string) error {
+	if len(number) < 12 || len(number) > 19 || strings.Trim(number, "0123456789") != "" {
+		return ERR_INVALID_NUMBER
+	}
+	if !Luhn(number) {
+		return ERR_INVALID_LUHN
+	}
+	return nil
+}
+
+// Luhn tells whether the last digit of number is its Luhn check digit
+func Luhn(number string) bool {
+	sum := 0
+	double := false
+	for i := len(number) - 1; i >= 0; i-- {
+		digit := int(number[i] - '0')
+		if double {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// DetectBrand returns the brand issuing a normalized card number, unknown when no range matches its
+// prefix and length
+func DetectBrand(number string) Brand {
+	for _, r := range ranges {
+		if len(number) < r.digits {
+			continue
+		}
+		prefix, err := strconv.Atoi(number[:r.digits])
+		if err != nil || prefix < r.from || prefix > r.to {
+			continue
+		}
+		for _, length := range r.lengths {
+			if len(number) == length {
+				return r.brand
+			}
+		}
+	}
+	return BrandUnknown
+}
+
+// CVCLength is the number of digits of the security code of a brand, 0 when it is not known
+func CVCLength(brand Brand) int {
+	switch brand {
+	case BrandAmex:
+		return 4
+	case BrandUnknown:
+		return 0
+	default:
+		return 3
+	}
+}
+
+// ValidateCVC checks a security code against the length of the brand, 3 or 4 digits for unknown brands
+func ValidateCVC(brand Brand, cvc string) error {
+	if strings.Trim(cvc, "0123456789") != "" || cvc == "" {
+		return errors.New("CVC must be digits")
+	}
+	length := CVCLength(brand)
+	if length == 0 {
+		if len(cvc) != 3 && len(cvc) != 4 {
+			return errors.New("CVC must be 3 or 4 digits")
+		}
+		return nil
+	}
+	if len(cvc) != length {
+		return fmt.Errorf("CVC must be %d digits for %s cards", length, brand)
+	}
+	return nil
+}
+
+// LastFour returns the last four digits of a card number, the only ones shown unless it is revealed
+func LastFour(number string) string {
+	if len(number) <= 4 {
+		return number
+	}
+	return number[len(number)-4:]
+}
+
+// Mask hides every digit of a card number but the last four
+func Mask(number string) string {
+	if len(number) <= 4 {
+		return number
+	}
+	return strings.Repeat("*", len(number)-4) + LastFour(number)
+}
+
+var (
+	monthYear = regexp.MustCompile(`^(\d{1,2})\s*/\s*(\d{2}|\d{4})$`)
+	isoDate   = regexp.MustCompile(`^(\d{4})-(\d{2})-\d{2}$`)
+)
+
+const expiryLayout = "01/06"
+
+// Expiry is the month a card expires at the end of
+type Expiry struct {
+	Month time.Month
+	Year  int
+}
+
+// ParseExpiry reads MM/YY or MM/YYYY, and YYYY-MM-DD as written by older versions
+func ParseExpiry(value string) (Expiry, error) {
+	value = strings.TrimSpace(value)
+	var month, year int
+	if match := monthYear.FindStringSubmatch(value); match != nil {
+		month, _ = strconv.Atoi(match[1])
+		year, _ = strconv.Atoi(match[2])
+	} else if match := isoDate.FindStringSubmatch(value); match != nil {
+		year, _ = strconv.Atoi(match[1])
+		month, _ = strconv.Atoi(match[2])
+	} else {
+		return Expiry{}, ERR_INVALID_EXPIRY
+	}
+	if month < 1 || month > 12 {
+		return Expiry{}, ERR_INVALID_EXPIRY
+	}
+	if year < 100 {
+		year += 2000
+	}
+	return Expiry{Month: time.Month(month), Year: year}, nil
+}
+
+// String formats the expiry as MM/YY
+func (e Expiry) String() string {
+	return time.Date(e.Year, e.Month, 1, 0, 0, 0, 0, time.UTC).Format(expiryLayout)
+}
+
+// End is the first instant the card is no longer valid, the card can be used until the end of its month
+func (e Expiry) End() time.Time {
+	return time.Date(e.Year, e.Month+1, 1, 0, 0, 0, 0, time.UTC)
+}
+
+func (e Expiry) Expired(now time.Time) bool {
+	return !now.Before(e.End())
+}
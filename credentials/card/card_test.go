@@ -0,0 +1,204 @@
+package card
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		number string
+		err    error
+	}{
+		{"visa", "4111111111111111", nil},
+		{"visa 13 digits", "4222222222222", nil},
+		{"amex", "378282246310005", nil},
+		{"mastercard 2 series", "2223003122003222", nil},
+		{"12 digits", "501800000009", nil},
+		{"19 digits", "6200000000000000000", nil},
+		{"wrong check digit", "4111111111111112", ERR_INVALID_LUHN},
+		{"swapped digits", "4111111111111161", ERR_INVALID_LUHN},
+		{"too short", "41111111111", ERR_INVALID_NUMBER},
+		{"too long", "41111111111111111111", ERR_INVALID_NUMBER},
+		{"not normalized", "4111 1111 1111 1111", ERR_INVALID_NUMBER},
+		{"letters", "4111111111111a11", ERR_INVALID_NUMBER},
+		{"empty", "", ERR_INVALID_NUMBER},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Validate(tt.number); !errors.Is(err, tt.err) {
+				t.Fatalf("Validate(%s) = %v, want %v", tt.number, err, tt.err)
+			}
+		})
+	}
+}
+
+func TestLuhn(t *testing.T) {
+	tests := []struct {
+		number string
+		valid  bool
+	}{
+		{"0", true},
+		{"18", true},
+		{"79927398713", true},
+		{"79927398710", false},
+		{"5555555555554444", true},
+		{"5555555555554445", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.number, func(t *testing.T) {
+			if valid := Luhn(tt.number); valid != tt.valid {
+				t.Fatalf("Luhn(%s) = %v, want %v", tt.number, valid, tt.valid)
+			}
+		})
+	}
+}
+
+func TestDetectBrand(t *testing.T) {
+	tests := []struct {
+		name   string
+		number string
+		brand  Brand
+	}{
+		{"visa 16", "4111111111111111", BrandVisa},
+		{"visa 13", "4222222222222", BrandVisa},
+		{"visa 19", "4000000000000000006", BrandVisa},
+		{"visa 15 digits", "411111111111111", BrandUnknown},
+		{"mastercard 51", "5105105105105100", BrandMastercard},
+		{"mastercard 55", "5555555555554444", BrandMastercard},
+		{"mastercard 2221", "2221000000000009", BrandMastercard},
+		{"mastercard 2720", "2720990000000000", BrandMastercard},
+		{"below mastercard 2 series", "2220990000000000", BrandUnknown},
+		{"above mastercard 2 series", "2721000000000000", BrandUnknown},
+		{"mastercard 15 digits", "555555555555444", BrandUnknown},
+		{"amex 34", "340000000000009", BrandAmex},
+		{"amex 37", "378282246310005", BrandAmex},
+		{"amex 16 digits", "3782822463100050", BrandUnknown},
+		{"discover 6011", "6011111111111117", BrandDiscover},
+		{"discover 644", "6440000000000000", BrandDiscover},
+		{"discover 649", "6490000000000000", BrandDiscover},
+		{"discover 65", "6500000000000002", BrandDiscover},
+		{"diners 300", "30000000000004", BrandDiners},
+		{"diners 305", "30569309025904", BrandDiners},
+		{"diners 36", "36227206271667", BrandDiners},
+		{"diners 38", "3800000000000000", BrandDiners},
+		{"jcb 3528", "3528000000000000", BrandJCB},
+		{"jcb 3589", "3589000000000000", BrandJCB},
+		{"below jcb", "3527000000000000", BrandUnknown},
+		{"unionpay", "6200000000000005", BrandUnionPay},
+		{"maestro 5018", "501800000009", BrandMaestro},
+		{"maestro 6759", "6759649826438453", BrandMaestro},
+		{"maestro 6763", "6763000000000000", BrandMaestro},
+		{"unknown prefix", "9999999999999995", BrandUnknown},
+		{"too short for any range", "4", BrandUnknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if brand := DetectBrand(tt.number); brand != tt.brand {
+				t.Fatalf("DetectBrand(%s) = %s, want %s", tt.number, brand, tt.brand)
+			}
+		})
+	}
+}
+
+func TestValidateCVC(t *testing.T) {
+	tests := []struct {
+		name  string
+		brand Brand
+		cvc   string
+		valid bool
+	}{
+		{"visa", BrandVisa, "123", true},
+		{"visa 4 digits", BrandVisa, "1234", false},
+		{"amex", BrandAmex, "1234", true},
+		{"amex 3 digits", BrandAmex, "123", false},
+		{"unknown 3 digits", BrandUnknown, "123", true},
+		{"unknown 4 digits", BrandUnknown, "1234", true},
+		{"unknown 5 digits", BrandUnknown, "12345", false},
+		{"letters", BrandVisa, "12a", false},
+		{"empty", BrandVisa, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateCVC(tt.brand, tt.cvc); (err == nil) != tt.valid {
+				t.Fatalf("ValidateCVC(%s, %s) = %v, want valid %v", tt.brand, tt.cvc, err, tt.valid)
+			}
+		})
+	}
+}
+
+func TestMask(t *testing.T) {
+	tests := []struct {
+		number string
+		masked string
+	}{
+		{"4111111111111111", "************1111"},
+		{"378282246310005", "***********0005"},
+		{"1234", "1234"},
+		{"12", "12"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.number, func(t *testing.T) {
+			if masked := Mask(tt.number); masked != tt.masked {
+				t.Fatalf("Mask(%s) = %s, want %s", tt.number, masked, tt.masked)
+			}
+		})
+	}
+}
+
+func TestParseExpiry(t *testing.T) {
+	tests := []struct {
+		value  string
+		expiry Expiry
+		err    error
+	}{
+		{"09/27", Expiry{time.September, 2027}, nil},
+		{"9/27", Expiry{time.September, 2027}, nil},
+		{"12 / 2030", Expiry{time.December, 2030}, nil},
+		{"2026-02-01", Expiry{time.February, 2026}, nil},
+		{" 01/29 ", Expiry{time.January, 2029}, nil},
+		{"13/27", Expiry{}, ERR_INVALID_EXPIRY},
+		{"00/27", Expiry{}, ERR_INVALID_EXPIRY},
+		{"0927", Expiry{}, ERR_INVALID_EXPIRY},
+		{"09/273", Expiry{}, ERR_INVALID_EXPIRY},
+		{"", Expiry{}, ERR_INVALID_EXPIRY},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			expiry, err := ParseExpiry(tt.value)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("ParseExpiry(%q) error = %v, want %v", tt.value, err, tt.err)
+			}
+			if expiry != tt.expiry {
+				t.Fatalf("ParseExpiry(%q) = %+v, want %+v", tt.value, expiry, tt.expiry)
+			}
+		})
+	}
+}
+
+func TestExpiryExpired(t *testing.T) {
+	expiry := Expiry{Month: time.February, Year: 2027}
+	if got := expiry.String(); got != "02/27" {
+		t.Fatalf("String() = %s, want 02/27", got)
+	}
+
+	tests := []struct {
+		name    string
+		now     time.Time
+		expired bool
+	}{
+		{"before the month", time.Date(2027, time.January, 31, 23, 59, 59, 0, time.UTC), false},
+		{"last day of the month", time.Date(2027, time.February, 28, 23, 59, 59, 0, time.UTC), false},
+		{"first day after", time.Date(2027, time.March, 1, 0, 0, 0, 0, time.UTC), true},
+		{"years later", time.Date(2030, time.June, 1, 0, 0, 0, 0, time.UTC), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if expired := expiry.Expired(tt.now); expired != tt.expired {
+				t.Fatalf("Expired(%s) = %v, want %v", tt.now, expired, tt.expired)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"strings"
+
+	"github.com/DO-2K23-26/polypass-microservices/credentials/core"
+	"github.com/DO-2K23-26/polypass-microservices/credentials/types"
+)
+
+const cliUsage = `usage:
+  credentials                  start the service
+  credentials export <file>    export the vault into a passphrase protected archive
+  credentials import <file>    restore the credentials of an archive
+
+The passphrase is read from POLYPASS_ARCHIVE_PASSPHRASE, or from the first line of stdin.`
+
+// runCommand runs a command given on the command line instead of the service, it returns the exit code
+func runCommand(service core.CredentialsService, args []string) int {
+	if len(args) != 2 {
+		fmt.Fprintln(os.Stderr, cliUsage)
+		return 2
+	}
+	var err error
+	switch args[0] {
+	case "export":
+		err = exportArchive(service, args[1])
+	case "import":
+		err = importArchive(service, args[1])
+	default:
+		fmt.Fprintln(os.Stderr, cliUsage)
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+func exportArchive(service core.CredentialsService, path string) error {
+	passphrase, err := readPassphrase()
+	if err != nil {
+		return err
+	}
+	data, err := service.ExportVault(passphrase, cliAccess())
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+func importArchive(service core.CredentialsService, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	passphrase, err := readPassphrase()
+	if err != nil {
+		return err
+	}
+	report, err := service.ImportVault(data, passphrase)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+func readPassphrase() (string, error) {
+	if passphrase := os.Getenv("POLYPASS_ARCHIVE_PASSPHRASE"); passphrase != "" {
+		return passphrase, nil
+	}
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	line = strings.TrimRight(line, "\r\n")
+	if line == "" {
+		return "", errors.New("no passphrase given")
+	}
+	if err != nil && len(line) == 0 {
+		return "", err
+	}
+	return line, nil
+}
+
+// cliAccess records exports made on the command line with the name of the system user
+func cliAccess() types.AccessContext {
+	access := types.AccessContext{SourceIP: "local", UserAgent: "credentials-cli"}
+	if current, err := user.Current(); err == nil {
+		access.Actor = &current.Username
+	}
+	return access
+}
@@ -2,9 +2,19 @@ package config
 
 import (
 	"fmt"
+	"time"
 
+	"github.com/DO-2K23-26/polypass-microservices/credentials/application/expiry"
+	"github.com/DO-2K23-26/polypass-microservices/credentials/application/grpc"
 	"github.com/DO-2K23-26/polypass-microservices/credentials/application/http"
+	"github.com/DO-2K23-26/polypass-microservices/credentials/application/outbox"
+	"github.com/DO-2K23-26/polypass-microservices/credentials/application/trash"
+	"github.com/DO-2K23-26/polypass-microservices/credentials/core"
+	"github.com/DO-2K23-26/polypass-microservices/credentials/infrastructure/auth"
+	"github.com/DO-2K23-26/polypass-microservices/credentials/infrastructure/breach"
+	"github.com/DO-2K23-26/polypass-microservices/credentials/infrastructure/encryption"
 	"github.com/DO-2K23-26/polypass-microservices/credentials/infrastructure/sql"
+	"github.com/DO-2K23-26/polypass-microservices/credentials/infrastructure/telemetry"
 	"github.com/optique-dev/optique"
 
 	"github.com/spf13/viper"
@@ -12,9 +22,67 @@ import (
 
 type Config struct {
 	// Bootstrap is a flag to indicate if the application should start in bootstrap mode, meaning that the cycle should setup repositories e.g. for migrations or seeding
-	Bootstrap bool        `json:"bootstrap"`
-	Database  sql.Config  `json:"database"`
-	Server    http.Config `json:"server"`
+	Bootstrap  bool              `json:"bootstrap"`
+	Database   sql.Config        `json:"database"`
+	Server     http.Config       `json:"server"`
+	Encryption encryption.Config `json:"encryption"`
+	// PasswordPolicy applies to the password of password credentials on create and update
+	PasswordPolicy core.PasswordPolicyConfig `json:"password_policy" mapstructure:"password_policy"`
+	Breach         breach.Config             `json:"breach"`
+	Expiry         expiry.Config             `json:"expiry"`
+	// Reveal restricts the endpoint returning secrets in clear
+	Reveal core.RevealConfig `json:"reveal"`
+	// Outbox is relayed to Kafka by the cycle
+	Outbox outbox.Config `json:"outbox"`
+	// Trash keeps deleted credentials until the cycle purges them
+	Trash trash.Config `json:"trash"`
+	// Grpc serves the gRPC API next to the HTTP one, see proto/credentials.proto
+	Grpc grpc.Config `json:"grpc"`
+	// Auth verifies the bearer tokens of the HTTP and gRPC APIs
+	Auth auth.Config `json:"auth"`
+	// Telemetry exports the spans of the service, metrics are served on /metrics
+	Telemetry telemetry.Config `json:"telemetry"`
+	// Cycle starts and stops the applications, see cycle.go
+	Cycle CycleConfig `json:"cycle"`
+}
+
+type CycleConfig struct {
+	//time between reporting not ready on /readyz and stopping the applications, so that orchestrators stop routing traffic first
+	DrainDelay time.Duration `mapstructure:"drain_delay"`
+	//time the applications must run without failing to be started, a required one failing meanwhile stops the service; 2s by default
+	StartPeriod time.Duration `mapstructure:"start_period"`
+	//time given to each application and repository to stop, 10s by default
+	StopTimeout time.Duration `mapstructure:"stop_timeout"`
+	//restart policy of the applications that fail once started
+	Restart RestartConfig `mapstructure:"restart"`
+	//settings of one component by name (http, grpc, outbox, trash, expiry, database, kafka...), over the ones above
+	Components map[string]ComponentConfig `mapstructure:"components"`
+}
+
+const (
+	// a failed application stays stopped, the service stops when it is required
+	RestartNever = "never"
+	// an application is restarted when it fails, not when it returns without error
+	RestartOnFailure = "on_failure"
+	// an application is restarted whenever it returns
+	RestartAlways = "always"
+)
+
+type RestartConfig struct {
+	//never, on_failure (default) or always
+	Policy string `mapstructure:"policy"`
+	//restarts of an application before it is given up, unlimited when 0
+	MaxRestarts int `mapstructure:"max_restarts"`
+	//delay before the first restart, doubled at each one up to max_backoff; 1s and 1m by default
+	Backoff    time.Duration `mapstructure:"backoff"`
+	MaxBackoff time.Duration `mapstructure:"max_backoff"`
+}
+
+type ComponentConfig struct {
+	//restart policy of the application, the one of restart when empty
+	Restart string `mapstructure:"restart"`
+	//time given to the component to stop, stop_timeout when 0
+	StopTimeout time.Duration `mapstructure:"stop_timeout"`
 }
 
 func LoadConfig() (*Config, error) {
@@ -22,6 +90,8 @@ func LoadConfig() (*Config, error) {
 	viper.AddConfigPath(".")
 	viper.SetConfigType("json")
 	viper.AutomaticEnv()
+	// running without authentication must be asked for, see auth.Config
+	viper.SetDefault("auth.enabled", true)
 
 	if err := viper.ReadInConfig(); err != nil {
 		return nil, err
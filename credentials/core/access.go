@@ -0,0 +1,67 @@
+package core
+
+import (
+	"sort"
+	"time"
+
+	"github.com/DO-2K23-26/polypass-microservices/credentials/registry"
+	"github.com/DO-2K23-26/polypass-microservices/credentials/types"
+)
+
+// recordAccess logs the read of credentials before they are returned and sets their LastReadAt. A read
+// that cannot be logged fails, so that no credential is returned without a trace.
+func (c *credentialService) recordAccess(credentials []types.GenericCredential, access types.AccessContext, fields func(types.GenericCredential) []string) error {
+	now := time.Now()
+	accesses := make([]types.CredentialAccess, 0, len(credentials))
+	for i := range credentials {
+		accesses = append(accesses, types.CredentialAccess{
+			CredentialID: credentials[i].ID,
+			Type:         credentials[i].Type,
+			Actor:        access.Actor,
+			Fields:       fields(credentials[i]),
+			SourceIP:     access.SourceIP,
+			UserAgent:    access.UserAgent,
+			ReadAt:       &now,
+		})
+		credentials[i].LastReadAt = &now
+	}
+	return c.sqlRepository.RecordCredentialAccess(accesses)
+}
+
+// lookupCredentials reads credentials for the service itself, e.g. to keep the stored seed of a TOTP
+// credential on update. It is not a read made on behalf of a caller, so nothing is recorded.
+func (c *credentialService) lookupCredentials(credentialType types.CredentialType, ids []string) ([]types.GenericCredential, error) {
+	return c.sqlRepository.GetCredentials(credentialType, ids)
+}
+
+// attributeNames are the type-specific fields of a credential as returned
+func attributeNames(credential types.GenericCredential) []string {
+	names := make([]string, 0, len(credential.Attributes))
+	for name := range credential.Attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// readFields are the attributes returned in clear, masked ones are left out unless revealed
+func readFields(reveal bool) func(types.GenericCredential) []string {
+	return func(credential types.GenericCredential) []string {
+		names := attributeNames(credential)
+		definition, err := registry.Get(credential.Type)
+		if reveal || err != nil {
+			return names
+		}
+		shown := names[:0]
+		for _, name := range names {
+			if !definition.IsMasked(name) {
+				shown = append(shown, name)
+			}
+		}
+		return shown
+	}
+}
+
+func (c *credentialService) GetCredentialAccessLog(id string, limit int, offset int) ([]types.CredentialAccess, error) {
+	return c.sqlRepository.GetCredentialAccessLog(id, limit, offset)
+}
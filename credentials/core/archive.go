@@ -0,0 +1,172 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/DO-2K23-26/polypass-microservices/credentials/archive"
+	"github.com/DO-2K23-26/polypass-microservices/credentials/infrastructure/sql"
+	"github.com/DO-2K23-26/polypass-microservices/credentials/registry"
+	"github.com/DO-2K23-26/polypass-microservices/credentials/types"
+	"github.com/optique-dev/optique"
+)
+
+// number of credentials read per batch when exporting the vault
+const exportBatchSize = 500
+
+var ERR_OWNER_REQUIRED error = errors.New("archives are exported for one owner, export the vault to include every owner")
+
+// ERR_FOREIGN_OWNER rejects an archive holding credentials of another owner than the caller
+var ERR_FOREIGN_OWNER error = errors.New("the archive holds credentials of another owner")
+
+// ERR_HISTORY_CONFLICT rejects an archive reusing the id of a purged credential whose versions remain
+var ERR_HISTORY_CONFLICT error = sql.ERR_HISTORY_CONFLICT
+
+// ExportArchive seals the credentials of the caller's owner with their version history into an archive
+// protected by the passphrase. Service identities name the owner they export for, or export the whole
+// vault with ExportVault.
+func (c *credentialService) ExportArchive(passphrase string, access types.AccessContext) ([]byte, error) {
+	if access.Owner == nil {
+		return nil, ERR_OWNER_REQUIRED
+	}
+	return c.exportArchive(passphrase, access.Owner, access)
+}
+
+// ExportVault seals every credential of every owner, it is only reachable from the command line and the
+// admin routes
+func (c *credentialService) ExportVault(passphrase string, access types.AccessContext) ([]byte, error) {
+	return c.exportArchive(passphrase, nil, access)
+}
+
+// exportArchive seals the credentials of the owner, or of every owner when it is nil. Write-only
+// attributes are included so that an import restores them, the export is recorded in the access log as
+// a read of every field.
+func (c *credentialService) exportArchive(passphrase string, owner *string, access types.AccessContext) ([]byte, error) {
+	if len([]rune(passphrase)) < archive.MinPassphraseLength {
+		return nil, archive.ERR_PASSPHRASE_TOO_SHORT
+	}
+
+	var credentials []types.GenericCredential
+	for _, definition := range registry.All() {
+		err := c.sqlRepository.ScanCredentials(definition.Type, owner, exportBatchSize, func(batch []types.GenericCredential) error {
+			credentials = append(credentials, batch...)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := c.recordAccess(credentials, access, attributeNames); err != nil {
+		return nil, err
+	}
+
+	vault := archive.Vault{ExportedAt: time.Now().UTC(), Credentials: make([]types.ArchivedCredential, 0, len(credentials))}
+	for _, credential := range credentials {
+		versions, err := c.sqlRepository.GetCredentialVersions(credential.ID)
+		if err != nil {
+			return nil, err
+		}
+		archived := types.ArchivedCredential{Credential: credential, Versions: make([]types.ArchivedVersion, 0, len(versions))}
+		for _, version := range versions {
+			archived.Versions = append(archived.Versions, types.ArchivedVersion{CredentialVersion: version, Data: version.Data})
+		}
+		vault.Credentials = append(vault.Credentials, archived)
+	}
+
+	sealed, err := archive.Seal(vault, passphrase, archive.DefaultParams)
+	if err == nil {
+		optique.Info(fmt.Sprintf("vault exported: %d credentials", len(vault.Credentials)))
+	}
+	return sealed, err
+}
+
+// ImportArchive restores the credentials of an archive made by ExportArchive for the owner. Credentials
+// without owner are given to it, an archive holding credentials of another owner is rejected as a whole.
+// Whole vaults are restored with ImportVault.
+func (c *credentialService) ImportArchive(data []byte, passphrase string, owner *string) (types.ImportReport, error) {
+	if owner == nil {
+		return emptyImportReport(), ERR_OWNER_REQUIRED
+	}
+	vault, err := archive.Open(data, passphrase)
+	if err != nil {
+		return emptyImportReport(), err
+	}
+	for i, archived := range vault.Credentials {
+		if archived.Credential.Owner != nil && *archived.Credential.Owner != *owner {
+			return emptyImportReport(), ERR_FOREIGN_OWNER
+		}
+		vault.Credentials[i].Credential.Owner = owner
+	}
+	return c.restoreVault(vault)
+}
+
+// ImportVault restores an archive with the owners it holds, it is only reachable from the command line
+// and the admin routes
+func (c *credentialService) ImportVault(data []byte, passphrase string) (types.ImportReport, error) {
+	vault, err := archive.Open(data, passphrase)
+	if err != nil {
+		return emptyImportReport(), err
+	}
+	return c.restoreVault(vault)
+}
+
+func emptyImportReport() types.ImportReport {
+	return types.ImportReport{
+		Created:    []types.ImportItem{},
+		Duplicates: []types.ImportItem{},
+		Invalid:    []types.ImportItem{},
+	}
+}
+
+// restoreVault restores the credentials of an opened archive in one transaction, with their ids,
+// timestamps and version history. Credentials whose id is already stored are reported as duplicates and
+// left untouched.
+func (c *credentialService) restoreVault(vault archive.Vault) (types.ImportReport, error) {
+	report := emptyImportReport()
+
+	ids := make([]string, 0, len(vault.Credentials))
+	for _, archived := range vault.Credentials {
+		ids = append(ids, archived.Credential.ID)
+	}
+	stored, err := c.sqlRepository.GetCredentialTypes(ids)
+	if err != nil {
+		return report, err
+	}
+
+	var restore []types.ArchivedCredential
+	for i, archived := range vault.Credentials {
+		credential := archived.Credential
+		item := types.ImportItem{Index: i + 1, Title: credential.Title, Type: credential.Type, ID: credential.ID}
+		if _, ok := stored[credential.ID]; ok {
+			report.Duplicates = append(report.Duplicates, item)
+			continue
+		}
+
+		// attributes are normalized only, the archive is restored as it was even if the rules changed since
+		definition, err := registry.Get(credential.Type)
+		if err == nil {
+			archived.Credential.Attributes, err = definition.Normalize(credential.Attributes)
+		}
+		if err != nil {
+			item.Error = err.Error()
+			report.Invalid = append(report.Invalid, item)
+			continue
+		}
+		report.Created = append(report.Created, item)
+		restore = append(restore, archived)
+	}
+
+	if len(restore) == 0 {
+		return report, nil
+	}
+	restored, err := c.sqlRepository.RestoreCredentials(restore)
+	if err != nil {
+		return report, err
+	}
+	for _, credential := range restored {
+		c.recordPasswordBreach(credential)
+	}
+	optique.Info(fmt.Sprintf("archive imported: %d credentials restored, %d already stored, %d invalid", len(report.Created), len(report.Duplicates), len(report.Invalid)))
+	return report, nil
+}
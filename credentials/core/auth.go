@@ -0,0 +1,47 @@
+package core
+
+import "github.com/DO-2K23-26/polypass-microservices/credentials/types"
+
+// Authorize checks that the principal may access the credentials before they are written or their
+// history is read. Credentials of another owner are reported as not found so that their existence is
+// not disclosed, unknown ids are left to the operation.
+func (c *credentialService) Authorize(principal types.Principal, ids []string) error {
+	owner := principal.Owner()
+	if owner == nil || len(ids) == 0 {
+		return nil
+	}
+	owners, err := c.sqlRepository.GetCredentialOwners(ids)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if credentialOwner, ok := owners[id]; ok && !owns(owner, credentialOwner) {
+			return ERR_CREDENTIAL_NOT_FOUND
+		}
+	}
+	return nil
+}
+
+// ownedIDs keeps the ids of the credentials of the owner, every id is kept when it is nil
+func (c *credentialService) ownedIDs(ids []string, owner *string) ([]string, error) {
+	if owner == nil || len(ids) == 0 {
+		return ids, nil
+	}
+	owners, err := c.sqlRepository.GetCredentialOwners(ids)
+	if err != nil {
+		return nil, err
+	}
+	owned := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if credentialOwner, ok := owners[id]; ok && owns(owner, credentialOwner) {
+			owned = append(owned, id)
+		}
+	}
+	return owned, nil
+}
+
+// owns tells whether a credential of credentialOwner belongs to owner, credentials without owner
+// belong to no user
+func owns(owner *string, credentialOwner *string) bool {
+	return owner == nil || (credentialOwner != nil && *credentialOwner == *owner)
+}
@@ -0,0 +1,163 @@
+package core
+
+import (
+	"errors"
+
+	"github.com/DO-2K23-26/polypass-microservices/credentials/infrastructure/sql"
+	"github.com/DO-2K23-26/polypass-microservices/credentials/registry"
+	"github.com/DO-2K23-26/polypass-microservices/credentials/types"
+)
+
+// WriteCredentials applies a batch of creates, updates and deletes of any type. Operations are validated
+// first, then the valid ones are written in one transaction. In atomic mode nothing is written if one
+// operation fails, otherwise the failed ones are reported and the others are written. With an owner,
+// updates and deletes of credentials of another owner are not found.
+func (c *credentialService) WriteCredentials(operations []types.CredentialOperation, atomic bool, owner *string) (types.CredentialBatchReport, error) {
+	report := types.CredentialBatchReport{Atomic: atomic, Results: make([]types.CredentialOperationResult, len(operations))}
+
+	var ids []string
+	for _, operation := range operations {
+		if operation.Kind != types.CredentialOperationCreate && operation.Credential.ID != "" {
+			ids = append(ids, operation.Credential.ID)
+		}
+	}
+	owned, err := c.ownedIDs(ids, owner)
+	if err != nil {
+		return report, err
+	}
+	found := make(map[string]bool, len(owned))
+	for _, id := range owned {
+		found[id] = true
+	}
+
+	var valid []types.CredentialOperation
+	var positions []int
+	for i := range operations {
+		operation := &operations[i]
+		report.Results[i] = types.CredentialOperationResult{
+			Index: i,
+			Op:    operation.Kind,
+			Type:  operation.Credential.Type,
+			ID:    operation.Credential.ID,
+		}
+		if err := c.checkOperation(operation); err != nil {
+			report.Results[i].Status, report.Results[i].Error = types.CredentialOperationInvalid, err.Error()
+			continue
+		}
+		if operation.Kind != types.CredentialOperationDelete {
+			if err := c.CheckTOTPLink(operation.Credential, owner); err != nil {
+				report.Results[i].Status, report.Results[i].Error = types.CredentialOperationInvalid, err.Error()
+				continue
+			}
+		}
+		if operation.Kind != types.CredentialOperationCreate && !found[operation.Credential.ID] {
+			report.Results[i].Status, report.Results[i].Error = types.CredentialOperationNotFound, ERR_CREDENTIAL_NOT_FOUND.Error()
+			continue
+		}
+		valid = append(valid, *operation)
+		positions = append(positions, i)
+	}
+	if atomic && len(valid) < len(operations) {
+		rollBack(&report)
+		return report, nil
+	}
+
+	written, errs, err := c.sqlRepository.WriteCredentials(valid, atomic)
+	if err != nil {
+		return report, err
+	}
+	failed := false
+	for j, i := range positions {
+		result := &report.Results[i]
+		switch {
+		case errs[j] == nil:
+			continue
+		case errors.Is(errs[j], sql.ERR_VERSION_CONFLICT):
+			result.Status, result.Version = types.CredentialOperationConflict, written[j].Version
+		case errors.Is(errs[j], sql.ERR_NOT_FOUND):
+			result.Status, errs[j] = types.CredentialOperationNotFound, ERR_CREDENTIAL_NOT_FOUND
+		default:
+			result.Status = types.CredentialOperationFailed
+		}
+		result.Error, failed = errs[j].Error(), true
+	}
+	if atomic && failed {
+		rollBack(&report)
+		return report, nil
+	}
+
+	for j, i := range positions {
+		if errs[j] != nil {
+			continue
+		}
+		result := &report.Results[i]
+		result.Status = operationStatus(valid[j].Kind)
+		if valid[j].Kind != types.CredentialOperationDelete {
+			result.ID, result.Version = written[j].ID, written[j].Version
+			c.recordPasswordBreach(written[j])
+		}
+	}
+	report.Failed = countFailed(report.Results)
+	report.Succeeded = len(operations) - report.Failed
+	return report, nil
+}
+
+// checkOperation validates an operation like the single create, update and delete would. Updates of a
+// batch must give the version they are based on, as PUT requires If-Match.
+func (c *credentialService) checkOperation(operation *types.CredentialOperation) error {
+	credential := &operation.Credential
+	if _, err := registry.Get(credential.Type); err != nil {
+		return err
+	}
+	switch operation.Kind {
+	case types.CredentialOperationCreate:
+		credential.ID = ""
+		return c.CheckNewCredential(credential)
+	case types.CredentialOperationUpdate:
+		if credential.ID == "" {
+			return errors.New("id is required")
+		}
+		if credential.Version <= 0 {
+			return errors.New("version is required")
+		}
+		return c.CheckCredentialValidity(credential)
+	case types.CredentialOperationDelete:
+		if credential.ID == "" {
+			return errors.New("id is required")
+		}
+		return nil
+	}
+	return errors.New("op must be create, update or delete")
+}
+
+func operationStatus(kind types.CredentialOperationKind) types.CredentialOperationStatus {
+	switch kind {
+	case types.CredentialOperationCreate:
+		return types.CredentialOperationCreated
+	case types.CredentialOperationUpdate:
+		return types.CredentialOperationUpdated
+	}
+	return types.CredentialOperationDeleted
+}
+
+// countFailed counts the operations that failed, rolled back ones are not
+func countFailed(results []types.CredentialOperationResult) int {
+	failed := 0
+	for _, result := range results {
+		switch result.Status {
+		case types.CredentialOperationInvalid, types.CredentialOperationNotFound, types.CredentialOperationConflict, types.CredentialOperationFailed:
+			failed++
+		}
+	}
+	return failed
+}
+
+// rollBack reports a failed atomic batch, the operations that did not fail are rolled back
+func rollBack(report *types.CredentialBatchReport) {
+	for i := range report.Results {
+		if report.Results[i].Status == "" {
+			report.Results[i].Status = types.CredentialOperationRolledBack
+		}
+	}
+	report.Failed = countFailed(report.Results)
+}
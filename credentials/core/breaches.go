@@ -0,0 +1,88 @@
+package core
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/DO-2K23-26/polypass-microservices/credentials/types"
+	"github.com/optique-dev/optique"
+)
+
+var ERR_BREACH_CHECK_DISABLED error = errors.New("breach check disabled: no range dataset configured")
+
+// number of password credentials checked per batch when scanning the whole vault
+const breachScanBatchSize = 100
+
+// CountPasswordBreaches looks a password up in the range dataset. Only the first 5 characters of its
+// SHA-1 hash are used for the query, the rest is compared here.
+func (c *credentialService) CountPasswordBreaches(value string) (int, error) {
+	if c.breaches == nil {
+		return 0, ERR_BREACH_CHECK_DISABLED
+	}
+	sum := sha1.Sum([]byte(value))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	suffixes, err := c.breaches.Range(hash[:5])
+	if err != nil {
+		return 0, err
+	}
+	return suffixes[hash[5:]], nil
+}
+
+func (c *credentialService) checkPasswordBreaches(credentials []types.GenericCredential) ([]types.PasswordBreach, error) {
+	breaches := make([]types.PasswordBreach, 0, len(credentials))
+	for _, credential := range credentials {
+		value, _ := credential.Attributes["password"].(string)
+		occurrences, err := c.CountPasswordBreaches(value)
+		if err != nil {
+			return nil, err
+		}
+		breaches = append(breaches, types.PasswordBreach{
+			CredentialID: credential.ID,
+			Occurrences:  occurrences,
+			Compromised:  occurrences > 0,
+		})
+	}
+	return breaches, c.sqlRepository.SavePasswordBreaches(breaches)
+}
+
+// recordPasswordBreach checks a password credential that was just written, a failure does not undo the write
+func (c *credentialService) recordPasswordBreach(credential types.GenericCredential) {
+	if credential.Type != types.CredentialTypePassword || c.breaches == nil {
+		return
+	}
+	if _, err := c.checkPasswordBreaches([]types.GenericCredential{credential}); err != nil {
+		optique.Error(fmt.Sprintf("breach check of credential %s failed: %s", credential.ID, err))
+	}
+}
+
+func (c *credentialService) GetPasswordBreaches(ids []string) ([]types.PasswordBreach, error) {
+	return c.sqlRepository.GetPasswordBreaches(ids)
+}
+
+// ScanPasswordBreaches checks every password credential of the vault against the dataset
+func (c *credentialService) ScanPasswordBreaches() (types.PasswordBreachScan, error) {
+	var scan types.PasswordBreachScan
+	if c.breaches == nil {
+		return scan, ERR_BREACH_CHECK_DISABLED
+	}
+	err := c.sqlRepository.ScanCredentials(types.CredentialTypePassword, nil, breachScanBatchSize, func(credentials []types.GenericCredential) error {
+		breaches, err := c.checkPasswordBreaches(credentials)
+		if err != nil {
+			return err
+		}
+		scan.Scanned += len(breaches)
+		for _, breach := range breaches {
+			if breach.Compromised {
+				scan.Compromised++
+			}
+		}
+		return nil
+	})
+	if err == nil {
+		optique.Info(fmt.Sprintf("breach scan done: %d password credentials scanned, %d compromised", scan.Scanned, scan.Compromised))
+	}
+	return scan, err
+}
@@ -0,0 +1,71 @@
+package core
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/DO-2K23-26/polypass-microservices/credentials/infrastructure/breach"
+)
+
+// breachFixture writes a range directory, the hashes are the SHA-1 of the passwords of the tests
+func breachFixture(t *testing.T) breach.Dataset {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string]string{
+		// password
+		"5BAA6.txt": "0018A45C4D1DEF81644B54AB7F969B88D65:1\n1E4C9B93F3F0682250B6CF8331B7EE68FD8:9545824\n",
+		// hunter2, with a lower case suffix in a file without extension
+		"F3BBB": "d66a63d4bf1747940578ec3d0103530e21d:17043\r\n",
+		// padded, only listed as a padding line
+		"35B1A.txt": "C6F9CC1A7D2B46D057C6858B3AF47086AE9:0\n",
+		// the range of Password, without its suffix
+		"8BE3C.txt": "0018A45C4D1DEF81644B54AB7F969B88D65:4\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	dataset, err := breach.NewDataset(breach.Config{RangeDir: dir})
+	if err != nil {
+		t.Fatalf("new dataset: %v", err)
+	}
+	return dataset
+}
+
+func TestCountPasswordBreaches(t *testing.T) {
+	service := NewCredentialService(nil, PasswordPolicyConfig{}, breachFixture(t), RevealConfig{})
+
+	tests := []struct {
+		password    string
+		occurrences int
+	}{
+		{"password", 9545824},
+		{"hunter2", 17043},
+		{"padded", 0},
+		// its range is listed, its suffix is not
+		{"Password", 0},
+		// no range file for its prefix
+		{"not breached", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.password, func(t *testing.T) {
+			occurrences, err := service.CountPasswordBreaches(tt.password)
+			if err != nil {
+				t.Fatalf("count: %v", err)
+			}
+			if occurrences != tt.occurrences {
+				t.Fatalf("occurrences = %d, want %d", occurrences, tt.occurrences)
+			}
+		})
+	}
+}
+
+func TestCountPasswordBreachesDisabled(t *testing.T) {
+	service := NewCredentialService(nil, PasswordPolicyConfig{}, nil, RevealConfig{})
+	if _, err := service.CountPasswordBreaches("password"); !errors.Is(err, ERR_BREACH_CHECK_DISABLED) {
+		t.Fatalf("error = %v, want %v", err, ERR_BREACH_CHECK_DISABLED)
+	}
+}
@@ -0,0 +1,21 @@
+package core
+
+import "time"
+
+const (
+	PasswordPolicyOff    = "off"
+	PasswordPolicyWarn   = "warn"
+	PasswordPolicyReject = "reject"
+)
+
+type PasswordPolicyConfig struct {
+	//what to do with password credentials whose strength score is below MinScore: off, warn or reject
+	Mode string `mapstructure:"mode"`
+	//minimum strength score, from 0 (very weak) to 4 (very strong)
+	MinScore int `mapstructure:"min_score"`
+}
+
+type RevealConfig struct {
+	//how long after authenticating a caller can reveal secrets, e.g. 5m. 0 accepts any caller
+	MaxAuthAge time.Duration `mapstructure:"max_auth_age"`
+}
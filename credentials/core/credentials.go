@@ -2,197 +2,285 @@ package core
 
 import (
 	"errors"
-	"regexp"
-	"strconv"
+	"fmt"
+	"io"
 	"time"
 
+	"github.com/DO-2K23-26/polypass-microservices/credentials/importer"
+	"github.com/DO-2K23-26/polypass-microservices/credentials/infrastructure/breach"
 	"github.com/DO-2K23-26/polypass-microservices/credentials/infrastructure/sql"
+	"github.com/DO-2K23-26/polypass-microservices/credentials/password"
+	"github.com/DO-2K23-26/polypass-microservices/credentials/registry"
+	"github.com/DO-2K23-26/polypass-microservices/credentials/sshkey"
+	"github.com/DO-2K23-26/polypass-microservices/credentials/totp"
 	"github.com/DO-2K23-26/polypass-microservices/credentials/types"
 )
 
 type CredentialsService interface {
-	GetPasswordCredentials(ids []string) ([]types.PasswordCredential, error)
-	GetCardCredentials(ids []string) ([]types.CardCredential, error)
-	GetSSHKeyCredentials(ids []string) ([]types.SSHKeyCredential, error)
-
-	CreateCredential(credential *types.CreateCredentialOpts) error
-	CheckCredentialValidity(credential *types.CreateCredentialOpts) error
-	CreatePasswordCredential(credential types.PasswordCredential) (types.PasswordCredential, error)
-	CreateCardCredential(credential types.CardCredential) (types.CardCredential, error)
-	CreateSSHKeyCredential(credential types.SSHKeyCredential) (types.SSHKeyCredential, error)
-
-	UpdatePasswordCredential(credential types.PasswordCredential) (types.PasswordCredential, error)
-	UpdateCardCredential(credential types.CardCredential) (types.CardCredential, error)
-	UpdateSSHKeyCredential(credential types.SSHKeyCredential) (types.SSHKeyCredential, error)
-
-	DeletePasswordCredentials(ids []string) error
-	DeleteCardCredentials(ids []string) error
-	DeleteSSHKeyCredentials(ids []string) error
-}
+	// reads are recorded in the access log, see access.go
+	GetCredentialsOfType(credentialType types.CredentialType, ids []string, access types.AccessContext) ([]types.GenericCredential, error)
+	GetCredentials(ids []string, access types.AccessContext) ([]types.GenericCredential, error)
+	// pages of the credentials of a type, or of every type, matching filters, see list.go
+	ListCredentials(query types.CredentialQuery, access types.AccessContext) (types.CredentialPage, error)
+	GetCredentialAccessLog(id string, limit int, offset int) ([]types.CredentialAccess, error)
+	// users only access the credentials they own, service identities every credential, see auth.go
+	Authorize(principal types.Principal, ids []string) error
+	// secrets are masked by the reads above, they are returned in clear one credential at a time
+	RevealCredential(credentialType types.CredentialType, id string, access types.AccessContext) (types.GenericCredential, error)
 
-type credentialService struct {
-	sqlRepository sql.Sql
-}
+	CheckCredentialValidity(credential *types.GenericCredential) error
+	// new credentials also pass the rules of their type reserved to creation
+	CheckNewCredential(credential *types.GenericCredential) error
+	// a totp credential is only linked to a password credential of its owner, see totp.go
+	CheckTOTPLink(credential types.GenericCredential, owner *string) error
+	CreateCredential(credential types.GenericCredential) (types.GenericCredential, error)
+	// a credential with a Version is only updated if it is still the current one
+	UpdateCredential(credential types.GenericCredential) (types.GenericCredential, error)
+	// deleted credentials are moved to the trash, see trash.go
+	DeleteCredentials(credentialType types.CredentialType, ids []string, actor *string) error
+	GetTrash(owner *string, limit int, offset int) ([]types.TrashedCredential, error)
+	RestoreDeletedCredentials(ids []string) ([]types.GenericCredential, error)
+	PurgeTrash(before time.Time, batchSize int) (int, error)
+	// creates, updates and deletes of any type with a result per operation, see batch.go
+	// updates and deletes of credentials of another owner than a non nil owner are not found
+	WriteCredentials(operations []types.CredentialOperation, atomic bool, owner *string) (types.CredentialBatchReport, error)
+	// imported credentials belong to the principal
+	ImportCredentials(format importer.Format, export io.Reader, dryRun bool, principal types.Principal) (types.ImportReport, error)
 
-func NewCredentialService(sqlRepository sql.Sql) *credentialService {
-	return &credentialService{
-		sqlRepository: sqlRepository,
-	}
-}
+	// passphrase protected archive of the credentials of the caller's owner, or of the whole vault for
+	// admins, see archive.go
+	ExportArchive(passphrase string, access types.AccessContext) ([]byte, error)
+	ExportVault(passphrase string, access types.AccessContext) ([]byte, error)
+	ImportArchive(data []byte, passphrase string, owner *string) (types.ImportReport, error)
+	ImportVault(data []byte, passphrase string) (types.ImportReport, error)
 
-func (c *credentialService) GetPasswordCredentials(ids []string) ([]types.PasswordCredential, error) {
-	return c.sqlRepository.GetPasswordCredentials(ids)
-}
+	GetCredentialVersions(credentialType types.CredentialType, id string) ([]types.CredentialVersion, error)
+	DiffCredentialVersions(credentialType types.CredentialType, id string, from int, to int, access types.AccessContext) (types.CredentialVersionDiff, error)
+	RestoreCredentialVersion(credentialType types.CredentialType, id string, version int, actor *string) (types.GenericCredential, error)
 
-func (c *credentialService) GetCardCredentials(ids []string) ([]types.CardCredential, error) {
-	return c.sqlRepository.GetCardCredentials(ids)
-}
+	ParseTOTPURI(uri string, credential types.GenericCredential) (types.GenericCredential, error)
+	GenerateTOTPCode(id string, access types.AccessContext) (totp.Code, error)
 
-func (c *credentialService) GetSSHKeyCredentials(ids []string) ([]types.SSHKeyCredential, error) {
-	return c.sqlRepository.GetSSHKeyCredentials(ids)
-}
+	GenerateSSHKey(options sshkey.GenerateOptions) (sshkey.KeyPair, error)
+	FindSSHKeys(fingerprint string, access types.AccessContext) ([]types.GenericCredential, error)
 
-func (c *credentialService) CreateSSHKeyCredential(credential types.SSHKeyCredential) (types.SSHKeyCredential, error) {
-	return c.sqlRepository.CreateSSHKeyCredential(credential)
-}
+	GeneratePassword(options password.GeneratorOptions) (string, error)
+	GeneratePassphrase(options password.PassphraseOptions) (string, error)
+	EstimatePasswordStrength(value string, context ...string) password.Strength
+	CheckPasswordPolicy(credential *types.GenericCredential) (string, error)
 
-func (c *credentialService) UpdateSSHKeyCredential(credential types.SSHKeyCredential) (types.SSHKeyCredential, error) {
-	return c.sqlRepository.UpdateSSHKeyCredential(credential)
-}
+	CountPasswordBreaches(value string) (int, error)
+	GetPasswordBreaches(ids []string) ([]types.PasswordBreach, error)
+	ScanPasswordBreaches() (types.PasswordBreachScan, error)
 
-func (c *credentialService) DeleteSSHKeyCredentials(ids []string) error {
-	return c.sqlRepository.DeleteSSHKeyCredentials(ids)
+	SweepExpiringCredentials(now time.Time, windows []int) (types.ExpirySweep, error)
 }
 
-func (c *credentialService) CreateCardCredential(credential types.CardCredential) (types.CardCredential, error) {
-	return c.sqlRepository.CreateCardCredential(credential)
+type credentialService struct {
+	sqlRepository  sql.Sql
+	passwordPolicy PasswordPolicyConfig
+	// nil when breach checks are disabled
+	breaches breach.Dataset
+	reveal   RevealConfig
 }
 
-func (c *credentialService) UpdateCardCredential(credential types.CardCredential) (types.CardCredential, error) {
-	return c.sqlRepository.UpdateCardCredential(credential)
+func NewCredentialService(sqlRepository sql.Sql, passwordPolicy PasswordPolicyConfig, breaches breach.Dataset, reveal RevealConfig) *credentialService {
+	return &credentialService{
+		sqlRepository:  sqlRepository,
+		passwordPolicy: passwordPolicy,
+		breaches:       breaches,
+		reveal:         reveal,
+	}
 }
 
-func (c *credentialService) DeleteCardCredentials(ids []string) error {
-	return c.sqlRepository.DeleteCardCredentials(ids)
-}
+var ERR_INVALID_CREDENTIAL_TYPE error = registry.ERR_INVALID_CREDENTIAL_TYPE
 
-func (c *credentialService) CreatePasswordCredential(credential types.PasswordCredential) (types.PasswordCredential, error) {
-	return c.sqlRepository.CreatePasswordCredential(credential)
+// ERR_INVALID_CREDENTIAL wraps the errors of CheckNewCredential and CheckCredentialValidity returned by
+// CreateCredential and UpdateCredential, which check the credential before writing it
+var ERR_INVALID_CREDENTIAL error = errors.New("invalid credential")
+
+// ERR_VERSION_CONFLICT is returned by UpdateCredential with the current version of the credential
+var ERR_VERSION_CONFLICT error = sql.ERR_VERSION_CONFLICT
+
+func (c *credentialService) GetCredentialsOfType(credentialType types.CredentialType, ids []string, access types.AccessContext) ([]types.GenericCredential, error) {
+	ids, err := c.ownedIDs(ids, access.Owner)
+	if err != nil {
+		return nil, err
+	}
+	credentials, err := c.lookupCredentials(credentialType, ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range credentials {
+		credentials[i] = present(credentials[i], access.Reveal)
+	}
+	if err := c.recordAccess(credentials, access, readFields(access.Reveal)); err != nil {
+		return nil, err
+	}
+	return credentials, nil
 }
 
-func (c *credentialService) UpdatePasswordCredential(credential types.PasswordCredential) (types.PasswordCredential, error) {
-	return c.sqlRepository.UpdatePasswordCredential(credential)
+// present drops the attributes that never leave the service, e.g. TOTP seeds, and masks the ones only
+// shown when revealed, e.g. card numbers
+func present(credential types.GenericCredential, reveal bool) types.GenericCredential {
+	if definition, err := registry.Get(credential.Type); err == nil {
+		credential.Attributes = definition.Visible(credential.Attributes)
+		if !reveal {
+			credential.Attributes = definition.Masked(credential.Attributes)
+		}
+	}
+	return credential
 }
 
-func (c *credentialService) DeletePasswordCredentials(ids []string) error {
-	return c.sqlRepository.DeletePasswordCredentials(ids)
+// GetCredentials reads credentials of any type, the type of each id is resolved from the parent table.
+// Unknown ids are left out and the order of ids is kept.
+func (c *credentialService) GetCredentials(ids []string, access types.AccessContext) ([]types.GenericCredential, error) {
+	credentialTypes, err := c.sqlRepository.GetCredentialTypes(ids)
+	if err != nil {
+		return nil, err
+	}
+	return c.readCredentials(ids, credentialTypes, access)
 }
 
-var ERR_INVALID_CREDENTIAL_TYPE error = errors.New("invalid credential type")
+// readCredentials reads credentials of several types, given the type of each id, and keeps the order of ids
+func (c *credentialService) readCredentials(ids []string, credentialTypes map[string]types.CredentialType, access types.AccessContext) ([]types.GenericCredential, error) {
+	idsByType := make(map[types.CredentialType][]string)
+	for _, id := range ids {
+		if credentialType, ok := credentialTypes[id]; ok {
+			idsByType[credentialType] = append(idsByType[credentialType], id)
+		}
+	}
 
-// TODO it is not used yet
-func (c *credentialService) CreateCredential(credentialOpts *types.CreateCredentialOpts) error {
+	found := make(map[string]types.GenericCredential, len(ids))
+	for credentialType, typeIds := range idsByType {
+		credentials, err := c.GetCredentialsOfType(credentialType, typeIds, access)
+		if err != nil {
+			return nil, err
+		}
+		for _, credential := range credentials {
+			found[credential.ID] = credential
+		}
+	}
 
-	if err := c.CheckCredentialValidity(credentialOpts); err != nil {
-		return err
+	credentials := make([]types.GenericCredential, 0, len(found))
+	for _, id := range ids {
+		if credential, ok := found[id]; ok {
+			credentials = append(credentials, credential)
+		}
 	}
+	return credentials, nil
+}
 
-	switch credentialOpts.Type {
-	case types.CredentialTypeCard:
-		_, err := c.CreateCardCredential(types.CardCredential{
-			Credential: types.Credential{
-				Title:        credentialOpts.Title,
-				Note:         credentialOpts.Note,
-				CustomFields: &credentialOpts.CustomFields,
-			},
-			CardAttributes: credentialOpts.CardAttributes,
-			UserIdentifierAttribute: types.UserIdentifierAttribute{
-				UserIdentifier: credentialOpts.UserIdentifierAttribute.UserIdentifier,
-			},
-		})
+// CheckCredentialValidity normalizes the attributes to the definition of the credential type
+// then applies its validation rules and the password policy. As write-only attributes are never returned, they keep their
+// stored value when an existing credential is written without them.
+func (s *credentialService) CheckCredentialValidity(credential *types.GenericCredential) error {
+	if credential == nil {
+		return errors.New("credential options cannot be nil")
+	}
+	if credential.Title == "" {
+		return errors.New("title cannot be empty")
+	}
+
+	definition, err := registry.Get(credential.Type)
+	if err != nil {
 		return err
-	case types.CredentialTypePassword:
-		_, err := c.CreatePasswordCredential(types.PasswordCredential{
-			Credential: types.Credential{
-				Title:        credentialOpts.Title,
-				Note:         credentialOpts.Note,
-				CustomFields: &credentialOpts.CustomFields,
-			},
-			PasswordAttributes: credentialOpts.PasswordAttributes,
-			UserIdentifierAttribute: types.UserIdentifierAttribute{
-				UserIdentifier: credentialOpts.UserIdentifierAttribute.UserIdentifier,
-			},
-		})
+	}
+	if err := s.keepStoredSecrets(definition, credential); err != nil {
 		return err
-	case types.CredentialTypeSSHKey:
-		_, err := c.CreateSSHKeyCredential(types.SSHKeyCredential{
-			Credential: types.Credential{
-				Title:        credentialOpts.Title,
-				Note:         credentialOpts.Note,
-				CustomFields: &credentialOpts.CustomFields,
-			},
-			SSHKeyAttributes: credentialOpts.SSHKeyAttributes,
-			UserIdentifierAttribute: types.UserIdentifierAttribute{
-				UserIdentifier: credentialOpts.UserIdentifierAttribute.UserIdentifier,
-			},
-		})
+	}
+	attributes, err := definition.Normalize(credential.Attributes)
+	if err != nil {
+		return err
+	}
+	if err := definition.Check(attributes); err != nil {
 		return err
-	default:
-		return ERR_INVALID_CREDENTIAL_TYPE
 	}
+	credential.Attributes = attributes
+	_, err = s.CheckPasswordPolicy(credential)
+	return err
 }
 
-func (s *credentialService) CheckCredentialValidity(credentialOpts *types.CreateCredentialOpts) error {
-	if credentialOpts == nil {
-		return errors.New("credential options cannot be nil")
+// keepStoredSecrets completes an update with the stored values of the write-only attributes it leaves
+// empty, and of the secret attributes it sends back masked as they were read, so that a credential read
+// then written back keeps its secrets
+func (s *credentialService) keepStoredSecrets(definition registry.Definition, credential *types.GenericCredential) error {
+	if credential.ID == "" {
+		return nil
 	}
-	if credentialOpts.Title == "" {
-		return errors.New("title cannot be empty")
+	var candidates []registry.Attribute
+	for _, attribute := range definition.Attributes {
+		value, ok := credential.Attributes[attribute.Name]
+		empty := !ok || value == nil || value == ""
+		if (attribute.WriteOnly && empty) || (attribute.IsMasked() && !empty) {
+			candidates = append(candidates, attribute)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
 	}
 
-	switch credentialOpts.Type {
-	case types.CredentialTypeCard:
-		re := regexp.MustCompile(`^\d{16}$`)
-		cardStr := strconv.FormatInt(credentialOpts.CardAttributes.CardNumber, 10)
-		if !re.MatchString(cardStr) {
-			return errors.New("invalid card number format: must be exactly 16 digits")
-		}
-		if credentialOpts.CardAttributes.CVC < 100 || credentialOpts.CardAttributes.CVC > 999 {
-			return errors.New("CVC must be between 100 and 999")
-		}
-		expDate, err := time.Parse("2006-01-02", credentialOpts.CardAttributes.ExpirationDate)
-		if err != nil {
-			return errors.New("invalid expiry date format: expected YYYY-MM-DD")
-		}
-		if expDate.Before(time.Now()) {
-			return errors.New("expired card")
+	stored, err := s.lookupCredentials(definition.Type, []string{credential.ID})
+	if err != nil || len(stored) == 0 {
+		return err
+	}
+	if credential.Attributes == nil {
+		credential.Attributes = make(map[string]any, len(candidates))
+	}
+	for _, attribute := range candidates {
+		storedValue := stored[0].Attributes[attribute.Name]
+		value, ok := credential.Attributes[attribute.Name]
+		if attribute.WriteOnly && (!ok || value == nil || value == "") {
+			credential.Attributes[attribute.Name] = storedValue
+			continue
 		}
-		if credentialOpts.CardAttributes.OwnerName == "" {
-			return errors.New("owner name cannot be empty")
+		if attribute.IsMasked() && storedValue != nil && value == attribute.MaskValue(storedValue) {
+			credential.Attributes[attribute.Name] = storedValue
 		}
+	}
+	return nil
+}
 
-	case types.CredentialTypePassword:
-		if credentialOpts.PasswordAttributes.DomainName == "" {
-			return errors.New("domain name cannot be empty")
-		}
-		if credentialOpts.PasswordAttributes.Password == "" {
-			return errors.New("password cannot be empty")
-		}
+// CheckNewCredential checks a credential about to be created like CheckCredentialValidity, then applies
+// the rules of its type that only hold at creation
+func (s *credentialService) CheckNewCredential(credential *types.GenericCredential) error {
+	if err := s.CheckCredentialValidity(credential); err != nil {
+		return err
+	}
+	definition, err := registry.Get(credential.Type)
+	if err != nil {
+		return err
+	}
+	return definition.CheckNew(credential.Attributes)
+}
 
-	case types.CredentialTypeSSHKey:
-		if credentialOpts.SSHKeyAttributes.Hostname == "" {
-			return errors.New("hostname cannot be empty")
-		}
-		if credentialOpts.SSHKeyAttributes.PrivateKey == "" {
-			return errors.New("private key cannot be empty")
-		}
-		if credentialOpts.SSHKeyAttributes.PublicKey == "" {
-			return errors.New("public key cannot be empty")
-		}
+func (c *credentialService) CreateCredential(credential types.GenericCredential) (types.GenericCredential, error) {
+	if err := c.CheckNewCredential(&credential); err != nil {
+		return types.GenericCredential{}, fmt.Errorf("%w: %w", ERR_INVALID_CREDENTIAL, err)
+	}
+	created, err := c.sqlRepository.CreateCredential(credential)
+	if err != nil {
+		return created, err
+	}
+	c.recordPasswordBreach(created)
+	return present(created, false), nil
+}
 
-	default:
-		return ERR_INVALID_CREDENTIAL_TYPE
+func (c *credentialService) UpdateCredential(credential types.GenericCredential) (types.GenericCredential, error) {
+	if err := c.CheckCredentialValidity(&credential); err != nil {
+		return credential, fmt.Errorf("%w: %w", ERR_INVALID_CREDENTIAL, err)
 	}
+	updated, err := c.sqlRepository.UpdateCredential(credential)
+	if errors.Is(err, sql.ERR_NOT_FOUND) {
+		return updated, ERR_CREDENTIAL_NOT_FOUND
+	}
+	if err != nil {
+		return updated, err
+	}
+	c.recordPasswordBreach(updated)
+	return present(updated, false), nil
+}
 
-	return nil
+func (c *credentialService) DeleteCredentials(credentialType types.CredentialType, ids []string, actor *string) error {
+	return c.sqlRepository.DeleteCredentials(credentialType, ids, actor)
 }
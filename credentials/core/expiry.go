@@ -0,0 +1,58 @@
+package core
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/DO-2K23-26/polypass-microservices/credentials/types"
+	"github.com/optique-dev/optique"
+)
+
+// SweepExpiringCredentials sends a credential_expiring event when a credential enters one of the
+// reminder windows, given in days, and a credential_expired event once it is past its expiry. Each
+// event is sent once per window.
+func (c *credentialService) SweepExpiringCredentials(now time.Time, windows []int) (types.ExpirySweep, error) {
+	var sweep types.ExpirySweep
+
+	windows = slices.DeleteFunc(slices.Clone(windows), func(window int) bool { return window <= 0 })
+	slices.Sort(windows)
+	horizon := now
+	if len(windows) > 0 {
+		horizon = now.AddDate(0, 0, windows[len(windows)-1])
+	}
+
+	expiring, err := c.sqlRepository.GetExpiringCredentials(horizon)
+	if err != nil {
+		return sweep, err
+	}
+
+	var failed error
+	for _, expiry := range expiring {
+		if remaining := expiry.ExpiresAt.Sub(now); remaining > 0 {
+			expiry.DaysLeft = int(math.Ceil(remaining.Hours() / 24))
+			for _, window := range windows {
+				if !expiry.ExpiresAt.After(now.AddDate(0, 0, window)) {
+					expiry.WindowDays = window
+					break
+				}
+			}
+		}
+
+		notified, err := c.sqlRepository.NotifyCredentialExpiry(expiry)
+		if err != nil {
+			optique.Error(fmt.Sprintf("expiry event of credential %s failed: %s", expiry.CredentialID, err))
+			failed = err
+			continue
+		}
+		switch {
+		case !notified:
+		case expiry.WindowDays == 0:
+			sweep.Expired++
+		default:
+			sweep.Expiring++
+		}
+	}
+	return sweep, failed
+}
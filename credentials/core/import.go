@@ -0,0 +1,109 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/DO-2K23-26/polypass-microservices/credentials/importer"
+	"github.com/DO-2K23-26/polypass-microservices/credentials/registry"
+	"github.com/DO-2K23-26/polypass-microservices/credentials/types"
+	"github.com/optique-dev/optique"
+)
+
+var ERR_INVALID_EXPORT error = errors.New("invalid export")
+
+// number of stored credentials read per batch when looking for duplicates
+const importScanBatchSize = 500
+
+// ImportCredentials reads an export of another password manager. Entries failing validation and
+// entries whose identity is already stored, or appears earlier in the file, are reported and skipped.
+// The others are created in one transaction unless dryRun is set, they belong to the principal. Users
+// only have duplicates among the credentials they own.
+func (c *credentialService) ImportCredentials(format importer.Format, export io.Reader, dryRun bool, principal types.Principal) (types.ImportReport, error) {
+	var actor *string
+	if principal.Subject != "" {
+		actor = &principal.Subject
+	}
+	report := types.ImportReport{
+		DryRun:     dryRun,
+		Created:    []types.ImportItem{},
+		Duplicates: []types.ImportItem{},
+		Invalid:    []types.ImportItem{},
+	}
+	entries, err := importer.Parse(format, export)
+	if err != nil {
+		return report, fmt.Errorf("%w: %w", ERR_INVALID_EXPORT, err)
+	}
+
+	stored := make(map[string]string)
+	scanned := make(map[types.CredentialType]bool)
+	var credentials []types.GenericCredential
+	for i, entry := range entries {
+		credential := entry.Credential
+		credential.UpdatedBy, credential.Owner = actor, actor
+		item := types.ImportItem{Index: i + 1, Title: credential.Title, Type: credential.Type}
+
+		if entry.Err == nil {
+			entry.Err = c.CheckCredentialValidity(&credential)
+		}
+		if entry.Err != nil {
+			item.Error = entry.Err.Error()
+			report.Invalid = append(report.Invalid, item)
+			continue
+		}
+
+		definition, err := registry.Get(credential.Type)
+		if err != nil {
+			return report, err
+		}
+		if !scanned[credential.Type] {
+			if err := c.storedIdentities(definition, principal.Owner(), stored); err != nil {
+				return report, err
+			}
+			scanned[credential.Type] = true
+		}
+		if identity := definition.IdentityOf(credential.Attributes); identity != "" {
+			if id, ok := stored[identity]; ok {
+				item.ID = id
+				report.Duplicates = append(report.Duplicates, item)
+				continue
+			}
+			stored[identity] = ""
+		}
+
+		report.Created = append(report.Created, item)
+		credentials = append(credentials, credential)
+	}
+
+	if dryRun || len(credentials) == 0 {
+		return report, nil
+	}
+	created, err := c.sqlRepository.CreateCredentials(credentials)
+	if err != nil {
+		return report, err
+	}
+	for i, credential := range created {
+		report.Created[i].ID = credential.ID
+		c.recordPasswordBreach(credential)
+	}
+	optique.Info(fmt.Sprintf("import done: %d credentials created, %d duplicates, %d invalid", len(report.Created), len(report.Duplicates), len(report.Invalid)))
+	return report, nil
+}
+
+// storedIdentities adds the identity of every stored credential of a type and of the owner to identities
+func (c *credentialService) storedIdentities(definition registry.Definition, owner *string, identities map[string]string) error {
+	if len(definition.Identity) == 0 {
+		return nil
+	}
+	return c.sqlRepository.ScanCredentials(definition.Type, owner, importScanBatchSize, func(credentials []types.GenericCredential) error {
+		for _, credential := range credentials {
+			attributes, err := definition.Normalize(credential.Attributes)
+			if err != nil {
+				return err
+			}
+			identities[definition.IdentityOf(attributes)] = credential.ID
+		}
+		return nil
+	})
+}
@@ -0,0 +1,133 @@
+package core
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/DO-2K23-26/polypass-microservices/credentials/registry"
+	"github.com/DO-2K23-26/polypass-microservices/credentials/types"
+)
+
+var ERR_INVALID_QUERY error = errors.New("invalid query")
+
+// ListCredentials reads a page of the credentials matching the query, in the order of the query.
+// Credentials are listed by id first then read like GetCredentials, so the page is recorded in the
+// access log and masked the same way.
+func (c *credentialService) ListCredentials(query types.CredentialQuery, access types.AccessContext) (types.CredentialPage, error) {
+	page := types.CredentialPage{Credentials: []types.GenericCredential{}}
+	if query.Sort == "" {
+		query.Sort = types.CredentialSortCreatedAt
+	}
+	query.Owner = access.Owner
+	if err := checkQuery(query); err != nil {
+		return page, err
+	}
+	after, err := decodeCursor(query)
+	if err != nil {
+		return page, err
+	}
+
+	// one more entry tells whether there is a next page
+	limit := query.Limit
+	query.Limit++
+	entries, err := c.sqlRepository.ListCredentials(query, after)
+	if err != nil {
+		return page, err
+	}
+	if len(entries) > limit {
+		entries = entries[:limit]
+		last := entries[limit-1]
+		page.NextCursor = encodeCursor(types.CredentialCursor{
+			Sort:       query.Sort,
+			Descending: query.Descending,
+			SortKey:    last.SortKey,
+			ID:         last.ID,
+		})
+	}
+
+	ids := make([]string, 0, len(entries))
+	credentialTypes := make(map[string]types.CredentialType, len(entries))
+	for _, entry := range entries {
+		ids = append(ids, entry.ID)
+		credentialTypes[entry.ID] = entry.Type
+	}
+	page.Credentials, err = c.readCredentials(ids, credentialTypes, access)
+	return page, err
+}
+
+func checkQuery(query types.CredentialQuery) error {
+	if query.Type != "" {
+		if _, err := registry.Get(query.Type); err != nil {
+			return err
+		}
+	}
+	switch query.Sort {
+	case types.CredentialSortTitle, types.CredentialSortCreatedAt, types.CredentialSortUpdatedAt, types.CredentialSortLastReadAt:
+	default:
+		return fmt.Errorf("%w: credentials cannot be sorted by %s", ERR_INVALID_QUERY, query.Sort)
+	}
+	switch query.Expiry {
+	case "", types.ExpiryStatusExpired, types.ExpiryStatusExpiring, types.ExpiryStatusValid, types.ExpiryStatusNone:
+	default:
+		return fmt.Errorf("%w: unknown expiry status %s", ERR_INVALID_QUERY, query.Expiry)
+	}
+	if query.Limit <= 0 {
+		return fmt.Errorf("%w: limit must be positive", ERR_INVALID_QUERY)
+	}
+	for name, value := range query.Attributes {
+		if err := checkFilter(query.Type, name, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkFilter tells whether a non secret attribute of the type, or of any type when it is empty, has
+// this name, and whether the value can be compared to it
+func checkFilter(credentialType types.CredentialType, name string, value string) error {
+	searchable := false
+	for _, definition := range registry.All() {
+		if credentialType != "" && definition.Type != credentialType {
+			continue
+		}
+		attribute, ok := definition.Attribute(name)
+		if !ok || attribute.Secret {
+			continue
+		}
+		if _, err := attribute.Parse(value); err != nil {
+			return fmt.Errorf("%w: %s", ERR_INVALID_QUERY, err.Error())
+		}
+		searchable = true
+	}
+	if !searchable {
+		return fmt.Errorf("%w: credentials cannot be filtered on %s", ERR_INVALID_QUERY, name)
+	}
+	return nil
+}
+
+// cursors are opaque to clients, they carry the sort so that they cannot be used with another one
+func encodeCursor(cursor types.CredentialCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(query types.CredentialQuery) (*types.CredentialCursor, error) {
+	if query.Cursor == "" {
+		return nil, nil
+	}
+	invalid := fmt.Errorf("%w: invalid cursor", ERR_INVALID_QUERY)
+	data, err := base64.RawURLEncoding.DecodeString(query.Cursor)
+	if err != nil {
+		return nil, invalid
+	}
+	var cursor types.CredentialCursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID == "" {
+		return nil, invalid
+	}
+	if cursor.Sort != query.Sort || cursor.Descending != query.Descending {
+		return nil, fmt.Errorf("%w: the cursor was given for another sort", ERR_INVALID_QUERY)
+	}
+	return &cursor, nil
+}
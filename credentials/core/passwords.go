@@ -0,0 +1,52 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/DO-2K23-26/polypass-microservices/credentials/password"
+	"github.com/DO-2K23-26/polypass-microservices/credentials/types"
+)
+
+var ERR_WEAK_PASSWORD error = errors.New("weak password")
+
+func (c *credentialService) GeneratePassword(options password.GeneratorOptions) (string, error) {
+	return password.Generate(options)
+}
+
+func (c *credentialService) GeneratePassphrase(options password.PassphraseOptions) (string, error) {
+	return password.GeneratePassphrase(options)
+}
+
+// EstimatePasswordStrength flags, among others, passwords that reuse one of the context values
+func (c *credentialService) EstimatePasswordStrength(value string, context ...string) password.Strength {
+	return password.Estimate(value, context...)
+}
+
+// CheckPasswordPolicy estimates the password of a password credential against the title, domain and
+// user identifier. Below the minimum score it returns a warning in warn mode and ERR_WEAK_PASSWORD in
+// reject mode.
+func (c *credentialService) CheckPasswordPolicy(credential *types.GenericCredential) (string, error) {
+	mode := c.passwordPolicy.Mode
+	if credential.Type != types.CredentialTypePassword || (mode != PasswordPolicyWarn && mode != PasswordPolicyReject) {
+		return "", nil
+	}
+
+	value, _ := credential.Attributes["password"].(string)
+	domainName, _ := credential.Attributes["domain_name"].(string)
+	userIdentifier, _ := credential.Attributes["user_identifier"].(string)
+	strength := c.EstimatePasswordStrength(value, credential.Title, domainName, userIdentifier)
+	if strength.Score >= c.passwordPolicy.MinScore {
+		return "", nil
+	}
+
+	warning := fmt.Sprintf("score %d below %d", strength.Score, c.passwordPolicy.MinScore)
+	if len(strength.Flags) > 0 {
+		warning += ": " + strings.Join(strength.Flags, ", ")
+	}
+	if mode == PasswordPolicyReject {
+		return "", fmt.Errorf("%w, %s", ERR_WEAK_PASSWORD, warning)
+	}
+	return fmt.Sprintf("%s, %s", ERR_WEAK_PASSWORD, warning), nil
+}
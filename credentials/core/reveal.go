@@ -0,0 +1,37 @@
+package core
+
+import (
+	"errors"
+	"time"
+
+	"github.com/DO-2K23-26/polypass-microservices/credentials/types"
+)
+
+var ERR_REAUTHENTICATION_REQUIRED error = errors.New("authenticate again to reveal secrets")
+
+// RevealCredential returns one credential with its secrets in clear, write-only attributes stay hidden.
+// The read is recorded in the access log with every field. When MaxAuthAge is set the caller must have
+// authenticated within it.
+func (c *credentialService) RevealCredential(credentialType types.CredentialType, id string, access types.AccessContext) (types.GenericCredential, error) {
+	if err := c.checkAuthAge(access); err != nil {
+		return types.GenericCredential{}, err
+	}
+	access.Reveal = true
+	credentials, err := c.GetCredentialsOfType(credentialType, []string{id}, access)
+	if err != nil {
+		return types.GenericCredential{}, err
+	}
+	if len(credentials) == 0 {
+		return types.GenericCredential{}, ERR_CREDENTIAL_NOT_FOUND
+	}
+	return credentials[0], nil
+}
+
+// checkAuthAge fails with ERR_REAUTHENTICATION_REQUIRED when MaxAuthAge is set and the caller did not
+// authenticate within it, it guards every read of secrets in clear
+func (c *credentialService) checkAuthAge(access types.AccessContext) error {
+	if c.reveal.MaxAuthAge > 0 && (access.AuthTime == nil || time.Since(*access.AuthTime) > c.reveal.MaxAuthAge) {
+		return ERR_REAUTHENTICATION_REQUIRED
+	}
+	return nil
+}
@@ -0,0 +1,21 @@
+package core
+
+import (
+	"github.com/DO-2K23-26/polypass-microservices/credentials/sshkey"
+	"github.com/DO-2K23-26/polypass-microservices/credentials/types"
+)
+
+// GenerateSSHKey generates a key pair, it is not stored: the caller creates the credential with it
+func (c *credentialService) GenerateSSHKey(options sshkey.GenerateOptions) (sshkey.KeyPair, error) {
+	return sshkey.Generate(options)
+}
+
+// FindSSHKeys returns the ssh key credentials with the given SHA256 fingerprint, they are read like
+// with GetCredentialsOfType
+func (c *credentialService) FindSSHKeys(fingerprint string, access types.AccessContext) ([]types.GenericCredential, error) {
+	ids, err := c.sqlRepository.FindCredentialIDs(types.CredentialTypeSSHKey, "fingerprint", fingerprint)
+	if err != nil || len(ids) == 0 {
+		return []types.GenericCredential{}, err
+	}
+	return c.GetCredentialsOfType(types.CredentialTypeSSHKey, ids, access)
+}
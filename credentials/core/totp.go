@@ -0,0 +1,85 @@
+package core
+
+import (
+	"errors"
+	"regexp"
+	"time"
+
+	"github.com/DO-2K23-26/polypass-microservices/credentials/registry"
+	"github.com/DO-2K23-26/polypass-microservices/credentials/totp"
+	"github.com/DO-2K23-26/polypass-microservices/credentials/types"
+)
+
+var ERR_CREDENTIAL_NOT_FOUND error = errors.New("credential not found")
+
+// ERR_INVALID_PASSWORD_LINK rejects a totp credential linked to a credential that is not a password of
+// its owner, other owners' credentials are not told apart from missing ones
+var ERR_INVALID_PASSWORD_LINK error = errors.New("password_credential_id must be the id of a password credential of the caller")
+
+var credentialIDPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// ParseTOTPURI fills a totp credential from an otpauth:// URI. The title defaults to the issuer, or to
+// the account when the URI has no issuer.
+func (c *credentialService) ParseTOTPURI(uri string, credential types.GenericCredential) (types.GenericCredential, error) {
+	key, err := totp.ParseURI(uri)
+	if err != nil {
+		return credential, err
+	}
+
+	attributes := registry.TOTPAttributes(key)
+	attributes["password_credential_id"] = credential.Attributes["password_credential_id"]
+	credential.Type, credential.Attributes = types.CredentialTypeTOTP, attributes
+	if credential.Title == "" {
+		credential.Title = key.Issuer
+	}
+	if credential.Title == "" {
+		credential.Title = key.AccountName
+	}
+	return credential, nil
+}
+
+// GenerateTOTPCode computes the current code of a totp credential, the seed stays in the service.
+// It counts as a read of the code.
+func (c *credentialService) GenerateTOTPCode(id string, access types.AccessContext) (totp.Code, error) {
+	ids, err := c.ownedIDs([]string{id}, access.Owner)
+	if err != nil {
+		return totp.Code{}, err
+	}
+	credentials, err := c.lookupCredentials(types.CredentialTypeTOTP, ids)
+	if err != nil {
+		return totp.Code{}, err
+	}
+	if len(credentials) == 0 {
+		return totp.Code{}, ERR_CREDENTIAL_NOT_FOUND
+	}
+	code, err := registry.TOTPKey(credentials[0].Attributes).Generate(time.Now())
+	if err != nil {
+		return code, err
+	}
+	err = c.recordAccess(credentials, access, func(types.GenericCredential) []string { return []string{"code"} })
+	return code, err
+}
+
+// CheckTOTPLink makes sure that the password credential a totp credential is linked to exists and
+// belongs to the owner, any password credential can be linked when the owner is nil
+func (c *credentialService) CheckTOTPLink(credential types.GenericCredential, owner *string) error {
+	id, _ := credential.Attributes["password_credential_id"].(string)
+	if credential.Type != types.CredentialTypeTOTP || id == "" {
+		return nil
+	}
+	if !credentialIDPattern.MatchString(id) {
+		return ERR_INVALID_PASSWORD_LINK
+	}
+	owned, err := c.ownedIDs([]string{id}, owner)
+	if err != nil {
+		return err
+	}
+	stored, err := c.sqlRepository.GetCredentialTypes(owned)
+	if err != nil {
+		return err
+	}
+	if stored[id] != types.CredentialTypePassword {
+		return ERR_INVALID_PASSWORD_LINK
+	}
+	return nil
+}
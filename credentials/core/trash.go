@@ -0,0 +1,36 @@
+package core
+
+import (
+	"time"
+
+	"github.com/DO-2K23-26/polypass-microservices/credentials/types"
+)
+
+func (c *credentialService) GetTrash(owner *string, limit int, offset int) ([]types.TrashedCredential, error) {
+	return c.sqlRepository.GetTrash(owner, limit, offset)
+}
+
+// RestoreDeletedCredentials takes credentials out of the trash, they are returned as after a create
+func (c *credentialService) RestoreDeletedCredentials(ids []string) ([]types.GenericCredential, error) {
+	restored, err := c.sqlRepository.RestoreDeletedCredentials(ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range restored {
+		restored[i] = present(restored[i], false)
+	}
+	return restored, nil
+}
+
+// PurgeTrash hard deletes the credentials deleted before the given time, batch by batch, and returns
+// how many were purged
+func (c *credentialService) PurgeTrash(before time.Time, batchSize int) (int, error) {
+	total := 0
+	for {
+		purged, err := c.sqlRepository.PurgeTrash(before, batchSize)
+		total += purged
+		if err != nil || purged < batchSize {
+			return total, err
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sort"
+
+	"github.com/DO-2K23-26/polypass-microservices/credentials/infrastructure/sql"
+	"github.com/DO-2K23-26/polypass-microservices/credentials/registry"
+	"github.com/DO-2K23-26/polypass-microservices/credentials/types"
+)
+
+var ERR_CREDENTIAL_VERSION_NOT_FOUND error = errors.New("credential version not found")
+
+func (c *credentialService) GetCredentialVersions(credentialType types.CredentialType, id string) ([]types.CredentialVersion, error) {
+	versions, err := c.sqlRepository.GetCredentialVersions(id)
+	if err != nil {
+		return nil, err
+	}
+	if len(versions) == 0 || versions[0].Type != credentialType {
+		return nil, ERR_CREDENTIAL_VERSION_NOT_FOUND
+	}
+	return versions, nil
+}
+
+func (c *credentialService) getCredentialVersion(credentialType types.CredentialType, id string, version int) (types.CredentialVersion, error) {
+	credentialVersion, err := c.sqlRepository.GetCredentialVersion(id, version)
+	if errors.Is(err, sql.ERR_NOT_FOUND) || (err == nil && credentialVersion.Type != credentialType) {
+		return credentialVersion, ERR_CREDENTIAL_VERSION_NOT_FOUND
+	}
+	return credentialVersion, err
+}
+
+// DiffCredentialVersions compares two versions field by field, write-only fields are always masked. Secret
+// fields are masked unless access.Reveal is set, a revealed diff goes through the same checks as
+// RevealCredential and is recorded in the access log as a read of the secret fields it shows.
+func (c *credentialService) DiffCredentialVersions(credentialType types.CredentialType, id string, from int, to int, access types.AccessContext) (types.CredentialVersionDiff, error) {
+	diff := types.CredentialVersionDiff{From: from, To: to, Changes: []types.CredentialFieldChange{}}
+	if access.Reveal {
+		if err := c.checkAuthAge(access); err != nil {
+			return diff, err
+		}
+	}
+
+	fromVersion, err := c.getCredentialVersion(credentialType, id, from)
+	if err != nil {
+		return diff, err
+	}
+	toVersion, err := c.getCredentialVersion(credentialType, id, to)
+	if err != nil {
+		return diff, err
+	}
+
+	definition, err := registry.Get(credentialType)
+	if err != nil {
+		return diff, err
+	}
+
+	var fromFields, toFields map[string]any
+	if err := json.Unmarshal(fromVersion.Data, &fromFields); err != nil {
+		return diff, err
+	}
+	if err := json.Unmarshal(toVersion.Data, &toFields); err != nil {
+		return diff, err
+	}
+
+	fields := make([]string, 0, len(fromFields)+len(toFields))
+	for field := range fromFields {
+		fields = append(fields, field)
+	}
+	for field := range toFields {
+		if _, ok := fromFields[field]; !ok {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+
+	var revealed []string
+	for _, field := range fields {
+		// the type is fixed and was not recorded in the oldest snapshots
+		if field == "type" || reflect.DeepEqual(fromFields[field], toFields[field]) {
+			continue
+		}
+		change := types.CredentialFieldChange{Field: field, From: fromFields[field], To: toFields[field]}
+		if (!access.Reveal && definition.IsSecret(field)) || definition.IsWriteOnly(field) {
+			change.From, change.To = types.MaskedSecret, types.MaskedSecret
+		} else if definition.IsSecret(field) {
+			revealed = append(revealed, field)
+		}
+		diff.Changes = append(diff.Changes, change)
+	}
+
+	if len(revealed) > 0 {
+		read := []types.GenericCredential{{Type: credentialType, Credential: types.Credential{ID: id}}}
+		err := c.recordAccess(read, access, func(types.GenericCredential) []string { return revealed })
+		if err != nil {
+			return types.CredentialVersionDiff{From: from, To: to, Changes: []types.CredentialFieldChange{}}, err
+		}
+	}
+	return diff, nil
+}
+
+// RestoreCredentialVersion writes an old version back as a regular update, so a new version is recorded
+// and the usual update event is produced.
+func (c *credentialService) RestoreCredentialVersion(credentialType types.CredentialType, id string, version int, actor *string) (types.GenericCredential, error) {
+	credentialVersion, err := c.getCredentialVersion(credentialType, id, version)
+	if err != nil {
+		return types.GenericCredential{}, err
+	}
+
+	var credential types.GenericCredential
+	if err := json.Unmarshal(credentialVersion.Data, &credential); err != nil {
+		return credential, err
+	}
+	credential.Type, credential.ID, credential.UpdatedBy = credentialVersion.Type, id, actor
+	// the snapshot holds the version it was taken at, the restore applies to whatever the current one is
+	credential.Version = 0
+	return c.UpdateCredential(credential)
+}
@@ -1,11 +1,18 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"os"
 	"os/signal"
+	"sync"
+	"sync/atomic"
 	"syscall"
+	"time"
 
 	"github.com/DO-2K23-26/polypass-microservices/credentials/application"
+	"github.com/DO-2K23-26/polypass-microservices/credentials/config"
 	"github.com/DO-2K23-26/polypass-microservices/credentials/infrastructure"
 	"github.com/optique-dev/optique"
 )
@@ -19,34 +26,106 @@ type Cycle interface {
 	Stop() error
 }
 
+// states of the cycle reported by /readyz, the service is only ready while running
+const (
+	StateStarting = "starting"
+	StateRunning  = "running"
+	StateStopping = "stopping"
+)
+
+const (
+	defaultStartPeriod = 2 * time.Second
+	defaultStopTimeout = 10 * time.Second
+	defaultBackoff     = time.Second
+	defaultMaxBackoff  = time.Minute
+)
+
+// an application returned while the cycle was running and it was not restarted
+var ERR_APPLICATION_STOPPED error = errors.New("application stopped")
+
+// supervised is an application run by the cycle, under the name of its component
+type supervised struct {
+	name     string
+	app      application.Application
+	required bool
+	// closed when the application is stopped for good
+	done chan struct{}
+}
+
+type component struct {
+	name string
+	repo infrastructure.Repository
+}
+
+// AppOption changes how the cycle supervises an application
+type AppOption func(*supervised)
+
+// Required applications stop the service when they fail to start, or when they fail and are not
+// restarted
+func Required() AppOption {
+	return func(s *supervised) {
+		s.required = true
+	}
+}
+
 type cycle struct {
-	repos    []infrastructure.Repository
-	apps     []application.Application
-	shutdown chan os.Signal
+	repos  []component
+	apps   []*supervised
+	config config.CycleConfig
+	state  atomic.Value
+
+	// cancelled when the cycle stops, applications are no longer restarted
+	ctx    context.Context
+	cancel context.CancelFunc
+	// the first failure of a required application, it is returned by Ignite
+	failure  chan error
+	ignited  atomic.Bool
+	stopOnce sync.Once
+	stopped  chan struct{}
 }
 
-func NewCycle() *cycle {
-	return &cycle{
-		shutdown: make(chan os.Signal, 1),
-		repos:    []infrastructure.Repository{},
-		apps:     []application.Application{},
+func NewCycle(config config.CycleConfig) *cycle {
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &cycle{
+		repos:   []component{},
+		apps:    []*supervised{},
+		config:  config,
+		ctx:     ctx,
+		cancel:  cancel,
+		failure: make(chan error, 1),
+		stopped: make(chan struct{}),
 	}
+	c.state.Store(StateStarting)
+	return c
 }
 
-func (c *cycle) AddRepository(repo infrastructure.Repository) {
-	c.repos = append(c.repos, repo)
+// State is starting until the applications ran for the start period, then running until the cycle stops
+func (c *cycle) State() string {
+	return c.state.Load().(string)
 }
 
-func (c *cycle) AddApplications(apps []application.Application) {
-	c.apps = append(c.apps, apps...)
+func (c *cycle) Ready() bool {
+	return c.State() == StateRunning
 }
 
-func (c *cycle) AddRepositories(repos []infrastructure.Repository) {
-	c.repos = append(c.repos, repos...)
+// AddRepository registers a repository under the name of its component. Repositories are shut down in
+// the reverse order, after every application is stopped.
+func (c *cycle) AddRepository(name string, repo infrastructure.Repository) {
+	c.repos = append(c.repos, component{name: name, repo: repo})
 }
 
-func (c *cycle) AddApplication(app application.Application) {
-	c.apps = append(c.apps, app)
+// AddApplication registers an application under the name of its component. Applications are started in
+// this order and stopped in the reverse one.
+func (c *cycle) AddApplication(name string, app application.Application, options ...AppOption) {
+	s := &supervised{
+		name: name,
+		app:  app,
+		done: make(chan struct{}),
+	}
+	for _, option := range options {
+		option(s)
+	}
+	c.apps = append(c.apps, s)
 }
 
 func (c *cycle) Setup() error {
@@ -55,47 +134,212 @@ func (c *cycle) Setup() error {
 		return nil
 	}
 	for _, repository := range c.repos {
-		if err := repository.Setup(); err != nil {
+		if err := repository.repo.Setup(); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-// Ignite starts the application
+// Ignite starts the applications and supervises them until a signal, a call to Stop or the failure of a
+// required application, then shuts everything down. It returns the failure that stopped the service.
 func (c *cycle) Ignite() error {
 	if len(c.apps) == 0 {
 		optique.Info("No application to start")
+		c.shutdown()
 		return nil
 	}
+	c.ignited.Store(true)
+
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	go c.handleSignals(signals)
 
 	for _, app := range c.apps {
-		go func(app application.Application) {
-			err := app.Ignite()
-			if err != nil {
-				optique.Error(err.Error())
+		go c.supervise(app)
+	}
+
+	var err error
+	startPeriod := c.config.StartPeriod
+	if startPeriod <= 0 {
+		startPeriod = defaultStartPeriod
+	}
+	select {
+	case <-time.After(startPeriod):
+		c.state.Store(StateRunning)
+		optique.Info(fmt.Sprintf("%d applications started", len(c.apps)))
+		select {
+		case err = <-c.failure:
+		case <-c.ctx.Done():
+		}
+	case err = <-c.failure:
+	case <-c.ctx.Done():
+	}
+
+	c.shutdown()
+	return err
+}
+
+// handleSignals stops the cycle on the first signal. A second one exits right away, without waiting
+// for the applications to stop.
+func (c *cycle) handleSignals(signals chan os.Signal) {
+	select {
+	case sig := <-signals:
+		optique.Info(fmt.Sprintf("Received %s, stopping", sig))
+		c.cancel()
+	case <-c.ctx.Done():
+	}
+	select {
+	case sig := <-signals:
+		optique.Error(fmt.Sprintf("Received %s again, exiting without graceful shutdown", sig))
+		os.Exit(1)
+	case <-c.stopped:
+	}
+}
+
+// supervise runs an application and restarts it according to its restart policy, until the cycle stops
+func (c *cycle) supervise(s *supervised) {
+	defer close(s.done)
+	restart := c.restartConfig(s.name)
+	backoff := restart.Backoff
+	for restarts := 0; ; restarts++ {
+		err := s.app.Ignite()
+		if c.ctx.Err() != nil {
+			return
+		}
+		if err == nil {
+			err = ERR_APPLICATION_STOPPED
+			optique.Info(fmt.Sprintf("%s stopped", s.name))
+		} else {
+			optique.Error(fmt.Sprintf("%s failed: %s", s.name, err))
+		}
+
+		// a required application that cannot start is not retried, the service fails fast
+		starting := c.State() == StateStarting
+		giveUp := restart.Policy == config.RestartNever ||
+			(restart.Policy == config.RestartOnFailure && errors.Is(err, ERR_APPLICATION_STOPPED)) ||
+			(restart.MaxRestarts > 0 && restarts >= restart.MaxRestarts)
+		if (starting && s.required) || giveUp {
+			if s.required {
+				c.fail(fmt.Errorf("%s: %w", s.name, err))
 			}
-		}(app)
+			return
+		}
+
+		optique.Info(fmt.Sprintf("Restarting %s in %s, restart %d", s.name, backoff, restarts+1))
+		select {
+		case <-time.After(backoff):
+		case <-c.ctx.Done():
+			return
+		}
+		backoff = min(backoff*2, restart.MaxBackoff)
 	}
+}
 
-	signal.Notify(c.shutdown, os.Interrupt, syscall.SIGTERM)
+// fail keeps the first failure of a required application, Ignite then stops the cycle
+func (c *cycle) fail(err error) {
+	select {
+	case c.failure <- err:
+	default:
+	}
+}
 
-	_ = <-c.shutdown
+func (c *cycle) restartConfig(name string) config.RestartConfig {
+	restart := c.config.Restart
+	if component, ok := c.config.Components[name]; ok && component.Restart != "" {
+		restart.Policy = component.Restart
+	}
+	if restart.Policy == "" {
+		restart.Policy = config.RestartOnFailure
+	}
+	if restart.Backoff <= 0 {
+		restart.Backoff = defaultBackoff
+	}
+	if restart.MaxBackoff < restart.Backoff {
+		restart.MaxBackoff = max(defaultMaxBackoff, restart.Backoff)
+	}
+	return restart
+}
 
-	_ = c.Stop()
+func (c *cycle) stopTimeout(name string) time.Duration {
+	if component, ok := c.config.Components[name]; ok && component.StopTimeout > 0 {
+		return component.StopTimeout
+	}
+	if c.config.StopTimeout > 0 {
+		return c.config.StopTimeout
+	}
+	return defaultStopTimeout
+}
 
+// Stop shuts the cycle down and waits for it. It may be called several times, and before Ignite to
+// shut the repositories down when the service cannot start.
+func (c *cycle) Stop() error {
+	if c.ignited.Load() {
+		c.cancel()
+		<-c.stopped
+		return nil
+	}
+	c.shutdown()
 	return nil
 }
 
-// Stop stops the application
-func (c *cycle) Stop() error {
-	optique.Info("Stopping applications with graceful shutdown")
-	close(c.shutdown)
-	for _, app := range c.apps {
-		err := app.Stop()
-		if err != nil {
-			return err
+// shutdown stops the applications in the reverse order they were added, then shuts the repositories
+// down in the reverse order too, e.g. Kafka is flushed before the database is closed
+func (c *cycle) shutdown() {
+	c.stopOnce.Do(func() {
+		defer close(c.stopped)
+		c.cancel()
+
+		// orchestrators see the service not ready and stop routing traffic before the servers stop
+		if c.State() == StateRunning && c.config.DrainDelay > 0 {
+			c.state.Store(StateStopping)
+			optique.Info(fmt.Sprintf("Reporting not ready for %s before stopping", c.config.DrainDelay))
+			time.Sleep(c.config.DrainDelay)
+		}
+		c.state.Store(StateStopping)
+
+		optique.Info("Stopping applications with graceful shutdown")
+		if c.ignited.Load() {
+			for i := len(c.apps) - 1; i >= 0; i-- {
+				c.stopApplication(c.apps[i])
+			}
+		}
+		for i := len(c.repos) - 1; i >= 0; i-- {
+			repository := c.repos[i]
+			err := withTimeout(c.stopTimeout(repository.name), repository.repo.Shutdown)
+			if err != nil {
+				optique.Error(fmt.Sprintf("%s shutdown: %s", repository.name, err))
+			}
 		}
+		optique.Info("Stopped")
+	})
+}
+
+// stopApplication stops an application and waits for it to return, within its stop timeout
+func (c *cycle) stopApplication(s *supervised) {
+	timeout := c.stopTimeout(s.name)
+	deadline := time.Now().Add(timeout)
+	if err := withTimeout(timeout, s.app.Stop); err != nil {
+		optique.Error(fmt.Sprintf("%s stop: %s", s.name, err))
+	}
+	select {
+	case <-s.done:
+	case <-time.After(time.Until(deadline)):
+		optique.Error(fmt.Sprintf("%s did not stop within %s", s.name, timeout))
+	}
+}
+
+// withTimeout runs f and gives up waiting for it after timeout
+func withTimeout(timeout time.Duration, f func() error) error {
+	result := make(chan error, 1)
+	go func() {
+		result <- f()
+	}()
+	select {
+	case err := <-result:
+		return err
+	case <-time.After(timeout):
+		return fmt.Errorf("did not return within %s", timeout)
 	}
-	return nil
 }
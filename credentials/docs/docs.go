@@ -19,9 +19,9 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/credentials/card": {
+        "/credentials": {
             "get": {
-                "description": "Get a list of card credentials",
+                "description": "Get a list of credentials whatever their type, each one carries a type field. Secret attributes are masked.",
                 "consumes": [
                     "application/json"
                 ],
@@ -29,13 +29,13 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "card"
+                    "credentials"
                 ],
-                "summary": "Get card credentials",
+                "summary": "Get credentials of any type",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Comma-separated list of card IDs",
+                        "description": "Comma-separated list of credential IDs",
                         "name": "ids",
                         "in": "query",
                         "required": true
@@ -47,12 +47,25 @@ const docTemplate = `{
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/types.CardCredential"
+                                "type": "object",
+                                "additionalProperties": true
+                            }
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the credential, when a single one is returned"
                             }
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
                             "$ref": "#/definitions/fiber.Map"
                         }
@@ -60,7 +73,7 @@ const docTemplate = `{
                 }
             },
             "post": {
-                "description": "Create a card credential",
+                "description": "Create a credential of the type given by the type field (password, card, ssh_key or totp)",
                 "consumes": [
                     "application/json"
                 ],
@@ -70,15 +83,15 @@ const docTemplate = `{
                 "tags": [
                     "credentials"
                 ],
-                "summary": "Create card credential",
+                "summary": "Create credential",
                 "parameters": [
                     {
-                        "description": "Create card credential options",
+                        "description": "Create credential options",
                         "name": "payload",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/http.CreateCardCredentialOpts"
+                            "$ref": "#/definitions/http.CredentialOpts"
                         }
                     }
                 ],
@@ -86,7 +99,8 @@ const docTemplate = `{
                     "201": {
                         "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/types.CardCredential"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
                     "400": {
@@ -102,33 +116,37 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "delete": {
-                "description": "Delete a list of Card credentials",
+            }
+        },
+        "/credentials/admin/export": {
+            "post": {
+                "description": "Export every credential of every owner into one archive, like /credentials/export. Only service identities may export the vault.",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
-                    "application/json"
+                    "application/octet-stream"
                 ],
                 "tags": [
-                    "credentials"
+                    "archive"
                 ],
-                "summary": "Delete card credentials",
+                "summary": "Export vault archive",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Comma-separated list of credential IDs",
-                        "name": "ids",
-                        "in": "query",
-                        "required": true
+                        "description": "Passphrase of at least 12 characters",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/http.ExportArchiveOpts"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/fiber.Map"
+                            "type": "file"
                         }
                     },
                     "400": {
@@ -137,6 +155,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/fiber.Map"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -146,42 +170,40 @@ const docTemplate = `{
                 }
             }
         },
-        "/credentials/card/:id": {
-            "put": {
-                "description": "Update a card credential",
+        "/credentials/admin/import/archive": {
+            "post": {
+                "description": "Restore an archive with the owners it holds, like /credentials/import/archive. Only service identities may import a vault.",
                 "consumes": [
-                    "application/json"
+                    "multipart/form-data"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "credentials"
+                    "archive"
                 ],
-                "summary": "Update card credential",
+                "summary": "Import vault archive",
                 "parameters": [
                     {
-                        "description": "Update card credential options",
-                        "name": "payload",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/http.CreateCardCredentialOpts"
-                        }
+                        "type": "file",
+                        "description": "Archive",
+                        "name": "archive",
+                        "in": "formData",
+                        "required": true
                     },
                     {
                         "type": "string",
-                        "description": "Credential ID",
-                        "name": "id",
-                        "in": "path",
+                        "description": "Passphrase of the archive",
+                        "name": "passphrase",
+                        "in": "formData",
                         "required": true
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/types.CardCredential"
+                            "$ref": "#/definitions/types.ImportReport"
                         }
                     },
                     "400": {
@@ -190,6 +212,24 @@ const docTemplate = `{
                             "$ref": "#/definitions/fiber.Map"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -199,9 +239,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/credentials/password": {
-            "get": {
-                "description": "Get a list of password credentials",
+        "/credentials/batch": {
+            "post": {
+                "description": "Create, update and delete credentials of any type in one request, with a result per operation. The operations are written in one transaction and produce the same events as single writes. With atomic, nothing is written if one operation fails and the request fails with 400.",
                 "consumes": [
                     "application/json"
                 ],
@@ -211,62 +251,75 @@ const docTemplate = `{
                 "tags": [
                     "credentials"
                 ],
-                "summary": "Get password credentials",
+                "summary": "Write credentials in a batch",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Comma-separated list of credential IDs",
-                        "name": "ids",
-                        "in": "query",
-                        "required": true
+                        "description": "Operations (create, update or delete) and mode",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/http.BatchOpts"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/types.PasswordCredential"
-                            }
+                            "$ref": "#/definitions/types.CredentialBatchReport"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/types.CredentialBatchReport"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
                             "$ref": "#/definitions/fiber.Map"
                         }
                     }
                 }
-            },
+            }
+        },
+        "/credentials/export": {
             "post": {
-                "description": "Create a password credential",
+                "description": "Export the credentials of the caller with their custom fields and version history into one archive encrypted with a key derived from the passphrase (Argon2id, AES-256-GCM). Service identities name the owner to export, or export the whole vault from /credentials/admin/export.",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
-                    "application/json"
+                    "application/octet-stream"
                 ],
                 "tags": [
-                    "credentials"
+                    "archive"
                 ],
-                "summary": "Create password credential",
+                "summary": "Export archive",
                 "parameters": [
                     {
-                        "description": "Create password credential options",
+                        "description": "Passphrase of at least 12 characters",
                         "name": "payload",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/http.CreatePasswordCredentialOpts"
+                            "$ref": "#/definitions/http.ExportArchiveOpts"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Owner to export, for service identities only",
+                        "name": "owner",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/types.PasswordCredential"
+                            "type": "file"
                         }
                     },
                     "400": {
@@ -275,6 +328,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/fiber.Map"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -282,11 +341,13 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "delete": {
-                "description": "Delete a list of password credentials",
+            }
+        },
+        "/credentials/import": {
+            "post": {
+                "description": "Import the export of another password manager given as request body: KeePass 2.x XML, Bitwarden unencrypted JSON or 1Password/Chrome CSV. Duplicates and invalid entries are skipped, the others are created in one transaction. With dry_run nothing is created.",
                 "consumes": [
-                    "application/json"
+                    "text/plain"
                 ],
                 "produces": [
                     "application/json"
@@ -294,21 +355,42 @@ const docTemplate = `{
                 "tags": [
                     "credentials"
                 ],
-                "summary": "Delete password credentials",
+                "summary": "Import credentials",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Comma-separated list of credential IDs",
-                        "name": "ids",
+                        "description": "Export format (keepass, bitwarden or csv)",
+                        "name": "format",
                         "in": "query",
                         "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only report what would be created",
+                        "name": "dry_run",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Export file",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/fiber.Map"
+                            "$ref": "#/definitions/types.ImportReport"
+                        }
+                    },
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/types.ImportReport"
                         }
                     },
                     "400": {
@@ -326,42 +408,46 @@ const docTemplate = `{
                 }
             }
         },
-        "/credentials/password/:id": {
-            "put": {
-                "description": "Update a password credential",
+        "/credentials/import/archive": {
+            "post": {
+                "description": "Restore the credentials of an archive made by the export for the caller, or for the owner named by a service identity, with their ids, timestamps and version history. Credentials already stored are reported as duplicates. An archive holding credentials of another owner is rejected.",
                 "consumes": [
-                    "application/json"
+                    "multipart/form-data"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "credentials"
+                    "archive"
                 ],
-                "summary": "Update password credential",
+                "summary": "Import archive",
                 "parameters": [
                     {
-                        "description": "Update password credential options",
-                        "name": "payload",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/http.CreatePasswordCredentialOpts"
-                        }
+                        "type": "file",
+                        "description": "Archive",
+                        "name": "archive",
+                        "in": "formData",
+                        "required": true
                     },
                     {
                         "type": "string",
-                        "description": "Credential ID",
-                        "name": "id",
-                        "in": "path",
+                        "description": "Passphrase of the archive",
+                        "name": "passphrase",
+                        "in": "formData",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Owner to import for, for service identities only",
+                        "name": "owner",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/types.PasswordCredential"
+                            "$ref": "#/definitions/types.ImportReport"
                         }
                     },
                     "400": {
@@ -370,6 +456,24 @@ const docTemplate = `{
                             "$ref": "#/definitions/fiber.Map"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -379,9 +483,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/credentials/sshkey": {
+        "/credentials/list": {
             "get": {
-                "description": "Get a list of SSHKey credentials",
+                "description": "List credentials of every type page by page, each one carries a type field. Secret attributes are masked.",
                 "consumes": [
                     "application/json"
                 ],
@@ -391,62 +495,98 @@ const docTemplate = `{
                 "tags": [
                     "credentials"
                 ],
-                "summary": "Get SSHKey credentials",
+                "summary": "List credentials",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Comma-separated list of credential IDs",
-                        "name": "ids",
-                        "in": "query",
-                        "required": true
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/types.SSHKeyCredential"
-                            }
-                        }
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
                     },
-                    "404": {
-                        "description": "Not Found",
-                        "schema": {
-                            "$ref": "#/definitions/fiber.Map"
-                        }
-                    }
-                }
-            },
-            "post": {
-                "description": "Create a SSHKey credential",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "credentials"
-                ],
-                "summary": "Create SSHKey credential",
-                "parameters": [
                     {
-                        "description": "Create SSHKey credential options",
-                        "name": "payload",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/http.CreateSSHCredentialOpts"
-                        }
+                        "type": "integer",
+                        "description": "Maximum number of credentials (default 100, at most 1000)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "title, created_at (default), updated_at or last_read_at",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "asc (default) or desc",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Case-insensitive prefix of the title",
+                        "name": "title_prefix",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Domain name of password credentials",
+                        "name": "domain_name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Hostname of SSH keys",
+                        "name": "hostname",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "RFC 3339 time",
+                        "name": "created_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "RFC 3339 time",
+                        "name": "created_before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "RFC 3339 time",
+                        "name": "updated_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "RFC 3339 time",
+                        "name": "updated_before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "expired, expiring, valid or none",
+                        "name": "expiry",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Window of the expiring status in days (default 30)",
+                        "name": "expiring_days",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated list of custom field keys the credentials must have",
+                        "name": "custom_fields",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/types.SSHKeyCredential"
+                            "$ref": "#/definitions/types.CredentialPage"
                         }
                     },
                     "400": {
@@ -462,9 +602,11 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "delete": {
-                "description": "Delete a list of SSHKey credentials",
+            }
+        },
+        "/credentials/password/breaches": {
+            "get": {
+                "description": "Get the last breach check of password credentials, credentials never checked are left out",
                 "consumes": [
                     "application/json"
                 ],
@@ -472,9 +614,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "credentials"
+                    "passwords"
                 ],
-                "summary": "Delete SSHKey credentials",
+                "summary": "Get password breaches",
                 "parameters": [
                     {
                         "type": "string",
@@ -488,7 +630,10 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/fiber.Map"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/types.PasswordBreach"
+                            }
                         }
                     },
                     "400": {
@@ -497,6 +642,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/fiber.Map"
                         }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -506,9 +657,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/credentials/sshkey/:id": {
-            "put": {
-                "description": "Update a SSHKey credential",
+        "/credentials/password/breaches/scan": {
+            "post": {
+                "description": "Check every password credential of the vault against the breach dataset and store the results",
                 "consumes": [
                     "application/json"
                 ],
@@ -516,32 +667,323 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "credentials"
+                    "passwords"
                 ],
-                "summary": "Update SSHKey credential",
-                "parameters": [
+                "summary": "Scan password breaches",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/types.PasswordBreachScan"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    }
+                }
+            }
+        },
+        "/credentials/sshkey/search": {
+            "get": {
+                "description": "Get the ssh key credentials with the given SHA256 fingerprint, e.g. SHA256:uNiVztksCsDhcc0u9e8BujQXVUpKZIDTMczCvj3tD2s",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sshkeys"
+                ],
+                "summary": "Find SSH keys by fingerprint",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "SHA256 fingerprint",
+                        "name": "fingerprint",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "object",
+                                "additionalProperties": true
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    }
+                }
+            }
+        },
+        "/credentials/totp/import": {
+            "post": {
+                "description": "Create a totp credential from an otpauth:// URI, the title defaults to the issuer of the URI",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "totp"
+                ],
+                "summary": "Import TOTP credential",
+                "parameters": [
                     {
-                        "description": "Update SSHKey credential options",
+                        "description": "Import TOTP credential options",
                         "name": "payload",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/http.CreateSSHCredentialOpts"
+                            "$ref": "#/definitions/http.ImportTOTPCredentialOpts"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/types.TOTPCredential"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    }
+                }
+            }
+        },
+        "/credentials/totp/{id}/code": {
+            "get": {
+                "description": "Get the current code of a totp credential and the seconds it stays valid",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "totp"
+                ],
+                "summary": "Get TOTP code",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Credential ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/totp.Code"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    }
+                }
+            }
+        },
+        "/credentials/trash": {
+            "get": {
+                "description": "Get the deleted credentials of every type, the last deleted first. They are purged after trash.retention.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "credentials"
+                ],
+                "summary": "Get trash",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of entries (default 100, at most 1000)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of entries to skip",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/types.TrashedCredential"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    }
+                }
+            }
+        },
+        "/credentials/trash/restore": {
+            "post": {
+                "description": "Take credentials of any type out of the trash, a creation event is produced for each one. Ids that are not in the trash are left out.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "credentials"
+                ],
+                "summary": "Restore deleted credentials",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Comma-separated list of credential IDs",
+                        "name": "ids",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "object",
+                                "additionalProperties": true
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
                         }
                     },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    }
+                }
+            }
+        },
+        "/credentials/{id}/access-log": {
+            "get": {
+                "description": "Get who read a credential, when, which fields and from where, newest first",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "credentials"
+                ],
+                "summary": "Get credential access log",
+                "parameters": [
                     {
                         "type": "string",
                         "description": "Credential ID",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of entries (default 100, at most 1000)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of entries to skip",
+                        "name": "offset",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/types.SSHKeyCredential"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/types.CredentialAccess"
+                            }
                         }
                     },
                     "400": {
@@ -550,6 +992,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/fiber.Map"
                         }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -558,51 +1006,1142 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "fiber.Map": {
+        },
+        "/credentials/{type}": {
+            "get": {
+                "description": "Get a list of credentials of one type, secret attributes are masked",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "credentials"
+                ],
+                "summary": "Get credentials of a type",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Credential type (password, card, sshkey or totp)",
+                        "name": "type",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated list of credential IDs",
+                        "name": "ids",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "object",
+                                "additionalProperties": true
+                            }
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the credential, when a single one is returned"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create a credential of the type given in the path, its attributes are given next to title, note and custom_fields",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "credentials"
+                ],
+                "summary": "Create credential of a type",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Credential type (password, card, sshkey or totp)",
+                        "name": "type",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Create credential options",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/http.CredentialOpts"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Move a list of credentials of one type to the trash, they are purged after trash.retention",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "credentials"
+                ],
+                "summary": "Delete credentials",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Credential type (password, card, sshkey or totp)",
+                        "name": "type",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated list of credential IDs",
+                        "name": "ids",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    }
+                }
+            }
+        },
+        "/credentials/{type}/list": {
+            "get": {
+                "description": "List credentials of one type page by page, secret attributes are masked",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "credentials"
+                ],
+                "summary": "List credentials of a type",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Credential type (password, card, sshkey or totp)",
+                        "name": "type",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of credentials (default 100, at most 1000)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "title, created_at (default), updated_at or last_read_at",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "asc (default) or desc",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Case-insensitive prefix of the title",
+                        "name": "title_prefix",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Domain name of password credentials",
+                        "name": "domain_name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Hostname of SSH keys",
+                        "name": "hostname",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "RFC 3339 time",
+                        "name": "created_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "RFC 3339 time",
+                        "name": "created_before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "RFC 3339 time",
+                        "name": "updated_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "RFC 3339 time",
+                        "name": "updated_before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "expired, expiring, valid or none",
+                        "name": "expiry",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Window of the expiring status in days (default 30)",
+                        "name": "expiring_days",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated list of custom field keys the credentials must have",
+                        "name": "custom_fields",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/types.CredentialPage"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    }
+                }
+            }
+        },
+        "/credentials/{type}/{id}": {
+            "put": {
+                "description": "Replace a credential of the type given in the path. If-Match must give the ETag returned when the credential was read, the update fails with 412 if another one happened since.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "credentials"
+                ],
+                "summary": "Update credential",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Credential type (password, card, sshkey or totp)",
+                        "name": "type",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Credential ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the credential as read, or * to overwrite any version",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Update credential options",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/http.CredentialOpts"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the updated credential"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    },
+                    "412": {
+                        "description": "The credential was updated since it was read, the body gives the current version",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    }
+                }
+            }
+        },
+        "/credentials/{type}/{id}/reveal": {
+            "post": {
+                "description": "Get one credential with its secret attributes in clear, the read is recorded in the access log. When reveal.max_auth_age is set, the auth_time claim of the token must tell that the caller authenticated recently.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "credentials"
+                ],
+                "summary": "Reveal credential",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Credential type (password, card, sshkey or totp)",
+                        "name": "type",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Credential ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    }
+                }
+            }
+        },
+        "/credentials/{type}/{id}/versions": {
+            "get": {
+                "description": "Get the version history of a credential, oldest first",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "versions"
+                ],
+                "summary": "Get credential versions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Credential type (password, card, sshkey or totp)",
+                        "name": "type",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Credential ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/types.CredentialVersion"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    }
+                }
+            }
+        },
+        "/credentials/{type}/{id}/versions/diff": {
+            "get": {
+                "description": "Compare two versions of a credential field by field, secret fields are masked unless reveal is true. A revealed diff is recorded in the access log like a reveal, and requires a recent authentication when reveal.max_auth_age is set.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "versions"
+                ],
+                "summary": "Diff credential versions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Credential type (password, card, sshkey or totp)",
+                        "name": "type",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Credential ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Version to compare from",
+                        "name": "from",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Version to compare to",
+                        "name": "to",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Show secret fields in clear",
+                        "name": "reveal",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/types.CredentialVersionDiff"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    }
+                }
+            }
+        },
+        "/credentials/{type}/{id}/versions/{version}/restore": {
+            "post": {
+                "description": "Write an old version of a credential back, it is recorded as a new version",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "versions"
+                ],
+                "summary": "Restore credential version",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Credential type (password, card, sshkey or totp)",
+                        "name": "type",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Credential ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Version to restore",
+                        "name": "version",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    }
+                }
+            }
+        },
+        "/livez": {
+            "get": {
+                "description": "Answer as long as the process serves requests, dependencies are not checked so that an outage of the database does not restart the service",
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Liveness probe",
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/passwords/breaches": {
+            "post": {
+                "description": "Count the occurrences of a password in the offline breach dataset, nothing is sent outside the service",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "passwords"
+                ],
+                "summary": "Check password breach",
+                "parameters": [
+                    {
+                        "description": "Password to check",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/http.PasswordBreachOpts"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    }
+                }
+            }
+        },
+        "/passwords/generate": {
+            "post": {
+                "description": "Generate a random password, every character class is used when none is selected",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "passwords"
+                ],
+                "summary": "Generate password",
+                "parameters": [
+                    {
+                        "description": "Generator options",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/http.GeneratePasswordOpts"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/http.GeneratedPassword"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    }
+                }
+            }
+        },
+        "/passwords/passphrase": {
+            "post": {
+                "description": "Generate a passphrase from the EFF diceware wordlist",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "passwords"
+                ],
+                "summary": "Generate passphrase",
+                "parameters": [
+                    {
+                        "description": "Passphrase options",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/http.GeneratePassphraseOpts"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/http.GeneratedPassword"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    }
+                }
+            }
+        },
+        "/passwords/strength": {
+            "post": {
+                "description": "Estimate the entropy of a password and flag dictionary words, sequences, keyboard patterns and reuse of the title, domain or user identifier",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "passwords"
+                ],
+                "summary": "Estimate password strength",
+                "parameters": [
+                    {
+                        "description": "Password and its context",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/http.PasswordStrengthOpts"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/password.Strength"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    }
+                }
+            }
+        },
+        "/readyz": {
+            "get": {
+                "description": "Check the database pool, the migration version, Kafka and the key provider, with the status and latency of each. The service is not ready while it starts or stops.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Readiness probe",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/http.ReadinessReport"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/http.ReadinessReport"
+                        }
+                    }
+                }
+            }
+        },
+        "/sshkeys/generate": {
+            "post": {
+                "description": "Generate an ed25519, ECDSA or RSA key pair, the private key is in OpenSSH format and encrypted when a passphrase is given. The pair is not stored.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sshkeys"
+                ],
+                "summary": "Generate SSH key pair",
+                "parameters": [
+                    {
+                        "description": "Key options",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/http.GenerateSSHKeyOpts"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/sshkey.KeyPair"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "fiber.Map": {
+            "type": "object",
+            "additionalProperties": true
+        },
+        "http.BatchOperationOpts": {
+            "type": "object",
+            "required": [
+                "op",
+                "type"
+            ],
+            "properties": {
+                "credential": {
+                    "$ref": "#/definitions/http.CredentialOpts"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "op": {
+                    "$ref": "#/definitions/types.CredentialOperationKind"
+                },
+                "type": {
+                    "$ref": "#/definitions/types.CredentialType"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "http.BatchOpts": {
+            "type": "object",
+            "required": [
+                "operations"
+            ],
+            "properties": {
+                "atomic": {
+                    "type": "boolean"
+                },
+                "operations": {
+                    "type": "array",
+                    "maxItems": 1000,
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/http.BatchOperationOpts"
+                    }
+                }
+            }
+        },
+        "http.CredentialOpts": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "custom_fields": {
+                    "type": "object",
+                    "additionalProperties": {}
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "last_read_at": {
+                    "type": "string"
+                },
+                "note": {
+                    "type": "string"
+                },
+                "owner": {
+                    "description": "Owner is the subject that created the credential, only it and service identities access it",
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "type": {
+                    "$ref": "#/definitions/types.CredentialType"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "updated_by": {
+                    "type": "string"
+                },
+                "version": {
+                    "description": "Version is incremented by every update, it is the ETag of the credential",
+                    "type": "integer"
+                }
+            }
+        },
+        "http.DependencyStatus": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "latency_ms": {
+                    "type": "number"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "http.ExportArchiveOpts": {
+            "type": "object",
+            "required": [
+                "passphrase"
+            ],
+            "properties": {
+                "passphrase": {
+                    "type": "string"
+                }
+            }
+        },
+        "http.GeneratePassphraseOpts": {
             "type": "object",
-            "additionalProperties": true
+            "properties": {
+                "capitalize": {
+                    "type": "boolean"
+                },
+                "number": {
+                    "description": "Number appends a random digit to one of the words",
+                    "type": "boolean"
+                },
+                "separator": {
+                    "description": "Separator defaults to \"-\"",
+                    "type": "string"
+                },
+                "words": {
+                    "description": "Words defaults to 6",
+                    "type": "integer"
+                }
+            }
         },
-        "http.CreateCardCredentialOpts": {
+        "http.GeneratePasswordOpts": {
             "type": "object",
             "properties": {
-                "card_number": {
+                "digits": {
+                    "type": "boolean"
+                },
+                "exclude_ambiguous": {
+                    "description": "ExcludeAmbiguous leaves out characters such as l, 1, O and 0",
+                    "type": "boolean"
+                },
+                "length": {
+                    "description": "Length defaults to 20",
                     "type": "integer"
                 },
-                "custom_fields": {
-                    "type": "object",
-                    "additionalProperties": {}
+                "lowercase": {
+                    "type": "boolean"
                 },
-                "cvc": {
+                "symbols": {
+                    "type": "boolean"
+                },
+                "uppercase": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "http.GenerateSSHKeyOpts": {
+            "type": "object",
+            "properties": {
+                "bits": {
+                    "description": "Bits is the size of rsa keys, 4096 when zero, or the curve of ecdsa keys, 256 when zero",
                     "type": "integer"
                 },
-                "expiration_date": {
+                "comment": {
                     "type": "string"
                 },
-                "note": {
+                "passphrase": {
+                    "description": "Passphrase encrypts the private key when set",
                     "type": "string"
                 },
-                "owner_name": {
+                "type": {
+                    "description": "Type is ed25519, ecdsa or rsa, ed25519 when empty",
                     "type": "string"
-                },
-                "title": {
+                }
+            }
+        },
+        "http.GeneratedPassword": {
+            "type": "object",
+            "properties": {
+                "password": {
                     "type": "string"
+                },
+                "strength": {
+                    "$ref": "#/definitions/password.Strength"
                 }
             }
         },
-        "http.CreatePasswordCredentialOpts": {
+        "http.ImportTOTPCredentialOpts": {
             "type": "object",
+            "required": [
+                "uri"
+            ],
             "properties": {
                 "custom_fields": {
                     "type": "object",
                     "additionalProperties": {}
                 },
-                "domain_name": {
+                "note": {
                     "type": "string"
                 },
-                "note": {
+                "password_credential_id": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "uri": {
+                    "type": "string"
+                }
+            }
+        },
+        "http.PasswordBreachOpts": {
+            "type": "object",
+            "required": [
+                "password"
+            ],
+            "properties": {
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
+        "http.PasswordStrengthOpts": {
+            "type": "object",
+            "required": [
+                "password"
+            ],
+            "properties": {
+                "domain_name": {
                     "type": "string"
                 },
                 "password": {
@@ -610,79 +2149,278 @@ const docTemplate = `{
                 },
                 "title": {
                     "type": "string"
+                },
+                "user_identifier": {
+                    "type": "string"
                 }
             }
         },
-        "http.CreateSSHCredentialOpts": {
+        "http.ReadinessReport": {
             "type": "object",
             "properties": {
-                "custom_fields": {
+                "checks": {
                     "type": "object",
-                    "additionalProperties": {}
+                    "additionalProperties": {
+                        "$ref": "#/definitions/http.DependencyStatus"
+                    }
                 },
-                "hostname": {
+                "lifecycle": {
                     "type": "string"
                 },
-                "note": {
+                "status": {
                     "type": "string"
+                }
+            }
+        },
+        "password.Strength": {
+            "type": "object",
+            "properties": {
+                "entropy": {
+                    "description": "Entropy in bits, once the patterns found in the password are accounted for",
+                    "type": "number"
                 },
-                "private_key": {
+                "flags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "score": {
+                    "description": "Score from 0 (very weak) to 4 (very strong)",
+                    "type": "integer"
+                }
+            }
+        },
+        "sshkey.KeyPair": {
+            "type": "object",
+            "properties": {
+                "bits": {
+                    "type": "integer"
+                },
+                "fingerprint": {
+                    "description": "Fingerprint is the SHA256 fingerprint as printed by ssh-keygen -l, e.g. SHA256:uNiVztksCsDhcc0u9e8BujQXVUpKZIDTMczCvj3tD2s",
                     "type": "string"
                 },
-                "public_key": {
+                "key_type": {
+                    "description": "KeyType is the SSH name of the key type, e.g. ssh-ed25519 or ssh-rsa",
                     "type": "string"
                 },
-                "title": {
+                "private_key": {
+                    "type": "string"
+                },
+                "public_key": {
                     "type": "string"
                 }
             }
         },
-        "types.CardCredential": {
+        "totp.Code": {
             "type": "object",
             "properties": {
-                "card_number": {
+                "code": {
+                    "type": "string"
+                },
+                "period": {
                     "type": "integer"
                 },
-                "created_at": {
+                "remaining": {
+                    "type": "integer"
+                }
+            }
+        },
+        "types.CredentialAccess": {
+            "type": "object",
+            "properties": {
+                "actor": {
                     "type": "string"
                 },
-                "custom_fields": {
-                    "type": "object",
-                    "additionalProperties": {}
+                "credential_id": {
+                    "type": "string"
                 },
-                "cvc": {
+                "fields": {
+                    "description": "Fields are the type-specific fields that were returned",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "id": {
                     "type": "integer"
                 },
-                "expiration_date": {
+                "read_at": {
                     "type": "string"
                 },
-                "expires_at": {
+                "source_ip": {
                     "type": "string"
                 },
-                "id": {
+                "type": {
+                    "$ref": "#/definitions/types.CredentialType"
+                },
+                "user_agent": {
                     "type": "string"
+                }
+            }
+        },
+        "types.CredentialBatchReport": {
+            "type": "object",
+            "properties": {
+                "atomic": {
+                    "type": "boolean"
                 },
-                "last_read_at": {
+                "failed": {
+                    "type": "integer"
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.CredentialOperationResult"
+                    }
+                },
+                "succeeded": {
+                    "type": "integer"
+                }
+            }
+        },
+        "types.CredentialFieldChange": {
+            "type": "object",
+            "properties": {
+                "field": {
                     "type": "string"
                 },
-                "note": {
+                "from": {},
+                "to": {}
+            }
+        },
+        "types.CredentialOperationKind": {
+            "type": "string",
+            "enum": [
+                "create",
+                "update",
+                "delete"
+            ],
+            "x-enum-varnames": [
+                "CredentialOperationCreate",
+                "CredentialOperationUpdate",
+                "CredentialOperationDelete"
+            ]
+        },
+        "types.CredentialOperationResult": {
+            "type": "object",
+            "properties": {
+                "error": {
                     "type": "string"
                 },
-                "owner_name": {
+                "id": {
                     "type": "string"
                 },
-                "title": {
+                "index": {
+                    "type": "integer"
+                },
+                "op": {
+                    "$ref": "#/definitions/types.CredentialOperationKind"
+                },
+                "status": {
+                    "$ref": "#/definitions/types.CredentialOperationStatus"
+                },
+                "type": {
+                    "$ref": "#/definitions/types.CredentialType"
+                },
+                "version": {
+                    "description": "Version of the written credential, or the current one on a conflict",
+                    "type": "integer"
+                }
+            }
+        },
+        "types.CredentialOperationStatus": {
+            "type": "string",
+            "enum": [
+                "created",
+                "updated",
+                "deleted",
+                "invalid",
+                "not_found",
+                "conflict",
+                "failed",
+                "rolled_back"
+            ],
+            "x-enum-varnames": [
+                "CredentialOperationCreated",
+                "CredentialOperationUpdated",
+                "CredentialOperationDeleted",
+                "CredentialOperationInvalid",
+                "CredentialOperationNotFound",
+                "CredentialOperationConflict",
+                "CredentialOperationFailed",
+                "CredentialOperationRolledBack"
+            ]
+        },
+        "types.CredentialPage": {
+            "type": "object",
+            "properties": {
+                "credentials": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.GenericCredential"
+                    }
+                },
+                "next_cursor": {
+                    "description": "NextCursor is empty on the last page",
+                    "type": "string"
+                }
+            }
+        },
+        "types.CredentialType": {
+            "type": "string",
+            "enum": [
+                "card",
+                "password",
+                "ssh_key",
+                "totp"
+            ],
+            "x-enum-varnames": [
+                "CredentialTypeCard",
+                "CredentialTypePassword",
+                "CredentialTypeSSHKey",
+                "CredentialTypeTOTP"
+            ]
+        },
+        "types.CredentialVersion": {
+            "type": "object",
+            "properties": {
+                "actor": {
                     "type": "string"
                 },
-                "updated_at": {
+                "created_at": {
                     "type": "string"
                 },
-                "user_identifier": {
+                "credential_id": {
                     "type": "string"
+                },
+                "type": {
+                    "$ref": "#/definitions/types.CredentialType"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "types.CredentialVersionDiff": {
+            "type": "object",
+            "properties": {
+                "changes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.CredentialFieldChange"
+                    }
+                },
+                "from": {
+                    "type": "integer"
+                },
+                "to": {
+                    "type": "integer"
                 }
             }
         },
-        "types.PasswordCredential": {
+        "types.GenericCredential": {
             "type": "object",
             "properties": {
                 "created_at": {
@@ -692,9 +2430,6 @@ const docTemplate = `{
                     "type": "object",
                     "additionalProperties": {}
                 },
-                "domain_name": {
-                    "type": "string"
-                },
                 "expires_at": {
                     "type": "string"
                 },
@@ -707,23 +2442,113 @@ const docTemplate = `{
                 "note": {
                     "type": "string"
                 },
-                "password": {
+                "owner": {
+                    "description": "Owner is the subject that created the credential, only it and service identities access it",
                     "type": "string"
                 },
                 "title": {
                     "type": "string"
                 },
+                "type": {
+                    "$ref": "#/definitions/types.CredentialType"
+                },
                 "updated_at": {
                     "type": "string"
                 },
-                "user_identifier": {
+                "updated_by": {
+                    "type": "string"
+                },
+                "version": {
+                    "description": "Version is incremented by every update, it is the ETag of the credential",
+                    "type": "integer"
+                }
+            }
+        },
+        "types.ImportItem": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "id": {
+                    "description": "ID of the created credential, or of the stored one a duplicate matches when known",
+                    "type": "string"
+                },
+                "index": {
+                    "type": "integer"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "type": {
+                    "$ref": "#/definitions/types.CredentialType"
+                }
+            }
+        },
+        "types.ImportReport": {
+            "type": "object",
+            "properties": {
+                "created": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.ImportItem"
+                    }
+                },
+                "dry_run": {
+                    "type": "boolean"
+                },
+                "duplicates": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.ImportItem"
+                    }
+                },
+                "invalid": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.ImportItem"
+                    }
+                }
+            }
+        },
+        "types.PasswordBreach": {
+            "type": "object",
+            "properties": {
+                "checked_at": {
+                    "type": "string"
+                },
+                "compromised": {
+                    "type": "boolean"
+                },
+                "credential_id": {
                     "type": "string"
+                },
+                "occurrences": {
+                    "description": "Occurrences is the number of times the password appears in the breach dataset",
+                    "type": "integer"
+                }
+            }
+        },
+        "types.PasswordBreachScan": {
+            "type": "object",
+            "properties": {
+                "compromised": {
+                    "type": "integer"
+                },
+                "scanned": {
+                    "type": "integer"
                 }
             }
         },
-        "types.SSHKeyCredential": {
+        "types.TOTPCredential": {
             "type": "object",
             "properties": {
+                "account_name": {
+                    "type": "string"
+                },
+                "algorithm": {
+                    "type": "string"
+                },
                 "created_at": {
                     "type": "string"
                 },
@@ -731,13 +2556,16 @@ const docTemplate = `{
                     "type": "object",
                     "additionalProperties": {}
                 },
+                "digits": {
+                    "type": "integer"
+                },
                 "expires_at": {
                     "type": "string"
                 },
-                "hostname": {
+                "id": {
                     "type": "string"
                 },
-                "id": {
+                "issuer": {
                     "type": "string"
                 },
                 "last_read_at": {
@@ -746,10 +2574,17 @@ const docTemplate = `{
                 "note": {
                     "type": "string"
                 },
-                "private_key": {
+                "owner": {
+                    "description": "Owner is the subject that created the credential, only it and service identities access it",
                     "type": "string"
                 },
-                "public_key": {
+                "password_credential_id": {
+                    "type": "string"
+                },
+                "period": {
+                    "type": "integer"
+                },
+                "secret": {
                     "type": "string"
                 },
                 "title": {
@@ -758,8 +2593,32 @@ const docTemplate = `{
                 "updated_at": {
                     "type": "string"
                 },
-                "user_identifier": {
+                "updated_by": {
+                    "type": "string"
+                },
+                "version": {
+                    "description": "Version is incremented by every update, it is the ETag of the credential",
+                    "type": "integer"
+                }
+            }
+        },
+        "types.TrashedCredential": {
+            "type": "object",
+            "properties": {
+                "deleted_at": {
+                    "type": "string"
+                },
+                "deleted_by": {
+                    "type": "string"
+                },
+                "id": {
                     "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "type": {
+                    "$ref": "#/definitions/types.CredentialType"
                 }
             }
         }
@@ -0,0 +1,1015 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: proto/credentials.proto
+
+package api
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	structpb "google.golang.org/protobuf/types/known/structpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Credential is a credential of any type, the attributes of its type are set in one of the typed
+// messages. Secret attributes are masked as in the HTTP API.
+type Credential struct {
+	state        protoimpl.MessageState `protogen:"open.v1"`
+	Id           string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Title        string                 `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
+	Note         string                 `protobuf:"bytes,3,opt,name=note,proto3" json:"note,omitempty"`
+	CreatedAt    *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt    *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	ExpiresAt    *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	LastReadAt   *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=last_read_at,json=lastReadAt,proto3" json:"last_read_at,omitempty"`
+	CustomFields *structpb.Struct       `protobuf:"bytes,8,opt,name=custom_fields,json=customFields,proto3" json:"custom_fields,omitempty"`
+	UpdatedBy    *string                `protobuf:"bytes,9,opt,name=updated_by,json=updatedBy,proto3,oneof" json:"updated_by,omitempty"`
+	// version is the ETag of the credential in the HTTP API
+	Version int32 `protobuf:"varint,10,opt,name=version,proto3" json:"version,omitempty"`
+	// type is the credential type of the HTTP API, e.g. password or ssh_key
+	Type string `protobuf:"bytes,11,opt,name=type,proto3" json:"type,omitempty"`
+	// Types that are valid to be assigned to Attributes:
+	//
+	//	*Credential_Password
+	//	*Credential_Card
+	//	*Credential_SshKey
+	//	*Credential_Totp
+	Attributes    isCredential_Attributes `protobuf_oneof:"attributes"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Credential) Reset() {
+	*x = Credential{}
+	mi := &file_proto_credentials_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Credential) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Credential) ProtoMessage() {}
+
+func (x *Credential) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_credentials_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Credential.ProtoReflect.Descriptor instead.
+func (*Credential) Descriptor() ([]byte, []int) {
+	return file_proto_credentials_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Credential) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Credential) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *Credential) GetNote() string {
+	if x != nil {
+		return x.Note
+	}
+	return ""
+}
+
+func (x *Credential) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Credential) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+func (x *Credential) GetExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return nil
+}
+
+func (x *Credential) GetLastReadAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LastReadAt
+	}
+	return nil
+}
+
+func (x *Credential) GetCustomFields() *structpb.Struct {
+	if x != nil {
+		return x.CustomFields
+	}
+	return nil
+}
+
+func (x *Credential) GetUpdatedBy() string {
+	if x != nil && x.UpdatedBy != nil {
+		return *x.UpdatedBy
+	}
+	return ""
+}
+
+func (x *Credential) GetVersion() int32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *Credential) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Credential) GetAttributes() isCredential_Attributes {
+	if x != nil {
+		return x.Attributes
+	}
+	return nil
+}
+
+func (x *Credential) GetPassword() *PasswordAttributes {
+	if x != nil {
+		if x, ok := x.Attributes.(*Credential_Password); ok {
+			return x.Password
+		}
+	}
+	return nil
+}
+
+func (x *Credential) GetCard() *CardAttributes {
+	if x != nil {
+		if x, ok := x.Attributes.(*Credential_Card); ok {
+			return x.Card
+		}
+	}
+	return nil
+}
+
+func (x *Credential) GetSshKey() *SSHKeyAttributes {
+	if x != nil {
+		if x, ok := x.Attributes.(*Credential_SshKey); ok {
+			return x.SshKey
+		}
+	}
+	return nil
+}
+
+func (x *Credential) GetTotp() *TOTPAttributes {
+	if x != nil {
+		if x, ok := x.Attributes.(*Credential_Totp); ok {
+			return x.Totp
+		}
+	}
+	return nil
+}
+
+type isCredential_Attributes interface {
+	isCredential_Attributes()
+}
+
+type Credential_Password struct {
+	Password *PasswordAttributes `protobuf:"bytes,12,opt,name=password,proto3,oneof"`
+}
+
+type Credential_Card struct {
+	Card *CardAttributes `protobuf:"bytes,13,opt,name=card,proto3,oneof"`
+}
+
+type Credential_SshKey struct {
+	SshKey *SSHKeyAttributes `protobuf:"bytes,14,opt,name=ssh_key,json=sshKey,proto3,oneof"`
+}
+
+type Credential_Totp struct {
+	Totp *TOTPAttributes `protobuf:"bytes,15,opt,name=totp,proto3,oneof"`
+}
+
+func (*Credential_Password) isCredential_Attributes() {}
+
+func (*Credential_Card) isCredential_Attributes() {}
+
+func (*Credential_SshKey) isCredential_Attributes() {}
+
+func (*Credential_Totp) isCredential_Attributes() {}
+
+type PasswordAttributes struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	UserIdentifier string                 `protobuf:"bytes,1,opt,name=user_identifier,json=userIdentifier,proto3" json:"user_identifier,omitempty"`
+	Password       string                 `protobuf:"bytes,2,opt,name=password,proto3" json:"password,omitempty"`
+	DomainName     string                 `protobuf:"bytes,3,opt,name=domain_name,json=domainName,proto3" json:"domain_name,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *PasswordAttributes) Reset() {
+	*x = PasswordAttributes{}
+	mi := &file_proto_credentials_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PasswordAttributes) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PasswordAttributes) ProtoMessage() {}
+
+func (x *PasswordAttributes) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_credentials_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PasswordAttributes.ProtoReflect.Descriptor instead.
+func (*PasswordAttributes) Descriptor() ([]byte, []int) {
+	return file_proto_credentials_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *PasswordAttributes) GetUserIdentifier() string {
+	if x != nil {
+		return x.UserIdentifier
+	}
+	return ""
+}
+
+func (x *PasswordAttributes) GetPassword() string {
+	if x != nil {
+		return x.Password
+	}
+	return ""
+}
+
+func (x *PasswordAttributes) GetDomainName() string {
+	if x != nil {
+		return x.DomainName
+	}
+	return ""
+}
+
+type CardAttributes struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	UserIdentifier string                 `protobuf:"bytes,1,opt,name=user_identifier,json=userIdentifier,proto3" json:"user_identifier,omitempty"`
+	OwnerName      string                 `protobuf:"bytes,2,opt,name=owner_name,json=ownerName,proto3" json:"owner_name,omitempty"`
+	Cvc            string                 `protobuf:"bytes,3,opt,name=cvc,proto3" json:"cvc,omitempty"`
+	// MM/YY
+	ExpirationDate string `protobuf:"bytes,4,opt,name=expiration_date,json=expirationDate,proto3" json:"expiration_date,omitempty"`
+	CardNumber     string `protobuf:"bytes,5,opt,name=card_number,json=cardNumber,proto3" json:"card_number,omitempty"`
+	Brand          string `protobuf:"bytes,6,opt,name=brand,proto3" json:"brand,omitempty"`
+	LastFour       string `protobuf:"bytes,7,opt,name=last_four,json=lastFour,proto3" json:"last_four,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *CardAttributes) Reset() {
+	*x = CardAttributes{}
+	mi := &file_proto_credentials_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CardAttributes) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CardAttributes) ProtoMessage() {}
+
+func (x *CardAttributes) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_credentials_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CardAttributes.ProtoReflect.Descriptor instead.
+func (*CardAttributes) Descriptor() ([]byte, []int) {
+	return file_proto_credentials_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *CardAttributes) GetUserIdentifier() string {
+	if x != nil {
+		return x.UserIdentifier
+	}
+	return ""
+}
+
+func (x *CardAttributes) GetOwnerName() string {
+	if x != nil {
+		return x.OwnerName
+	}
+	return ""
+}
+
+func (x *CardAttributes) GetCvc() string {
+	if x != nil {
+		return x.Cvc
+	}
+	return ""
+}
+
+func (x *CardAttributes) GetExpirationDate() string {
+	if x != nil {
+		return x.ExpirationDate
+	}
+	return ""
+}
+
+func (x *CardAttributes) GetCardNumber() string {
+	if x != nil {
+		return x.CardNumber
+	}
+	return ""
+}
+
+func (x *CardAttributes) GetBrand() string {
+	if x != nil {
+		return x.Brand
+	}
+	return ""
+}
+
+func (x *CardAttributes) GetLastFour() string {
+	if x != nil {
+		return x.LastFour
+	}
+	return ""
+}
+
+type SSHKeyAttributes struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	UserIdentifier string                 `protobuf:"bytes,1,opt,name=user_identifier,json=userIdentifier,proto3" json:"user_identifier,omitempty"`
+	PrivateKey     string                 `protobuf:"bytes,2,opt,name=private_key,json=privateKey,proto3" json:"private_key,omitempty"`
+	PublicKey      string                 `protobuf:"bytes,3,opt,name=public_key,json=publicKey,proto3" json:"public_key,omitempty"`
+	Hostname       string                 `protobuf:"bytes,4,opt,name=hostname,proto3" json:"hostname,omitempty"`
+	Passphrase     string                 `protobuf:"bytes,5,opt,name=passphrase,proto3" json:"passphrase,omitempty"`
+	Fingerprint    string                 `protobuf:"bytes,6,opt,name=fingerprint,proto3" json:"fingerprint,omitempty"`
+	KeyType        string                 `protobuf:"bytes,7,opt,name=key_type,json=keyType,proto3" json:"key_type,omitempty"`
+	Bits           int32                  `protobuf:"varint,8,opt,name=bits,proto3" json:"bits,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *SSHKeyAttributes) Reset() {
+	*x = SSHKeyAttributes{}
+	mi := &file_proto_credentials_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SSHKeyAttributes) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SSHKeyAttributes) ProtoMessage() {}
+
+func (x *SSHKeyAttributes) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_credentials_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SSHKeyAttributes.ProtoReflect.Descriptor instead.
+func (*SSHKeyAttributes) Descriptor() ([]byte, []int) {
+	return file_proto_credentials_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *SSHKeyAttributes) GetUserIdentifier() string {
+	if x != nil {
+		return x.UserIdentifier
+	}
+	return ""
+}
+
+func (x *SSHKeyAttributes) GetPrivateKey() string {
+	if x != nil {
+		return x.PrivateKey
+	}
+	return ""
+}
+
+func (x *SSHKeyAttributes) GetPublicKey() string {
+	if x != nil {
+		return x.PublicKey
+	}
+	return ""
+}
+
+func (x *SSHKeyAttributes) GetHostname() string {
+	if x != nil {
+		return x.Hostname
+	}
+	return ""
+}
+
+func (x *SSHKeyAttributes) GetPassphrase() string {
+	if x != nil {
+		return x.Passphrase
+	}
+	return ""
+}
+
+func (x *SSHKeyAttributes) GetFingerprint() string {
+	if x != nil {
+		return x.Fingerprint
+	}
+	return ""
+}
+
+func (x *SSHKeyAttributes) GetKeyType() string {
+	if x != nil {
+		return x.KeyType
+	}
+	return ""
+}
+
+func (x *SSHKeyAttributes) GetBits() int32 {
+	if x != nil {
+		return x.Bits
+	}
+	return 0
+}
+
+// TOTPAttributes never carry the seed, codes are generated by the HTTP API
+type TOTPAttributes struct {
+	state                protoimpl.MessageState `protogen:"open.v1"`
+	Issuer               string                 `protobuf:"bytes,1,opt,name=issuer,proto3" json:"issuer,omitempty"`
+	AccountName          string                 `protobuf:"bytes,2,opt,name=account_name,json=accountName,proto3" json:"account_name,omitempty"`
+	Digits               int32                  `protobuf:"varint,3,opt,name=digits,proto3" json:"digits,omitempty"`
+	Period               int32                  `protobuf:"varint,4,opt,name=period,proto3" json:"period,omitempty"`
+	Algorithm            string                 `protobuf:"bytes,5,opt,name=algorithm,proto3" json:"algorithm,omitempty"`
+	PasswordCredentialId string                 `protobuf:"bytes,6,opt,name=password_credential_id,json=passwordCredentialId,proto3" json:"password_credential_id,omitempty"`
+	unknownFields        protoimpl.UnknownFields
+	sizeCache            protoimpl.SizeCache
+}
+
+func (x *TOTPAttributes) Reset() {
+	*x = TOTPAttributes{}
+	mi := &file_proto_credentials_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TOTPAttributes) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TOTPAttributes) ProtoMessage() {}
+
+func (x *TOTPAttributes) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_credentials_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TOTPAttributes.ProtoReflect.Descriptor instead.
+func (*TOTPAttributes) Descriptor() ([]byte, []int) {
+	return file_proto_credentials_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *TOTPAttributes) GetIssuer() string {
+	if x != nil {
+		return x.Issuer
+	}
+	return ""
+}
+
+func (x *TOTPAttributes) GetAccountName() string {
+	if x != nil {
+		return x.AccountName
+	}
+	return ""
+}
+
+func (x *TOTPAttributes) GetDigits() int32 {
+	if x != nil {
+		return x.Digits
+	}
+	return 0
+}
+
+func (x *TOTPAttributes) GetPeriod() int32 {
+	if x != nil {
+		return x.Period
+	}
+	return 0
+}
+
+func (x *TOTPAttributes) GetAlgorithm() string {
+	if x != nil {
+		return x.Algorithm
+	}
+	return ""
+}
+
+func (x *TOTPAttributes) GetPasswordCredentialId() string {
+	if x != nil {
+		return x.PasswordCredentialId
+	}
+	return ""
+}
+
+type BatchGetRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Ids   []string               `protobuf:"bytes,1,rep,name=ids,proto3" json:"ids,omitempty"`
+	// actor is recorded in the access log, like the X-Actor header of the HTTP API
+	Actor         *string `protobuf:"bytes,2,opt,name=actor,proto3,oneof" json:"actor,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchGetRequest) Reset() {
+	*x = BatchGetRequest{}
+	mi := &file_proto_credentials_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchGetRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchGetRequest) ProtoMessage() {}
+
+func (x *BatchGetRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_credentials_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchGetRequest.ProtoReflect.Descriptor instead.
+func (*BatchGetRequest) Descriptor() ([]byte, []int) {
+	return file_proto_credentials_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *BatchGetRequest) GetIds() []string {
+	if x != nil {
+		return x.Ids
+	}
+	return nil
+}
+
+func (x *BatchGetRequest) GetActor() string {
+	if x != nil && x.Actor != nil {
+		return *x.Actor
+	}
+	return ""
+}
+
+type BatchGetResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Credentials   []*Credential          `protobuf:"bytes,1,rep,name=credentials,proto3" json:"credentials,omitempty"`
+	MissingIds    []string               `protobuf:"bytes,2,rep,name=missing_ids,json=missingIds,proto3" json:"missing_ids,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchGetResponse) Reset() {
+	*x = BatchGetResponse{}
+	mi := &file_proto_credentials_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchGetResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchGetResponse) ProtoMessage() {}
+
+func (x *BatchGetResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_credentials_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchGetResponse.ProtoReflect.Descriptor instead.
+func (*BatchGetResponse) Descriptor() ([]byte, []int) {
+	return file_proto_credentials_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *BatchGetResponse) GetCredentials() []*Credential {
+	if x != nil {
+		return x.Credentials
+	}
+	return nil
+}
+
+func (x *BatchGetResponse) GetMissingIds() []string {
+	if x != nil {
+		return x.MissingIds
+	}
+	return nil
+}
+
+type ListRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// type restricts the listing to one credential type, every type is listed when it is empty
+	Type        string `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	TitlePrefix string `protobuf:"bytes,2,opt,name=title_prefix,json=titlePrefix,proto3" json:"title_prefix,omitempty"`
+	// attributes filter on non secret attributes, e.g. domain_name or hostname
+	Attributes    map[string]string      `protobuf:"bytes,3,rep,name=attributes,proto3" json:"attributes,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	CreatedAfter  *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=created_after,json=createdAfter,proto3" json:"created_after,omitempty"`
+	CreatedBefore *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=created_before,json=createdBefore,proto3" json:"created_before,omitempty"`
+	UpdatedAfter  *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=updated_after,json=updatedAfter,proto3" json:"updated_after,omitempty"`
+	UpdatedBefore *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=updated_before,json=updatedBefore,proto3" json:"updated_before,omitempty"`
+	// expiry is expired, expiring, valid or none
+	Expiry string `protobuf:"bytes,8,opt,name=expiry,proto3" json:"expiry,omitempty"`
+	// window of the expiring status in days, 30 when it is 0
+	ExpiringDays int32 `protobuf:"varint,9,opt,name=expiring_days,json=expiringDays,proto3" json:"expiring_days,omitempty"`
+	// keys of custom fields the credentials must have
+	CustomFields []string `protobuf:"bytes,10,rep,name=custom_fields,json=customFields,proto3" json:"custom_fields,omitempty"`
+	// sort is title, created_at (default), updated_at or last_read_at
+	Sort       string `protobuf:"bytes,11,opt,name=sort,proto3" json:"sort,omitempty"`
+	Descending bool   `protobuf:"varint,12,opt,name=descending,proto3" json:"descending,omitempty"`
+	// limit is the maximum number of credentials streamed, every matching one when it is 0
+	Limit         int32   `protobuf:"varint,13,opt,name=limit,proto3" json:"limit,omitempty"`
+	Actor         *string `protobuf:"bytes,14,opt,name=actor,proto3,oneof" json:"actor,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListRequest) Reset() {
+	*x = ListRequest{}
+	mi := &file_proto_credentials_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListRequest) ProtoMessage() {}
+
+func (x *ListRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_credentials_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListRequest.ProtoReflect.Descriptor instead.
+func (*ListRequest) Descriptor() ([]byte, []int) {
+	return file_proto_credentials_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ListRequest) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *ListRequest) GetTitlePrefix() string {
+	if x != nil {
+		return x.TitlePrefix
+	}
+	return ""
+}
+
+func (x *ListRequest) GetAttributes() map[string]string {
+	if x != nil {
+		return x.Attributes
+	}
+	return nil
+}
+
+func (x *ListRequest) GetCreatedAfter() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAfter
+	}
+	return nil
+}
+
+func (x *ListRequest) GetCreatedBefore() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedBefore
+	}
+	return nil
+}
+
+func (x *ListRequest) GetUpdatedAfter() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAfter
+	}
+	return nil
+}
+
+func (x *ListRequest) GetUpdatedBefore() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedBefore
+	}
+	return nil
+}
+
+func (x *ListRequest) GetExpiry() string {
+	if x != nil {
+		return x.Expiry
+	}
+	return ""
+}
+
+func (x *ListRequest) GetExpiringDays() int32 {
+	if x != nil {
+		return x.ExpiringDays
+	}
+	return 0
+}
+
+func (x *ListRequest) GetCustomFields() []string {
+	if x != nil {
+		return x.CustomFields
+	}
+	return nil
+}
+
+func (x *ListRequest) GetSort() string {
+	if x != nil {
+		return x.Sort
+	}
+	return ""
+}
+
+func (x *ListRequest) GetDescending() bool {
+	if x != nil {
+		return x.Descending
+	}
+	return false
+}
+
+func (x *ListRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *ListRequest) GetActor() string {
+	if x != nil && x.Actor != nil {
+		return *x.Actor
+	}
+	return ""
+}
+
+var File_proto_credentials_proto protoreflect.FileDescriptor
+
+const file_proto_credentials_proto_rawDesc = "" +
+	"\n" +
+	"\x17proto/credentials.proto\x12\vcredentials\x1a\x1cgoogle/protobuf/struct.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\xc1\x05\n" +
+	"\n" +
+	"Credential\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x14\n" +
+	"\x05title\x18\x02 \x01(\tR\x05title\x12\x12\n" +
+	"\x04note\x18\x03 \x01(\tR\x04note\x129\n" +
+	"\n" +
+	"created_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\x129\n" +
+	"\n" +
+	"expires_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\x12<\n" +
+	"\flast_read_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"lastReadAt\x12<\n" +
+	"\rcustom_fields\x18\b \x01(\v2\x17.google.protobuf.StructR\fcustomFields\x12\"\n" +
+	"\n" +
+	"updated_by\x18\t \x01(\tH\x01R\tupdatedBy\x88\x01\x01\x12\x18\n" +
+	"\aversion\x18\n" +
+	" \x01(\x05R\aversion\x12\x12\n" +
+	"\x04type\x18\v \x01(\tR\x04type\x12=\n" +
+	"\bpassword\x18\f \x01(\v2\x1f.credentials.PasswordAttributesH\x00R\bpassword\x121\n" +
+	"\x04card\x18\r \x01(\v2\x1b.credentials.CardAttributesH\x00R\x04card\x128\n" +
+	"\assh_key\x18\x0e \x01(\v2\x1d.credentials.SSHKeyAttributesH\x00R\x06sshKey\x121\n" +
+	"\x04totp\x18\x0f \x01(\v2\x1b.credentials.TOTPAttributesH\x00R\x04totpB\f\n" +
+	"\n" +
+	"attributesB\r\n" +
+	"\v_updated_by\"z\n" +
+	"\x12PasswordAttributes\x12'\n" +
+	"\x0fuser_identifier\x18\x01 \x01(\tR\x0euserIdentifier\x12\x1a\n" +
+	"\bpassword\x18\x02 \x01(\tR\bpassword\x12\x1f\n" +
+	"\vdomain_name\x18\x03 \x01(\tR\n" +
+	"domainName\"\xe7\x01\n" +
+	"\x0eCardAttributes\x12'\n" +
+	"\x0fuser_identifier\x18\x01 \x01(\tR\x0euserIdentifier\x12\x1d\n" +
+	"\n" +
+	"owner_name\x18\x02 \x01(\tR\townerName\x12\x10\n" +
+	"\x03cvc\x18\x03 \x01(\tR\x03cvc\x12'\n" +
+	"\x0fexpiration_date\x18\x04 \x01(\tR\x0eexpirationDate\x12\x1f\n" +
+	"\vcard_number\x18\x05 \x01(\tR\n" +
+	"cardNumber\x12\x14\n" +
+	"\x05brand\x18\x06 \x01(\tR\x05brand\x12\x1b\n" +
+	"\tlast_four\x18\a \x01(\tR\blastFour\"\x88\x02\n" +
+	"\x10SSHKeyAttributes\x12'\n" +
+	"\x0fuser_identifier\x18\x01 \x01(\tR\x0euserIdentifier\x12\x1f\n" +
+	"\vprivate_key\x18\x02 \x01(\tR\n" +
+	"privateKey\x12\x1d\n" +
+	"\n" +
+	"public_key\x18\x03 \x01(\tR\tpublicKey\x12\x1a\n" +
+	"\bhostname\x18\x04 \x01(\tR\bhostname\x12\x1e\n" +
+	"\n" +
+	"passphrase\x18\x05 \x01(\tR\n" +
+	"passphrase\x12 \n" +
+	"\vfingerprint\x18\x06 \x01(\tR\vfingerprint\x12\x19\n" +
+	"\bkey_type\x18\a \x01(\tR\akeyType\x12\x12\n" +
+	"\x04bits\x18\b \x01(\x05R\x04bits\"\xcf\x01\n" +
+	"\x0eTOTPAttributes\x12\x16\n" +
+	"\x06issuer\x18\x01 \x01(\tR\x06issuer\x12!\n" +
+	"\faccount_name\x18\x02 \x01(\tR\vaccountName\x12\x16\n" +
+	"\x06digits\x18\x03 \x01(\x05R\x06digits\x12\x16\n" +
+	"\x06period\x18\x04 \x01(\x05R\x06period\x12\x1c\n" +
+	"\talgorithm\x18\x05 \x01(\tR\talgorithm\x124\n" +
+	"\x16password_credential_id\x18\x06 \x01(\tR\x14passwordCredentialId\"H\n" +
+	"\x0fBatchGetRequest\x12\x10\n" +
+	"\x03ids\x18\x01 \x03(\tR\x03ids\x12\x19\n" +
+	"\x05actor\x18\x02 \x01(\tH\x00R\x05actor\x88\x01\x01B\b\n" +
+	"\x06_actor\"n\n" +
+	"\x10BatchGetResponse\x129\n" +
+	"\vcredentials\x18\x01 \x03(\v2\x17.credentials.CredentialR\vcredentials\x12\x1f\n" +
+	"\vmissing_ids\x18\x02 \x03(\tR\n" +
+	"missingIds\"\xa6\x05\n" +
+	"\vListRequest\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x12!\n" +
+	"\ftitle_prefix\x18\x02 \x01(\tR\vtitlePrefix\x12H\n" +
+	"\n" +
+	"attributes\x18\x03 \x03(\v2(.credentials.ListRequest.AttributesEntryR\n" +
+	"attributes\x12?\n" +
+	"\rcreated_after\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\fcreatedAfter\x12A\n" +
+	"\x0ecreated_before\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\rcreatedBefore\x12?\n" +
+	"\rupdated_after\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\fupdatedAfter\x12A\n" +
+	"\x0eupdated_before\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\rupdatedBefore\x12\x16\n" +
+	"\x06expiry\x18\b \x01(\tR\x06expiry\x12#\n" +
+	"\rexpiring_days\x18\t \x01(\x05R\fexpiringDays\x12#\n" +
+	"\rcustom_fields\x18\n" +
+	" \x03(\tR\fcustomFields\x12\x12\n" +
+	"\x04sort\x18\v \x01(\tR\x04sort\x12\x1e\n" +
+	"\n" +
+	"descending\x18\f \x01(\bR\n" +
+	"descending\x12\x14\n" +
+	"\x05limit\x18\r \x01(\x05R\x05limit\x12\x19\n" +
+	"\x05actor\x18\x0e \x01(\tH\x00R\x05actor\x88\x01\x01\x1a=\n" +
+	"\x0fAttributesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01B\b\n" +
+	"\x06_actor2\x99\x01\n" +
+	"\x11CredentialService\x12G\n" +
+	"\bBatchGet\x12\x1c.credentials.BatchGetRequest\x1a\x1d.credentials.BatchGetResponse\x12;\n" +
+	"\x04List\x12\x18.credentials.ListRequest\x1a\x17.credentials.Credential0\x01B\x11Z\x0fcredentials/apib\x06proto3"
+
+var (
+	file_proto_credentials_proto_rawDescOnce sync.Once
+	file_proto_credentials_proto_rawDescData []byte
+)
+
+func file_proto_credentials_proto_rawDescGZIP() []byte {
+	file_proto_credentials_proto_rawDescOnce.Do(func() {
+		file_proto_credentials_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_proto_credentials_proto_rawDesc), len(file_proto_credentials_proto_rawDesc)))
+	})
+	return file_proto_credentials_proto_rawDescData
+}
+
+var file_proto_credentials_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
+var file_proto_credentials_proto_goTypes = []any{
+	(*Credential)(nil),            // 0: credentials.Credential
+	(*PasswordAttributes)(nil),    // 1: credentials.PasswordAttributes
+	(*CardAttributes)(nil),        // 2: credentials.CardAttributes
+	(*SSHKeyAttributes)(nil),      // 3: credentials.SSHKeyAttributes
+	(*TOTPAttributes)(nil),        // 4: credentials.TOTPAttributes
+	(*BatchGetRequest)(nil),       // 5: credentials.BatchGetRequest
+	(*BatchGetResponse)(nil),      // 6: credentials.BatchGetResponse
+	(*ListRequest)(nil),           // 7: credentials.ListRequest
+	nil,                           // 8: credentials.ListRequest.AttributesEntry
+	(*timestamppb.Timestamp)(nil), // 9: google.protobuf.Timestamp
+	(*structpb.Struct)(nil),       // 10: google.protobuf.Struct
+}
+var file_proto_credentials_proto_depIdxs = []int32{
+	9,  // 0: credentials.Credential.created_at:type_name -> google.protobuf.Timestamp
+	9,  // 1: credentials.Credential.updated_at:type_name -> google.protobuf.Timestamp
+	9,  // 2: credentials.Credential.expires_at:type_name -> google.protobuf.Timestamp
+	9,  // 3: credentials.Credential.last_read_at:type_name -> google.protobuf.Timestamp
+	10, // 4: credentials.Credential.custom_fields:type_name -> google.protobuf.Struct
+	1,  // 5: credentials.Credential.password:type_name -> credentials.PasswordAttributes
+	2,  // 6: credentials.Credential.card:type_name -> credentials.CardAttributes
+	3,  // 7: credentials.Credential.ssh_key:type_name -> credentials.SSHKeyAttributes
+	4,  // 8: credentials.Credential.totp:type_name -> credentials.TOTPAttributes
+	0,  // 9: credentials.BatchGetResponse.credentials:type_name -> credentials.Credential
+	8,  // 10: credentials.ListRequest.attributes:type_name -> credentials.ListRequest.AttributesEntry
+	9,  // 11: credentials.ListRequest.created_after:type_name -> google.protobuf.Timestamp
+	9,  // 12: credentials.ListRequest.created_before:type_name -> google.protobuf.Timestamp
+	9,  // 13: credentials.ListRequest.updated_after:type_name -> google.protobuf.Timestamp
+	9,  // 14: credentials.ListRequest.updated_before:type_name -> google.protobuf.Timestamp
+	5,  // 15: credentials.CredentialService.BatchGet:input_type -> credentials.BatchGetRequest
+	7,  // 16: credentials.CredentialService.List:input_type -> credentials.ListRequest
+	6,  // 17: credentials.CredentialService.BatchGet:output_type -> credentials.BatchGetResponse
+	0,  // 18: credentials.CredentialService.List:output_type -> credentials.Credential
+	17, // [17:19] is the sub-list for method output_type
+	15, // [15:17] is the sub-list for method input_type
+	15, // [15:15] is the sub-list for extension type_name
+	15, // [15:15] is the sub-list for extension extendee
+	0,  // [0:15] is the sub-list for field type_name
+}
+
+func init() { file_proto_credentials_proto_init() }
+func file_proto_credentials_proto_init() {
+	if File_proto_credentials_proto != nil {
+		return
+	}
+	file_proto_credentials_proto_msgTypes[0].OneofWrappers = []any{
+		(*Credential_Password)(nil),
+		(*Credential_Card)(nil),
+		(*Credential_SshKey)(nil),
+		(*Credential_Totp)(nil),
+	}
+	file_proto_credentials_proto_msgTypes[5].OneofWrappers = []any{}
+	file_proto_credentials_proto_msgTypes[7].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_credentials_proto_rawDesc), len(file_proto_credentials_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   9,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_proto_credentials_proto_goTypes,
+		DependencyIndexes: file_proto_credentials_proto_depIdxs,
+		MessageInfos:      file_proto_credentials_proto_msgTypes,
+	}.Build()
+	File_proto_credentials_proto = out.File
+	file_proto_credentials_proto_goTypes = nil
+	file_proto_credentials_proto_depIdxs = nil
+}
@@ -0,0 +1,167 @@
+package importer
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DO-2K23-26/polypass-microservices/credentials/card"
+	"github.com/DO-2K23-26/polypass-microservices/credentials/types"
+)
+
+var ERR_ENCRYPTED_EXPORT error = errors.New("encrypted bitwarden export: export the vault as unencrypted JSON")
+
+type bitwardenExport struct {
+	Encrypted bool `json:"encrypted"`
+	Folders   []struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"folders"`
+	Items []bitwardenItem `json:"items"`
+}
+
+type bitwardenItem struct {
+	Type     int     `json:"type"`
+	Name     string  `json:"name"`
+	Notes    *string `json:"notes"`
+	FolderID *string `json:"folderId"`
+	Fields   []struct {
+		Name  string  `json:"name"`
+		Value *string `json:"value"`
+	} `json:"fields"`
+	Login *struct {
+		Username *string `json:"username"`
+		Password *string `json:"password"`
+		TOTP     *string `json:"totp"`
+		URIs     []struct {
+			URI *string `json:"uri"`
+		} `json:"uris"`
+	} `json:"login"`
+	Card *struct {
+		CardholderName *string `json:"cardholderName"`
+		Brand          *string `json:"brand"`
+		Number         *string `json:"number"`
+		ExpMonth       *string `json:"expMonth"`
+		ExpYear        *string `json:"expYear"`
+		Code           *string `json:"code"`
+	} `json:"card"`
+	SSHKey *struct {
+		PrivateKey     *string `json:"privateKey"`
+		PublicKey      *string `json:"publicKey"`
+		KeyFingerprint *string `json:"keyFingerprint"`
+	} `json:"sshKey"`
+}
+
+// item types of a Bitwarden export
+const (
+	bitwardenLogin      = 1
+	bitwardenSecureNote = 2
+	bitwardenCard       = 3
+	bitwardenIdentity   = 4
+	bitwardenSSHKey     = 5
+)
+
+// parseBitwarden reads an unencrypted JSON export of Bitwarden, the folder of the items is kept in a
+// folder custom field
+func parseBitwarden(r io.Reader) ([]Entry, error) {
+	var export bitwardenExport
+	if err := json.NewDecoder(r).Decode(&export); err != nil {
+		return nil, err
+	}
+	if export.Encrypted {
+		return nil, ERR_ENCRYPTED_EXPORT
+	}
+	folders := make(map[string]string, len(export.Folders))
+	for _, folder := range export.Folders {
+		folders[folder.ID] = folder.Name
+	}
+
+	entries := make([]Entry, 0, len(export.Items))
+	for _, item := range export.Items {
+		entries = append(entries, bitwardenCredential(item, folders))
+	}
+	return entries, nil
+}
+
+func bitwardenCredential(item bitwardenItem, folders map[string]string) Entry {
+	fields := make(map[string]string, len(item.Fields)+1)
+	if item.FolderID != nil {
+		fields["folder"] = folders[*item.FolderID]
+	}
+	for _, field := range item.Fields {
+		fields[fieldName(field.Name)] = value(field.Value)
+	}
+	note := value(item.Notes)
+
+	switch {
+	case item.Type == bitwardenLogin && item.Login != nil:
+		var uris []string
+		for _, uri := range item.Login.URIs {
+			if uri.URI != nil && *uri.URI != "" {
+				uris = append(uris, *uri.URI)
+			}
+		}
+		attributes := map[string]any{
+			"user_identifier": value(item.Login.Username),
+			"password":        value(item.Login.Password),
+			"domain_name":     "",
+		}
+		if len(uris) > 0 {
+			attributes["domain_name"] = domainName(uris[0])
+			fields["urls"] = strings.Join(uris, "\n")
+		}
+		fields["totp"] = value(item.Login.TOTP)
+		return newEntry(types.CredentialTypePassword, item.Name, note, attributes, fields)
+
+	case item.Type == bitwardenCard && item.Card != nil:
+		attributes := map[string]any{
+			"owner_name":      value(item.Card.CardholderName),
+			"card_number":     digits(value(item.Card.Number)),
+			"cvc":             digits(value(item.Card.Code)),
+			"expiration_date": cardExpiration(value(item.Card.ExpMonth), value(item.Card.ExpYear)),
+		}
+		fields["brand"] = value(item.Card.Brand)
+		return newEntry(types.CredentialTypeCard, item.Name, note, attributes, fields)
+
+	case item.Type == bitwardenSSHKey && item.SSHKey != nil:
+		attributes := map[string]any{
+			"private_key": value(item.SSHKey.PrivateKey),
+			"public_key":  value(item.SSHKey.PublicKey),
+		}
+		fields["fingerprint"] = value(item.SSHKey.KeyFingerprint)
+		return newEntry(types.CredentialTypeSSHKey, item.Name, note, attributes, fields)
+
+	case item.Type == bitwardenSecureNote:
+		return failedEntry(item.Name, "secure notes are not supported")
+	case item.Type == bitwardenIdentity:
+		return failedEntry(item.Name, "identities are not supported")
+	default:
+		return failedEntry(item.Name, "unsupported item type %d", item.Type)
+	}
+}
+
+func value(text *string) string {
+	if text == nil {
+		return ""
+	}
+	return *text
+}
+
+// cardExpiration turns a month and a year into MM/YY
+func cardExpiration(month string, year string) string {
+	m, err := strconv.Atoi(month)
+	if err != nil || m < 1 || m > 12 {
+		return ""
+	}
+	y, err := strconv.Atoi(year)
+	if err != nil {
+		return ""
+	}
+	if y < 100 {
+		y += 2000
+	}
+	return card.Expiry{Month: time.Month(m), Year: y}.String()
+}
@@ -0,0 +1,88 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"io"
+
+	"github.com/DO-2K23-26/polypass-microservices/credentials/types"
+)
+
+var ERR_MISSING_PASSWORD_COLUMN error = errors.New("csv export has no password column")
+
+// csvColumns maps the headers of 1Password and Chrome exports to the fields of a password credential,
+// the other columns are kept in custom fields
+var csvColumns = map[string]string{
+	"title":    "title",
+	"name":     "title",
+	"url":      "url",
+	"website":  "url",
+	"username": "username",
+	"login":    "username",
+	"email":    "username",
+	"password": "password",
+	"notes":    "note",
+	"note":     "note",
+}
+
+// utf8BOM starts the CSV files written by Excel and by several password managers
+var utf8BOM = []byte("\ufeff")
+
+// parseCSV reads a CSV export of 1Password or Chrome, the first line holds the headers. Every line is
+// a password credential.
+func parseCSV(r io.Reader) ([]Entry, error) {
+	// the BOM would stick to the first header, and break its quoting
+	buffered := bufio.NewReader(r)
+	if start, err := buffered.Peek(len(utf8BOM)); err == nil && bytes.Equal(start, utf8BOM) {
+		buffered.Discard(len(utf8BOM))
+	}
+	reader := csv.NewReader(buffered)
+	reader.FieldsPerRecord = -1
+	headers, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	for i := range headers {
+		headers[i] = fieldName(headers[i])
+	}
+
+	password := false
+	for _, header := range headers {
+		password = password || csvColumns[header] == "password"
+	}
+	if !password {
+		return nil, ERR_MISSING_PASSWORD_COLUMN
+	}
+
+	var entries []Entry
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		known := make(map[string]string, len(csvColumns))
+		fields := make(map[string]string, len(record))
+		for i, value := range record {
+			if i >= len(headers) {
+				break
+			}
+			if column, ok := csvColumns[headers[i]]; ok {
+				known[column] = value
+			} else {
+				fields[headers[i]] = value
+			}
+		}
+		attributes := map[string]any{
+			"user_identifier": known["username"],
+			"password":        known["password"],
+			"domain_name":     domainName(known["url"]),
+		}
+		entries = append(entries, newEntry(types.CredentialTypePassword, known["title"], known["note"], attributes, fields))
+	}
+}
@@ -0,0 +1,132 @@
+// Package importer reads the exports of other password managers into credentials of the registered
+// types. It only parses: validation, duplicate detection and storage are left to the service.
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/DO-2K23-26/polypass-microservices/credentials/registry"
+	"github.com/DO-2K23-26/polypass-microservices/credentials/types"
+)
+
+var ERR_UNKNOWN_FORMAT error = errors.New("unknown import format: expected keepass, bitwarden or csv")
+
+type Format string
+
+const (
+	// FormatKeePass is the XML export of KeePass 2.x
+	FormatKeePass Format = "keepass"
+	// FormatBitwarden is the unencrypted JSON export of Bitwarden
+	FormatBitwarden Format = "bitwarden"
+	// FormatCSV is the CSV export of 1Password or Chrome
+	FormatCSV Format = "csv"
+)
+
+// Entry is one credential read from an export. Entries the service cannot store, e.g. Bitwarden
+// secure notes, come with an error and are reported as failed.
+type Entry struct {
+	Credential types.GenericCredential
+	Err        error
+}
+
+// Parse reads a whole export, an error is returned when the file itself cannot be read
+func Parse(format Format, r io.Reader) ([]Entry, error) {
+	switch format {
+	case FormatKeePass:
+		return parseKeePass(r)
+	case FormatBitwarden:
+		return parseBitwarden(r)
+	case FormatCSV:
+		return parseCSV(r)
+	default:
+		return nil, ERR_UNKNOWN_FORMAT
+	}
+}
+
+// newEntry builds a credential of the given type. The fields named after an attribute of the type fill
+// it, e.g. a KeePass string named hostname, the others are kept in the custom fields.
+func newEntry(credentialType types.CredentialType, title string, note string, attributes map[string]any, fields map[string]string) Entry {
+	entry := Entry{Credential: types.GenericCredential{
+		Credential: types.Credential{Title: title, Note: note},
+		Type:       credentialType,
+		Attributes: attributes,
+	}}
+	definition, err := registry.Get(credentialType)
+	if err != nil {
+		entry.Err = err
+		return entry
+	}
+
+	customFields := make(map[string]any)
+	for name, value := range fields {
+		if value == "" {
+			continue
+		}
+		if _, ok := definition.Attribute(name); ok {
+			if current, set := attributes[name]; !set || current == "" {
+				attributes[name] = value
+				continue
+			}
+		}
+		customFields[name] = value
+	}
+	if len(customFields) > 0 {
+		entry.Credential.CustomFields = &customFields
+	}
+	return entry
+}
+
+// failedEntry is an entry of the export that has no matching credential type
+func failedEntry(title string, format string, args ...any) Entry {
+	return Entry{
+		Credential: types.GenericCredential{Credential: types.Credential{Title: title}},
+		Err:        fmt.Errorf(format, args...),
+	}
+}
+
+// typeOf guesses the credential type of an entry from the names of its fields
+func typeOf(fields map[string]string) types.CredentialType {
+	switch {
+	case fields["card_number"] != "":
+		return types.CredentialTypeCard
+	case fields["private_key"] != "":
+		return types.CredentialTypeSSHKey
+	default:
+		return types.CredentialTypePassword
+	}
+}
+
+// domainName keeps the host of an URL, values that are not URLs are kept as they are
+func domainName(value string) string {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return ""
+	}
+	candidate := value
+	if !strings.Contains(candidate, "://") {
+		candidate = "https://" + candidate
+	}
+	if parsed, err := url.Parse(candidate); err == nil && parsed.Hostname() != "" {
+		return parsed.Hostname()
+	}
+	return value
+}
+
+// fieldName turns a label such as "Card Number" into the name of an attribute, card_number
+func fieldName(label string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(label)), " ", "_")
+}
+
+// digits keeps the digits of a card number or code written with spaces or dashes
+func digits(value string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, value)
+}
@@ -0,0 +1,134 @@
+package importer
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/DO-2K23-26/polypass-microservices/credentials/types"
+)
+
+type keePassFile struct {
+	XMLName xml.Name     `xml:"KeePassFile"`
+	Root    keePassGroup `xml:"Root>Group"`
+}
+
+type keePassGroup struct {
+	Name    string         `xml:"Name"`
+	Entries []keePassEntry `xml:"Entry"`
+	Groups  []keePassGroup `xml:"Group"`
+}
+
+type keePassEntry struct {
+	Strings []keePassString `xml:"String"`
+	Times   struct {
+		Expires    string `xml:"Expires"`
+		ExpiryTime string `xml:"ExpiryTime"`
+	} `xml:"Times"`
+}
+
+type keePassString struct {
+	Key   string `xml:"Key"`
+	Value struct {
+		Text string `xml:",chardata"`
+		// values of a KDBX database are encrypted with the inner stream, an XML export has them in clear
+		Protected string `xml:"Protected,attr"`
+	} `xml:"Value"`
+}
+
+// the seconds of KDBX 4 times are counted from 0001-01-01, this is its distance to the Unix epoch
+const keePassEpoch = 62135596800
+
+// parseKeePass reads an XML export of KeePass 2.x, the group path of the entries is kept in a group
+// custom field. The recycle bin is left out.
+func parseKeePass(r io.Reader) ([]Entry, error) {
+	var file keePassFile
+	if err := xml.NewDecoder(r).Decode(&file); err != nil {
+		return nil, err
+	}
+	var entries []Entry
+	walkKeePassGroup(file.Root, "", &entries)
+	return entries, nil
+}
+
+func walkKeePassGroup(group keePassGroup, path string, entries *[]Entry) {
+	if path != "" {
+		path += "/"
+	}
+	path += group.Name
+	for _, entry := range group.Entries {
+		*entries = append(*entries, keePassCredential(entry, path))
+	}
+	for _, child := range group.Groups {
+		if child.Name == "Recycle Bin" {
+			continue
+		}
+		walkKeePassGroup(child, path, entries)
+	}
+}
+
+func keePassCredential(entry keePassEntry, group string) Entry {
+	var title, note, username, password, address string
+	fields := map[string]string{"group": group}
+	for _, field := range entry.Strings {
+		if strings.EqualFold(field.Value.Protected, "true") {
+			return failedEntry(title, "protected value %s: export the database as KeePass XML", field.Key)
+		}
+		value := field.Value.Text
+		switch field.Key {
+		case "Title":
+			title = value
+		case "Notes":
+			note = value
+		case "UserName":
+			username = value
+		case "Password":
+			password = value
+		case "URL":
+			address = value
+		default:
+			fields[fieldName(field.Key)] = value
+		}
+	}
+
+	credentialType := typeOf(fields)
+	attributes := map[string]any{"user_identifier": username}
+	switch credentialType {
+	case types.CredentialTypePassword:
+		attributes["password"] = password
+		attributes["domain_name"] = domainName(address)
+	case types.CredentialTypeSSHKey:
+		attributes["passphrase"] = password
+		fields["url"] = address
+	default:
+		fields["password"] = password
+		fields["url"] = address
+	}
+	imported := newEntry(credentialType, title, note, attributes, fields)
+
+	if strings.EqualFold(entry.Times.Expires, "true") && imported.Err == nil {
+		expiresAt, err := keePassTime(entry.Times.ExpiryTime)
+		if err != nil {
+			imported.Err = err
+		}
+		imported.Credential.ExpiresAt = expiresAt
+	}
+	return imported
+}
+
+// keePassTime reads an ISO 8601 time or the base64 encoded seconds used by KDBX 4
+func keePassTime(value string) (*time.Time, error) {
+	if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+		return &parsed, nil
+	}
+	data, err := base64.StdEncoding.DecodeString(value)
+	if err != nil || len(data) != 8 {
+		return nil, errors.New("invalid expiry time " + value)
+	}
+	parsed := time.Unix(int64(binary.LittleEndian.Uint64(data))-keePassEpoch, 0).UTC()
+	return &parsed, nil
+}
@@ -0,0 +1,217 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DO-2K23-26/polypass-microservices/credentials/types"
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+	"github.com/optique-dev/optique"
+)
+
+var ERR_INVALID_TOKEN error = errors.New("invalid token")
+
+const (
+	defaultRefreshInterval = time.Hour
+	defaultServiceScope    = "credentials:all"
+	// a token signed with an unknown key does not fetch the JWKS more often
+	minRefreshInterval = time.Minute
+)
+
+// algorithms tokens may be signed with, the type of the key must match the algorithm
+var algorithms = []jose.SignatureAlgorithm{
+	jose.RS256, jose.RS384, jose.RS512,
+	jose.PS256, jose.PS384, jose.PS512,
+	jose.ES256, jose.ES384, jose.ES512,
+	jose.EdDSA,
+	jose.HS256, jose.HS384, jose.HS512,
+}
+
+type Authenticator interface {
+	// Authenticate verifies a bearer token and returns the principal it was issued to
+	Authenticate(token string) (types.Principal, error)
+}
+
+type authenticator struct {
+	config Config
+	client *http.Client
+
+	mu        sync.RWMutex
+	keys      jose.JSONWebKeySet
+	fetchedAt time.Time
+}
+
+// NewAuthenticator loads the key set tokens are verified with, from jwks_url, jwks_file or keys in
+// this order of precedence
+func NewAuthenticator(config Config) (*authenticator, error) {
+	if config.RefreshInterval <= 0 {
+		config.RefreshInterval = defaultRefreshInterval
+	}
+	if config.ServiceScope == "" {
+		config.ServiceScope = defaultServiceScope
+	}
+	a := &authenticator{
+		config: config,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+
+	switch {
+	case config.JWKSURL != "":
+		if err := a.fetch(); err != nil {
+			return nil, err
+		}
+	case config.JWKSFile != "":
+		data, err := os.ReadFile(config.JWKSFile)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, &a.keys); err != nil {
+			return nil, fmt.Errorf("invalid JWKS file %s: %w", config.JWKSFile, err)
+		}
+	case len(config.Keys) > 0:
+		data, err := json.Marshal(map[string]any{"keys": config.Keys})
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, &a.keys); err != nil {
+			return nil, fmt.Errorf("invalid auth keys: %w", err)
+		}
+	default:
+		return nil, errors.New("auth requires jwks_url, jwks_file or keys")
+	}
+	if len(a.keys.Keys) == 0 {
+		return nil, errors.New("the JWKS of auth has no key")
+	}
+	return a, nil
+}
+
+// claims are the claims read besides the registered ones. Scopes are given in scope, space separated
+// as in OAuth 2.0, or in scp, space separated or as an array depending on the identity provider.
+type claims struct {
+	Scope    string           `json:"scope"`
+	Scp      any              `json:"scp"`
+	AuthTime *jwt.NumericDate `json:"auth_time"`
+}
+
+func (a *authenticator) Authenticate(token string) (types.Principal, error) {
+	parsed, err := jwt.ParseSigned(token, algorithms)
+	if err != nil {
+		return types.Principal{}, fmt.Errorf("%w: %w", ERR_INVALID_TOKEN, err)
+	}
+
+	var registered jwt.Claims
+	var extra claims
+	verified := false
+	for _, key := range a.candidates(parsed.Headers[0].KeyID) {
+		if err := parsed.Claims(verificationKey(key), &registered, &extra); err == nil {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return types.Principal{}, fmt.Errorf("%w: the signature does not match any key", ERR_INVALID_TOKEN)
+	}
+
+	expected := jwt.Expected{Issuer: a.config.Issuer}
+	if a.config.Audience != "" {
+		expected.AnyAudience = jwt.Audience{a.config.Audience}
+	}
+	if err := registered.Validate(expected); err != nil {
+		return types.Principal{}, fmt.Errorf("%w: %w", ERR_INVALID_TOKEN, err)
+	}
+	if registered.Expiry == nil {
+		return types.Principal{}, fmt.Errorf("%w: the token has no expiry", ERR_INVALID_TOKEN)
+	}
+	if registered.Subject == "" {
+		return types.Principal{}, fmt.Errorf("%w: the token has no subject", ERR_INVALID_TOKEN)
+	}
+
+	principal := types.Principal{
+		Subject: registered.Subject,
+		Service: slices.Contains(extra.scopes(), a.config.ServiceScope),
+	}
+	if extra.AuthTime != nil {
+		authTime := extra.AuthTime.Time()
+		principal.AuthTime = &authTime
+	}
+	return principal, nil
+}
+
+func (c claims) scopes() []string {
+	scopes := strings.Fields(c.Scope)
+	switch scp := c.Scp.(type) {
+	case string:
+		scopes = append(scopes, strings.Fields(scp)...)
+	case []any:
+		for _, scope := range scp {
+			if value, ok := scope.(string); ok {
+				scopes = append(scopes, value)
+			}
+		}
+	}
+	return scopes
+}
+
+// candidates are the keys a token signed with kid may be verified with, every key when the token has
+// no kid. The JWKS of jwks_url is fetched again when it is stale or does not have the key.
+func (a *authenticator) candidates(kid string) []jose.JSONWebKey {
+	keys, fetchedAt := a.lookup(kid)
+	if a.config.JWKSURL == "" {
+		return keys
+	}
+	stale := time.Since(fetchedAt) > a.config.RefreshInterval
+	if stale || (len(keys) == 0 && time.Since(fetchedAt) > minRefreshInterval) {
+		if err := a.fetch(); err != nil {
+			optique.Error(fmt.Sprintf("failed to fetch the JWKS: %s", err))
+		}
+		keys, _ = a.lookup(kid)
+	}
+	return keys
+}
+
+func (a *authenticator) lookup(kid string) ([]jose.JSONWebKey, time.Time) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if kid == "" {
+		return a.keys.Keys, a.fetchedAt
+	}
+	return a.keys.Key(kid), a.fetchedAt
+}
+
+func (a *authenticator) fetch() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	// failures count as a fetch too, so that an unreachable provider is not called for every token
+	a.fetchedAt = time.Now()
+
+	resp, err := a.client.Get(a.config.JWKSURL)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("JWKS endpoint returned %d", resp.StatusCode)
+	}
+	var keys jose.JSONWebKeySet
+	if err := json.NewDecoder(resp.Body).Decode(&keys); err != nil {
+		return fmt.Errorf("invalid JWKS: %w", err)
+	}
+	a.keys = keys
+	return nil
+}
+
+// verificationKey is the public part of asymmetric keys, a JWKS given for tests may hold private ones
+func verificationKey(key jose.JSONWebKey) jose.JSONWebKey {
+	if public := key.Public(); public.Valid() {
+		return public
+	}
+	return key
+}
@@ -0,0 +1,207 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+)
+
+const (
+	testIssuer   = "https://auth.example.com"
+	testAudience = "credentials"
+	testKeyID    = "test-key"
+)
+
+// testKeys returns a private key and the static key set of its public part, as given in the config
+func testKeys(t *testing.T) (ed25519.PrivateKey, []map[string]any) {
+	t.Helper()
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(jose.JSONWebKey{Key: public, KeyID: testKeyID, Algorithm: string(jose.EdDSA), Use: "sig"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var key map[string]any
+	if err := json.Unmarshal(data, &key); err != nil {
+		t.Fatal(err)
+	}
+	return private, []map[string]any{key}
+}
+
+func sign(t *testing.T, key ed25519.PrivateKey, kid string, claims map[string]any) string {
+	t.Helper()
+	options := (&jose.SignerOptions{}).WithType("JWT")
+	if kid != "" {
+		options = options.WithHeader(jose.HeaderKey("kid"), kid)
+	}
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.EdDSA, Key: key}, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := jwt.Signed(signer).Claims(claims).Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestAuthenticate(t *testing.T) {
+	key, keys := testKeys(t)
+	_, otherKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	authenticator, err := NewAuthenticator(Config{Enabled: true, Keys: keys, Issuer: testIssuer, Audience: testAudience})
+	if err != nil {
+		t.Fatalf("new authenticator: %v", err)
+	}
+
+	now := time.Now()
+	authTime := now.Add(-2 * time.Minute).Truncate(time.Second)
+	valid := func(changes map[string]any) map[string]any {
+		claims := map[string]any{
+			"iss": testIssuer,
+			"aud": testAudience,
+			"sub": "alice",
+			"iat": now.Unix(),
+			"exp": now.Add(time.Hour).Unix(),
+		}
+		for name, value := range changes {
+			if value == nil {
+				delete(claims, name)
+			} else {
+				claims[name] = value
+			}
+		}
+		return claims
+	}
+
+	tests := []struct {
+		name     string
+		token    string
+		subject  string
+		service  bool
+		authTime *time.Time
+		valid    bool
+	}{
+		{name: "valid", token: sign(t, key, testKeyID, valid(nil)), subject: "alice", valid: true},
+		{name: "without kid", token: sign(t, key, "", valid(nil)), subject: "alice", valid: true},
+		{name: "audience list", token: sign(t, key, testKeyID, valid(map[string]any{"aud": []string{"other", testAudience}})), subject: "alice", valid: true},
+		{name: "auth time", token: sign(t, key, testKeyID, valid(map[string]any{"auth_time": authTime.Unix()})), subject: "alice", authTime: &authTime, valid: true},
+		{name: "service scope", token: sign(t, key, testKeyID, valid(map[string]any{"sub": "backend", "scope": "openid credentials:all"})), subject: "backend", service: true, valid: true},
+		{name: "service scp string", token: sign(t, key, testKeyID, valid(map[string]any{"sub": "backend", "scp": "credentials:all"})), subject: "backend", service: true, valid: true},
+		{name: "service scp array", token: sign(t, key, testKeyID, valid(map[string]any{"sub": "backend", "scp": []string{"openid", "credentials:all"}})), subject: "backend", service: true, valid: true},
+		{name: "other scope", token: sign(t, key, testKeyID, valid(map[string]any{"scope": "credentials:read"})), subject: "alice", valid: true},
+		{name: "expired", token: sign(t, key, testKeyID, valid(map[string]any{"exp": now.Add(-time.Hour).Unix()}))},
+		{name: "not valid yet", token: sign(t, key, testKeyID, valid(map[string]any{"nbf": now.Add(time.Hour).Unix()}))},
+		{name: "missing exp", token: sign(t, key, testKeyID, valid(map[string]any{"exp": nil}))},
+		{name: "missing sub", token: sign(t, key, testKeyID, valid(map[string]any{"sub": nil}))},
+		{name: "wrong issuer", token: sign(t, key, testKeyID, valid(map[string]any{"iss": "https://evil.example.com"}))},
+		{name: "missing issuer", token: sign(t, key, testKeyID, valid(map[string]any{"iss": nil}))},
+		{name: "wrong audience", token: sign(t, key, testKeyID, valid(map[string]any{"aud": "billing"}))},
+		{name: "unknown kid", token: sign(t, key, "other-key", valid(nil))},
+		{name: "bad signature", token: sign(t, otherKey, testKeyID, valid(nil))},
+		{name: "not a token", token: "not.a.token"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal, err := authenticator.Authenticate(tt.token)
+			if !tt.valid {
+				if !errors.Is(err, ERR_INVALID_TOKEN) {
+					t.Fatalf("error = %v, want %v", err, ERR_INVALID_TOKEN)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("authenticate: %v", err)
+			}
+			if principal.Subject != tt.subject || principal.Service != tt.service {
+				t.Fatalf("principal = %+v, want subject %s and service %v", principal, tt.subject, tt.service)
+			}
+			if (principal.AuthTime == nil) != (tt.authTime == nil) || (tt.authTime != nil && !principal.AuthTime.Equal(*tt.authTime)) {
+				t.Fatalf("auth time = %v, want %v", principal.AuthTime, tt.authTime)
+			}
+
+			owner := principal.Owner()
+			if tt.service && owner != nil {
+				t.Fatalf("owner of a service identity = %s, want nil", *owner)
+			}
+			if !tt.service && (owner == nil || *owner != tt.subject) {
+				t.Fatalf("owner = %v, want %s", owner, tt.subject)
+			}
+		})
+	}
+}
+
+func TestAuthenticateServiceScope(t *testing.T) {
+	key, keys := testKeys(t)
+	authenticator, err := NewAuthenticator(Config{Enabled: true, Keys: keys, ServiceScope: "vault:admin"})
+	if err != nil {
+		t.Fatalf("new authenticator: %v", err)
+	}
+	expiry := time.Now().Add(time.Hour).Unix()
+
+	tests := []struct {
+		scope   string
+		service bool
+	}{
+		{"vault:admin", true},
+		{"credentials:all", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.scope, func(t *testing.T) {
+			principal, err := authenticator.Authenticate(sign(t, key, testKeyID, map[string]any{"sub": "backend", "exp": expiry, "scope": tt.scope}))
+			if err != nil {
+				t.Fatalf("authenticate: %v", err)
+			}
+			if principal.Service != tt.service {
+				t.Fatalf("service = %v, want %v", principal.Service, tt.service)
+			}
+		})
+	}
+}
+
+func TestNewAuthenticatorErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+	}{
+		{"no key source", Config{Enabled: true}},
+		{"missing file", Config{Enabled: true, JWKSFile: "testdata/missing.json"}},
+		{"invalid key", Config{Enabled: true, Keys: []map[string]any{{"kty": "OKP", "crv": "Ed25519"}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewAuthenticator(tt.config); err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
+
+func TestConfigCheck(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		err    error
+	}{
+		{"enabled", Config{Enabled: true}, nil},
+		{"disabled", Config{}, ERR_AUTH_DISABLED},
+		{"disabled in dev mode", Config{InsecureDevMode: true}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.config.Check(); !errors.Is(err, tt.err) {
+				t.Fatalf("check error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package auth
+
+import (
+	"errors"
+	"time"
+)
+
+type Config struct {
+	//require a bearer token on the credential routes, true by default
+	Enabled bool `mapstructure:"enabled"`
+	//allow enabled to be false, every caller is then trusted as a service and named by the X-Actor header; local development only
+	InsecureDevMode bool `mapstructure:"insecure_dev_mode"`
+	//path to the JWKS file holding the keys tokens are signed with
+	JWKSFile string `mapstructure:"jwks_file"`
+	//URL of the JWKS of the identity provider, also fetched when a token is signed with an unknown key
+	JWKSURL string `mapstructure:"jwks_url"`
+	//how often the JWKS of jwks_url is fetched again, 1h by default
+	RefreshInterval time.Duration `mapstructure:"refresh_interval"`
+	//keys of a static JWKS given in the config (local runs and tests only)
+	Keys []map[string]any `mapstructure:"keys"`
+	//expected iss claim, not checked when empty
+	Issuer string `mapstructure:"issuer"`
+	//one of the expected aud claims, not checked when empty
+	Audience string `mapstructure:"audience"`
+	//scope held by service identities, they access the credentials of every owner
+	ServiceScope string `mapstructure:"service_scope"`
+}
+
+var ERR_AUTH_DISABLED error = errors.New("auth is disabled: set auth.insecure_dev_mode to run without authentication, in local development only")
+
+// Check refuses a config running without authentication unless the development mode is explicitly set
+func (c Config) Check() error {
+	if !c.Enabled && !c.InsecureDevMode {
+		return ERR_AUTH_DISABLED
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package breach
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var ERR_INVALID_PREFIX error = errors.New("invalid hash prefix: expected 5 hex characters")
+
+var prefixFormat = regexp.MustCompile(`^[0-9A-F]{5}$`)
+
+// Dataset answers k-anonymity range queries: only the first 5 characters of a SHA-1 hash are given,
+// the caller looks for the rest of the hash among the suffixes returned.
+type Dataset interface {
+	// Range returns the number of occurrences of every hash suffix starting with the prefix
+	Range(prefix string) (map[string]int, error)
+}
+
+// NewDataset returns nil when no range directory is configured
+func NewDataset(config Config) (Dataset, error) {
+	if config.RangeDir == "" {
+		return nil, nil
+	}
+	info, err := os.Stat(config.RangeDir)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("breach range dir %s is not a directory", config.RangeDir)
+	}
+	return fileDataset{dir: config.RangeDir}, nil
+}
+
+// fileDataset reads the files written by the HIBP downloader: PREFIX.txt (or PREFIX) holding
+// SUFFIX:COUNT lines
+type fileDataset struct {
+	dir string
+}
+
+func (f fileDataset) Range(prefix string) (map[string]int, error) {
+	prefix = strings.ToUpper(prefix)
+	if !prefixFormat.MatchString(prefix) {
+		return nil, ERR_INVALID_PREFIX
+	}
+
+	file, err := os.Open(filepath.Join(f.dir, prefix+".txt"))
+	if errors.Is(err, os.ErrNotExist) {
+		file, err = os.Open(filepath.Join(f.dir, prefix))
+	}
+	if errors.Is(err, os.ErrNotExist) {
+		return map[string]int{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	suffixes := make(map[string]int)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		suffix, count, ok := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if !ok {
+			continue
+		}
+		occurrences, err := strconv.Atoi(count)
+		// padding lines have a count of 0
+		if err != nil || occurrences == 0 {
+			continue
+		}
+		suffixes[strings.ToUpper(suffix)] = occurrences
+	}
+	return suffixes, scanner.Err()
+}
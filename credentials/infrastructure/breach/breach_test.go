@@ -0,0 +1,79 @@
+package breach
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRange(t *testing.T) {
+	dataset, err := NewDataset(Config{RangeDir: "testdata"})
+	if err != nil {
+		t.Fatalf("new dataset: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		prefix   string
+		suffixes map[string]int
+		err      error
+	}{
+		{
+			// padding lines and lines that cannot be read are left out
+			name:   "PREFIX.txt",
+			prefix: "5BAA6",
+			suffixes: map[string]int{
+				"0018A45C4D1DEF81644B54AB7F969B88D65": 1,
+				"1E4C9B93F3F0682250B6CF8331B7EE68FD8": 9545824,
+			},
+		},
+		{name: "lower case prefix", prefix: "5baa6", suffixes: map[string]int{
+			"0018A45C4D1DEF81644B54AB7F969B88D65": 1,
+			"1E4C9B93F3F0682250B6CF8331B7EE68FD8": 9545824,
+		}},
+		{
+			// lower case suffixes and CRLF line endings
+			name:   "bare PREFIX",
+			prefix: "F3BBB",
+			suffixes: map[string]int{
+				"0A1B2C3D4E5F60718293A4B5C6D7E8F9012": 3,
+				"D66A63D4BF1747940578EC3D0103530E21D": 17043,
+			},
+		},
+		{name: "only padding for the suffix", prefix: "35B1A", suffixes: map[string]int{"C6F9CC1A7D2B46D057C6858B3AF47086AE8": 12}},
+		{name: "no file", prefix: "C5223", suffixes: map[string]int{}},
+		{name: "too short", prefix: "5BAA", err: ERR_INVALID_PREFIX},
+		{name: "not hex", prefix: "5BAAG", err: ERR_INVALID_PREFIX},
+		{name: "path traversal", prefix: "../..", err: ERR_INVALID_PREFIX},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			suffixes, err := dataset.Range(tt.prefix)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("error = %v, want %v", err, tt.err)
+			}
+			if tt.err == nil && !reflect.DeepEqual(suffixes, tt.suffixes) {
+				t.Fatalf("suffixes = %v, want %v", suffixes, tt.suffixes)
+			}
+		})
+	}
+}
+
+func TestNewDataset(t *testing.T) {
+	dataset, err := NewDataset(Config{})
+	if err != nil || dataset != nil {
+		t.Fatalf("without range dir = %v, %v, want a disabled dataset", dataset, err)
+	}
+
+	file := filepath.Join(t.TempDir(), "5BAA6.txt")
+	if err := os.WriteFile(file, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for _, dir := range []string{file, filepath.Join(t.TempDir(), "missing")} {
+		if _, err := NewDataset(Config{RangeDir: dir}); err == nil {
+			t.Fatalf("NewDataset(%s) expected an error", dir)
+		}
+	}
+}
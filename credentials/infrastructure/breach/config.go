@@ -0,0 +1,6 @@
+package breach
+
+type Config struct {
+	//directory of the HIBP-style range files, one file per 5 hex characters SHA-1 prefix; empty disables breach checks
+	RangeDir string `mapstructure:"range_dir"`
+}
@@ -0,0 +1,46 @@
+package broker
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/DO-2K23-26/polypass-microservices/credentials/infrastructure"
+	"github.com/confluentinc/confluent-kafka-go/kafka"
+)
+
+// messages still queued by the producer are given this long to be delivered on shutdown
+const defaultFlushTimeout = 5 * time.Second
+
+// Kafka closes the producer and the consumer shared by the repositories. It is registered after the
+// database so that the cycle flushes the producer before closing the database.
+type Kafka struct {
+	producer     *kafka.Producer
+	consumer     *kafka.Consumer
+	flushTimeout time.Duration
+}
+
+func NewKafka(producer *kafka.Producer, consumer *kafka.Consumer) infrastructure.Repository {
+	return Kafka{
+		producer:     producer,
+		consumer:     consumer,
+		flushTimeout: defaultFlushTimeout,
+	}
+}
+
+func (k Kafka) Setup() error {
+	return nil
+}
+
+// Shutdown flushes the producer, then closes both clients. Undelivered messages stay unsent in the
+// outbox and are relayed again on the next start.
+func (k Kafka) Shutdown() error {
+	remaining := k.producer.Flush(int(k.flushTimeout.Milliseconds()))
+	k.producer.Close()
+	if err := k.consumer.Close(); err != nil {
+		return err
+	}
+	if remaining > 0 {
+		return fmt.Errorf("%d Kafka messages were not delivered within %s", remaining, k.flushTimeout)
+	}
+	return nil
+}
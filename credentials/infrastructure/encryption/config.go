@@ -0,0 +1,15 @@
+package encryption
+
+type Config struct {
+	//name of the key provider used to wrap data keys (only "file" for now)
+	Provider string `mapstructure:"provider"`
+	//path to the master key file used by the file key provider
+	KeyFile string `mapstructure:"key_file"`
+	//generate the master key file if it does not exist (dev and tests only)
+	Generate bool `mapstructure:"generate"`
+	//rotate the master key and re-wrap every data key when starting in bootstrap mode
+	Rotate bool `mapstructure:"rotate"`
+	//number of data keys re-wrapped per transaction during a rotation, also the number of legacy rows
+	//sealed per transaction by the envelope backfill
+	RotationBatchSize int `mapstructure:"rotation_batch_size"`
+}
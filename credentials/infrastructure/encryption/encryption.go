@@ -0,0 +1,204 @@
+package encryption
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+const dataKeySize = 32
+
+var ERR_INVALID_CIPHERTEXT error = errors.New("invalid ciphertext")
+var ERR_UNKNOWN_KEY_PROVIDER error = errors.New("unknown key provider")
+
+// KeyProvider wraps and unwraps data keys with a master key it never exposes.
+// The key id returned by Wrap must be stored next to the wrapped key and given back to Unwrap.
+// A provider can hold several versions of the master key: Wrap always uses the current one
+// while Unwrap accepts any version that has not been retired yet.
+type KeyProvider interface {
+	CurrentKeyID() string
+	Wrap(dataKey []byte) (wrapped []byte, keyID string, err error)
+	Unwrap(wrapped []byte, keyID string) ([]byte, error)
+}
+
+// Rotator is implemented by the key providers able to create a new master key version themselves.
+// Providers backed by an external KMS rotate on their side and only expose the new current key.
+type Rotator interface {
+	Rotate() (keyID string, err error)
+}
+
+// Encryptor implements envelope encryption: every credential gets its own data key,
+// which is stored wrapped by the master key of the KeyProvider.
+type Encryptor interface {
+	// NewDataKey generates a fresh data key and wraps it
+	NewDataKey() (*DataKey, error)
+	// OpenDataKey unwraps a data key previously created by NewDataKey
+	OpenDataKey(wrapped []byte, keyID string) (*DataKey, error)
+	// Rewrap wraps an existing data key with the current master key, the data it protects is left untouched
+	Rewrap(wrapped []byte, keyID string) (*DataKey, error)
+	// CurrentKeyID returns the id of the master key used for new data keys
+	CurrentKeyID() string
+	// RotateMasterKey creates a new master key version when the provider supports it and returns the current key id
+	RotateMasterKey() (string, error)
+}
+
+type DataKey struct {
+	// Wrapped is the data key encrypted by the master key, safe to store
+	Wrapped []byte
+	// KeyID identifies the master key that wrapped the data key
+	KeyID string
+	aead  cipher.AEAD
+}
+
+type encryptor struct {
+	provider KeyProvider
+}
+
+func NewEncryptor(provider KeyProvider) Encryptor {
+	return encryptor{
+		provider: provider,
+	}
+}
+
+// NewKeyProvider builds the key provider selected in the configuration
+func NewKeyProvider(config Config) (KeyProvider, error) {
+	switch config.Provider {
+	case "", "file":
+		return NewFileKeyProvider(config.KeyFile, config.Generate)
+	default:
+		return nil, fmt.Errorf("%w: %s", ERR_UNKNOWN_KEY_PROVIDER, config.Provider)
+	}
+}
+
+func (e encryptor) NewDataKey() (*DataKey, error) {
+	plaintext := make([]byte, dataKeySize)
+	if _, err := rand.Read(plaintext); err != nil {
+		return nil, err
+	}
+	wrapped, keyID, err := e.provider.Wrap(plaintext)
+	if err != nil {
+		return nil, err
+	}
+	return newDataKey(plaintext, wrapped, keyID)
+}
+
+func (e encryptor) OpenDataKey(wrapped []byte, keyID string) (*DataKey, error) {
+	plaintext, err := e.provider.Unwrap(wrapped, keyID)
+	if err != nil {
+		return nil, err
+	}
+	return newDataKey(plaintext, wrapped, keyID)
+}
+
+func (e encryptor) Rewrap(wrapped []byte, keyID string) (*DataKey, error) {
+	plaintext, err := e.provider.Unwrap(wrapped, keyID)
+	if err != nil {
+		return nil, err
+	}
+	rewrapped, currentKeyID, err := e.provider.Wrap(plaintext)
+	if err != nil {
+		return nil, err
+	}
+	return newDataKey(plaintext, rewrapped, currentKeyID)
+}
+
+func (e encryptor) CurrentKeyID() string {
+	return e.provider.CurrentKeyID()
+}
+
+func (e encryptor) RotateMasterKey() (string, error) {
+	rotator, ok := e.provider.(Rotator)
+	if !ok {
+		return e.provider.CurrentKeyID(), nil
+	}
+	return rotator.Rotate()
+}
+
+func newDataKey(plaintext, wrapped []byte, keyID string) (*DataKey, error) {
+	aead, err := newAEAD(plaintext)
+	if err != nil {
+		return nil, err
+	}
+	return &DataKey{
+		Wrapped: wrapped,
+		KeyID:   keyID,
+		aead:    aead,
+	}, nil
+}
+
+// Encrypt seals the value of a secret field, the field name is authenticated
+// so that a ciphertext cannot be moved from one column to another.
+func (k *DataKey) Encrypt(field string, value string) (string, error) {
+	sealed, err := seal(k.aead, []byte(value), []byte(field))
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt opens a value produced by Encrypt for the same field
+func (k *DataKey) Decrypt(field string, value string) (string, error) {
+	sealed, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return "", ERR_INVALID_CIPHERTEXT
+	}
+	plaintext, err := open(k.aead, sealed, []byte(field))
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts plaintext and prepends the random nonce to the ciphertext
+func seal(aead cipher.AEAD, plaintext, additionalData []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+func open(aead cipher.AEAD, sealed, additionalData []byte) ([]byte, error) {
+	if len(sealed) < aead.NonceSize() {
+		return nil, ERR_INVALID_CIPHERTEXT
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, additionalData)
+	if err != nil {
+		return nil, ERR_INVALID_CIPHERTEXT
+	}
+	return plaintext, nil
+}
+
+// CheckKeyProvider wraps a random data key and unwraps it, which fails when the master key cannot be
+// reached
+func CheckKeyProvider(provider KeyProvider) error {
+	dataKey := make([]byte, dataKeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return err
+	}
+	wrapped, keyID, err := provider.Wrap(dataKey)
+	if err != nil {
+		return err
+	}
+	unwrapped, err := provider.Unwrap(wrapped, keyID)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(unwrapped, dataKey) {
+		return errors.New("the key provider did not unwrap the data key it wrapped")
+	}
+	return nil
+}
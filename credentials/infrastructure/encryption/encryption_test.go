@@ -0,0 +1,191 @@
+package encryption
+
+import (
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func newTestEncryptor(t *testing.T) (Encryptor, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "master.key")
+	provider, err := NewFileKeyProvider(path, true)
+	if err != nil {
+		t.Fatalf("new file key provider: %v", err)
+	}
+	return NewEncryptor(provider), path
+}
+
+func TestDataKeyRoundTrip(t *testing.T) {
+	encryptor, _ := newTestEncryptor(t)
+	dataKey, err := encryptor.NewDataKey()
+	if err != nil {
+		t.Fatalf("new data key: %v", err)
+	}
+	opened, err := encryptor.OpenDataKey(dataKey.Wrapped, dataKey.KeyID)
+	if err != nil {
+		t.Fatalf("open data key: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		field string
+		value string
+	}{
+		{"empty", "password", ""},
+		{"ascii", "password", "correct horse battery staple"},
+		{"unicode", "note", "mot de passe très secret 🔑"},
+		{"long", "private_key", string(make([]byte, 4096))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sealed, err := dataKey.Encrypt(tt.field, tt.value)
+			if err != nil {
+				t.Fatalf("encrypt: %v", err)
+			}
+			if tt.value != "" && sealed == tt.value {
+				t.Fatal("ciphertext equals the plaintext")
+			}
+			plaintext, err := opened.Decrypt(tt.field, sealed)
+			if err != nil {
+				t.Fatalf("decrypt: %v", err)
+			}
+			if plaintext != tt.value {
+				t.Fatalf("decrypt = %q, want %q", plaintext, tt.value)
+			}
+		})
+	}
+}
+
+func TestDataKeyTamperDetection(t *testing.T) {
+	encryptor, _ := newTestEncryptor(t)
+	dataKey, err := encryptor.NewDataKey()
+	if err != nil {
+		t.Fatalf("new data key: %v", err)
+	}
+	other, err := encryptor.NewDataKey()
+	if err != nil {
+		t.Fatalf("new data key: %v", err)
+	}
+	sealed, err := dataKey.Encrypt("password", "hunter2")
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+	raw, _ := base64.StdEncoding.DecodeString(sealed)
+
+	flip := func(index int) string {
+		tampered := append([]byte(nil), raw...)
+		tampered[index] ^= 0x01
+		return base64.StdEncoding.EncodeToString(tampered)
+	}
+
+	tests := []struct {
+		name  string
+		key   *DataKey
+		field string
+		value string
+	}{
+		{"flipped nonce", dataKey, "password", flip(0)},
+		{"flipped ciphertext", dataKey, "password", flip(len(raw) / 2)},
+		{"flipped tag", dataKey, "password", flip(len(raw) - 1)},
+		{"truncated", dataKey, "password", base64.StdEncoding.EncodeToString(raw[:8])},
+		{"not base64", dataKey, "password", "%%%"},
+		{"other field", dataKey, "cvc", sealed},
+		{"other data key", other, "password", sealed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.key.Decrypt(tt.field, tt.value); !errors.Is(err, ERR_INVALID_CIPHERTEXT) {
+				t.Fatalf("decrypt error = %v, want %v", err, ERR_INVALID_CIPHERTEXT)
+			}
+		})
+	}
+}
+
+func TestFileKeyProviderRotation(t *testing.T) {
+	encryptor, path := newTestEncryptor(t)
+	before, err := encryptor.NewDataKey()
+	if err != nil {
+		t.Fatalf("new data key: %v", err)
+	}
+	sealed, err := before.Encrypt("password", "hunter2")
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+
+	// another instance sharing the file rotates the master key
+	other, err := NewFileKeyProvider(path, false)
+	if err != nil {
+		t.Fatalf("open key file: %v", err)
+	}
+	rotated, err := NewEncryptor(other).RotateMasterKey()
+	if err != nil {
+		t.Fatalf("rotate: %v", err)
+	}
+	if rotated == before.KeyID {
+		t.Fatal("rotation kept the same key id")
+	}
+
+	after, err := encryptor.NewDataKey()
+	if err != nil {
+		t.Fatalf("new data key: %v", err)
+	}
+	if after.KeyID != rotated {
+		t.Fatalf("new data key wrapped with %s, want the rotated key %s", after.KeyID, rotated)
+	}
+
+	rewrapped, err := encryptor.Rewrap(before.Wrapped, before.KeyID)
+	if err != nil {
+		t.Fatalf("rewrap: %v", err)
+	}
+	if rewrapped.KeyID != rotated {
+		t.Fatalf("rewrapped with %s, want %s", rewrapped.KeyID, rotated)
+	}
+	plaintext, err := rewrapped.Decrypt("password", sealed)
+	if err != nil || plaintext != "hunter2" {
+		t.Fatalf("decrypt after rewrap = %q, %v", plaintext, err)
+	}
+}
+
+func TestFileKeyProviderErrors(t *testing.T) {
+	dir := t.TempDir()
+	invalid := filepath.Join(dir, "invalid.key")
+	if err := os.WriteFile(invalid, []byte("c2hvcnQ=\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	empty := filepath.Join(dir, "empty.key")
+	if err := os.WriteFile(empty, []byte("# no key yet\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		generate bool
+	}{
+		{"empty path", "", true},
+		{"missing file", filepath.Join(dir, "missing.key"), false},
+		{"short key", invalid, false},
+		{"no key", empty, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewFileKeyProvider(tt.path, tt.generate); err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+
+	provider, err := NewFileKeyProvider(filepath.Join(dir, "master.key"), true)
+	if err != nil {
+		t.Fatalf("new file key provider: %v", err)
+	}
+	if err := CheckKeyProvider(provider); err != nil {
+		t.Fatalf("check key provider: %v", err)
+	}
+	if _, err := provider.Unwrap([]byte("wrapped"), "file:unknown"); !errors.Is(err, ERR_UNKNOWN_MASTER_KEY) {
+		t.Fatalf("unwrap error = %v, want %v", err, ERR_UNKNOWN_MASTER_KEY)
+	}
+}
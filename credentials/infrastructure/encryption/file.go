@@ -0,0 +1,211 @@
+package encryption
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ERR_UNKNOWN_MASTER_KEY error = errors.New("unknown master key")
+
+// fileKeyProvider keeps the master keys in a local file, it is meant for dev and tests.
+// Every line of the file is the base64 encoding of a 32 bytes AES key. The last line is the
+// current key used to wrap new data keys, the previous ones stay available to unwrap the data
+// keys that were not rotated yet.
+type fileKeyProvider struct {
+	path    string
+	mu      sync.RWMutex
+	current string
+	keys    map[string]cipher.AEAD
+	// modTime and size of the file when it was loaded, a change means another instance rotated
+	modTime time.Time
+	size    int64
+}
+
+func NewFileKeyProvider(path string, generate bool) (KeyProvider, error) {
+	if path == "" {
+		return nil, errors.New("key file path cannot be empty")
+	}
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) && generate {
+		if err := appendKeyFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	provider := &fileKeyProvider{path: path}
+	if err := provider.load(); err != nil {
+		return nil, err
+	}
+	return provider, nil
+}
+
+func (f *fileKeyProvider) CurrentKeyID() string {
+	// a file that cannot be read keeps the keys already loaded, Wrap reports the error
+	_ = f.refresh()
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.current
+}
+
+// Wrap uses the current key of the file as it is now, so that new data keys are not wrapped with a
+// master key another instance retired
+func (f *fileKeyProvider) Wrap(dataKey []byte) ([]byte, string, error) {
+	if err := f.refresh(); err != nil {
+		return nil, "", err
+	}
+	f.mu.RLock()
+	keyID, aead := f.current, f.keys[f.current]
+	f.mu.RUnlock()
+
+	wrapped, err := seal(aead, dataKey, []byte(keyID))
+	if err != nil {
+		return nil, "", err
+	}
+	return wrapped, keyID, nil
+}
+
+func (f *fileKeyProvider) Unwrap(wrapped []byte, keyID string) ([]byte, error) {
+	aead, err := f.key(keyID)
+	if err != nil {
+		return nil, err
+	}
+	return open(aead, wrapped, []byte(keyID))
+}
+
+// Rotate appends a new master key to the file and makes it the current one
+func (f *fileKeyProvider) Rotate() (string, error) {
+	if err := appendKeyFile(f.path); err != nil {
+		return "", err
+	}
+	if err := f.load(); err != nil {
+		return "", err
+	}
+	return f.CurrentKeyID(), nil
+}
+
+// key looks a master key up, the file is reloaded once when the key is unknown
+// so that a rotation made by another instance is picked up without restarting.
+func (f *fileKeyProvider) key(keyID string) (cipher.AEAD, error) {
+	f.mu.RLock()
+	aead, ok := f.keys[keyID]
+	f.mu.RUnlock()
+	if ok {
+		return aead, nil
+	}
+
+	if err := f.load(); err != nil {
+		return nil, err
+	}
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	if aead, ok = f.keys[keyID]; !ok {
+		return nil, fmt.Errorf("%w: %s", ERR_UNKNOWN_MASTER_KEY, keyID)
+	}
+	return aead, nil
+}
+
+// refresh reloads the file when it changed since it was loaded, e.g. after a rotation made by another
+// instance
+func (f *fileKeyProvider) refresh() error {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return fmt.Errorf("read key file %s: %w", f.path, err)
+	}
+	f.mu.RLock()
+	changed := !info.ModTime().Equal(f.modTime) || info.Size() != f.size
+	f.mu.RUnlock()
+	if !changed {
+		return nil
+	}
+	return f.load()
+}
+
+func (f *fileKeyProvider) load() error {
+	// the file is stat before it is read, a change made in between is picked up by the next refresh
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return fmt.Errorf("read key file %s: %w", f.path, err)
+	}
+	keys, err := readKeyFile(f.path)
+	if err != nil {
+		return err
+	}
+
+	ring := make(map[string]cipher.AEAD, len(keys))
+	current := ""
+	for _, key := range keys {
+		aead, err := newAEAD(key)
+		if err != nil {
+			return err
+		}
+		current = fileKeyID(key)
+		ring[current] = aead
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.keys = ring
+	f.current = current
+	f.modTime = info.ModTime()
+	f.size = info.Size()
+	return nil
+}
+
+func readKeyFile(path string) ([][]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read key file %s: %w", path, err)
+	}
+
+	var keys [][]byte
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, err := base64.StdEncoding.DecodeString(line)
+		if err != nil {
+			return nil, fmt.Errorf("decode key file %s line %d: %w", path, i+1, err)
+		}
+		if len(key) != dataKeySize {
+			return nil, fmt.Errorf("key file %s line %d: expected a %d bytes key, got %d", path, i+1, dataKeySize, len(key))
+		}
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("key file %s: no master key found", path)
+	}
+	return keys, nil
+}
+
+func appendKeyFile(path string) error {
+	key := make([]byte, dataKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = file.WriteString(base64.StdEncoding.EncodeToString(key) + "\n")
+	return err
+}
+
+// fileKeyID derives a stable identifier from the key so that rows wrapped with another key are detected
+func fileKeyID(key []byte) string {
+	sum := sha256.Sum256(key)
+	return "file:" + hex.EncodeToString(sum[:8])
+}
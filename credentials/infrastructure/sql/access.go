@@ -0,0 +1,83 @@
+package sql
+
+import (
+	"time"
+
+	"github.com/DO-2K23-26/polypass-microservices/credentials/types"
+	"github.com/lib/pq"
+)
+
+type credentialAccessRow struct {
+	ID           int64                `db:"id"`
+	CredentialID string               `db:"credential_id"`
+	Type         types.CredentialType `db:"type"`
+	Actor        *string              `db:"actor"`
+	Fields       pq.StringArray       `db:"fields"`
+	SourceIP     string               `db:"source_ip"`
+	UserAgent    string               `db:"user_agent"`
+	ReadAt       *time.Time           `db:"read_at"`
+}
+
+// RecordCredentialAccess appends the reads to the access log and sets last_read_at of the credentials
+// read, in one transaction. A credential_read event is queued for every read.
+func (m sql) RecordCredentialAccess(accesses []types.CredentialAccess) error {
+	if len(accesses) == 0 {
+		return nil
+	}
+	tx, err := m.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	ids := make([]string, 0, len(accesses))
+	for i, access := range accesses {
+		err := tx.Get(&accesses[i].ID, `
+            INSERT INTO credential_access_log (credential_id, type, actor, fields, source_ip, user_agent, read_at)
+            VALUES ($1, $2, $3, $4, $5, $6, $7)
+            RETURNING id
+        `, access.CredentialID, access.Type, access.Actor, pq.Array(access.Fields), access.SourceIP, access.UserAgent, access.ReadAt)
+		if err != nil {
+			return err
+		}
+		if err := m.enqueueMessage(tx, "credential_read", accesses[i]); err != nil {
+			return err
+		}
+		ids = append(ids, access.CredentialID)
+	}
+	// updating the parent table updates the inherited tables too
+	if _, err := tx.Exec("UPDATE credentials SET last_read_at = $1 WHERE id = ANY($2)", accesses[0].ReadAt, pq.Array(ids)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetCredentialAccessLog returns the reads of a credential, newest first
+func (m sql) GetCredentialAccessLog(id string, limit int, offset int) ([]types.CredentialAccess, error) {
+	var rows []credentialAccessRow
+	err := m.db.Select(&rows, `
+        SELECT id, credential_id, type, actor, fields, COALESCE(source_ip, '') AS source_ip,
+               COALESCE(user_agent, '') AS user_agent, read_at
+        FROM credential_access_log
+        WHERE credential_id = $1
+        ORDER BY read_at DESC, id DESC
+        LIMIT $2 OFFSET $3
+    `, id, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	accesses := make([]types.CredentialAccess, 0, len(rows))
+	for _, row := range rows {
+		accesses = append(accesses, types.CredentialAccess{
+			ID:           row.ID,
+			CredentialID: row.CredentialID,
+			Type:         row.Type,
+			Actor:        row.Actor,
+			Fields:       row.Fields,
+			SourceIP:     row.SourceIP,
+			UserAgent:    row.UserAgent,
+			ReadAt:       row.ReadAt,
+		})
+	}
+	return accesses, nil
+}
@@ -0,0 +1,101 @@
+package sql
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/DO-2K23-26/polypass-microservices/credentials/registry"
+	"github.com/DO-2K23-26/polypass-microservices/credentials/types"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// ERR_HISTORY_CONFLICT is returned by RestoreCredentials when an id of the archive still has versions
+// stored, restoring it would hand the history of another credential to the importer
+var ERR_HISTORY_CONFLICT error = errors.New("the archive holds a credential id that already has a version history")
+
+// RestoreCredentials writes back credentials read from an export archive in one transaction. Unlike
+// CreateCredentials the ids, timestamps and version history are kept as they are in the archive. Ids
+// that still have versions stored are rejected with ERR_HISTORY_CONFLICT.
+func (m sql) RestoreCredentials(archived []types.ArchivedCredential) ([]types.GenericCredential, error) {
+	tx, err := m.db.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	ids := make([]string, 0, len(archived))
+	for _, entry := range archived {
+		ids = append(ids, entry.Credential.ID)
+	}
+	var withHistory bool
+	if err := tx.Get(&withHistory, "SELECT EXISTS (SELECT 1 FROM credential_versions WHERE credential_id = ANY($1))", pq.Array(ids)); err != nil {
+		return nil, err
+	}
+	if withHistory {
+		return nil, ERR_HISTORY_CONFLICT
+	}
+
+	restored := make([]types.GenericCredential, 0, len(archived))
+	for _, entry := range archived {
+		credential, err := m.restoreCredential(tx, entry.Credential)
+		if err != nil {
+			return nil, err
+		}
+		for _, version := range entry.Versions {
+			if err := m.restoreCredentialVersion(tx, credential.ID, version); err != nil {
+				return nil, err
+			}
+		}
+		if err := m.enqueueMessage(tx, "creds_create", credential); err != nil {
+			return nil, err
+		}
+		restored = append(restored, credential)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return restored, nil
+}
+
+func (m sql) restoreCredential(tx *sqlx.Tx, credential types.GenericCredential) (types.GenericCredential, error) {
+	definition, err := registry.Get(credential.Type)
+	if err != nil {
+		return credential, err
+	}
+	columns, values, err := m.sealCredential(definition, credential)
+	if err != nil {
+		return credential, err
+	}
+	// archives exported before versions were counted have none
+	columns = append(columns, "id", "created_at", "updated_at", "last_read_at", "owner", "version")
+	values = append(values, credential.ID, credential.CreatedAt, credential.UpdatedAt, credential.LastReadAt, credential.Owner, max(credential.Version, 1))
+	placeholders := make([]string, len(columns))
+	for i := range columns {
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+	}
+
+	var row credentialRow
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s) RETURNING %s", definition.Table, strings.Join(columns, ", "), strings.Join(placeholders, ", "), credentialColumns(definition))
+	if err := tx.Get(&row, query, values...); err != nil {
+		return credential, err
+	}
+	return m.openCredential(definition, row)
+}
+
+func (m sql) restoreCredentialVersion(tx *sqlx.Tx, id string, version types.ArchivedVersion) error {
+	key, err := m.encryptor.NewDataKey()
+	if err != nil {
+		return err
+	}
+	sealed, err := key.Encrypt("version", string(version.Data))
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`
+        INSERT INTO credential_versions (credential_id, version, type, actor, data, data_key, key_id, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+    `, id, version.Version, version.Type, version.Actor, sealed, key.Wrapped, key.KeyID, version.CreatedAt)
+	return err
+}
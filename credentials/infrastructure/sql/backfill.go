@@ -0,0 +1,229 @@
+package sql
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/DO-2K23-26/polypass-microservices/credentials/card"
+	"github.com/DO-2K23-26/polypass-microservices/credentials/infrastructure"
+	"github.com/DO-2K23-26/polypass-microservices/credentials/registry"
+	"github.com/DO-2K23-26/polypass-microservices/credentials/types"
+	"github.com/optique-dev/optique"
+)
+
+// SealLegacyCredentials encrypts the secret attributes of the rows written before encryption was
+// enabled, trashed ones included, and returns how many were sealed. Rows are sealed in batches committed
+// one by one so that an interrupted backfill is resumed by the next run. Versions were always encrypted.
+func (m sql) SealLegacyCredentials(batchSize int) (int, error) {
+	if batchSize <= 0 {
+		batchSize = defaultRotationBatchSize
+	}
+	total := 0
+	for _, definition := range registry.All() {
+		for {
+			sealed, err := m.sealLegacyBatch(definition, batchSize)
+			if err != nil {
+				return total, err
+			}
+			total += sealed
+			if sealed > 0 {
+				optique.Info(fmt.Sprintf("Envelope backfill: %d %s credentials sealed", sealed, definition.Type))
+			}
+			if sealed < batchSize {
+				break
+			}
+		}
+	}
+	return total, nil
+}
+
+// sealLegacyBatch seals one batch of plaintext rows of a type in a single transaction. Only the secret
+// attributes and the envelope change: no version nor event is written as the credential is the same.
+func (m sql) sealLegacyBatch(definition registry.Definition, batchSize int) (int, error) {
+	tx, err := m.db.Beginx()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var rows []credentialRow
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE data_key IS NULL OR key_id IS NULL ORDER BY id LIMIT $1 FOR UPDATE", credentialColumns(definition), definition.Table)
+	if err := tx.Select(&rows, query, batchSize); err != nil {
+		return 0, err
+	}
+
+	for _, row := range rows {
+		attributes, err := decodeAttributes(row.Attributes)
+		if err != nil {
+			return 0, err
+		}
+		attributes, err = openAttributes(nil, definition, attributes)
+		if err != nil {
+			return 0, fmt.Errorf("read legacy credential %s: %w", row.ID, err)
+		}
+		key, err := m.encryptor.NewDataKey()
+		if err != nil {
+			return 0, err
+		}
+		attributes, err = sealAttributes(key, definition, attributes)
+		if err != nil {
+			return 0, err
+		}
+
+		sealed := newEnvelope(key)
+		assignments := []string{"data_key = $1", "key_id = $2"}
+		values := []any{sealed.DataKey, sealed.KeyID}
+		for _, attribute := range definition.Attributes {
+			if attribute.Secret {
+				values = append(values, attributes[attribute.Name])
+				assignments = append(assignments, fmt.Sprintf("%s = $%d", attribute.Name, len(values)))
+			}
+		}
+		values = append(values, row.ID)
+		update := fmt.Sprintf("UPDATE %s SET %s WHERE id = $%d", definition.Table, strings.Join(assignments, ", "), len(values))
+		if _, err := tx.Exec(update, values...); err != nil {
+			return 0, err
+		}
+	}
+	return len(rows), tx.Commit()
+}
+
+// CompleteLegacyCards sets the brand and last four digits of the cards stored before they were computed,
+// trashed ones included, and returns how many were completed. The card number is encrypted so they cannot
+// be set by the migration: each row is opened with its data key, which requires the envelope backfill first.
+func (m sql) CompleteLegacyCards(batchSize int) (int, error) {
+	if batchSize <= 0 {
+		batchSize = defaultRotationBatchSize
+	}
+	definition, err := registry.Get(types.CredentialTypeCard)
+	if err != nil {
+		return 0, err
+	}
+	total := 0
+	for {
+		completed, err := m.completeLegacyCardBatch(definition, batchSize)
+		if err != nil {
+			return total, err
+		}
+		total += completed
+		if completed < batchSize {
+			return total, nil
+		}
+	}
+}
+
+// completeLegacyCardBatch completes one batch of cards in a single transaction. Like the envelope
+// backfill, no version nor event is written as only derived attributes change.
+func (m sql) completeLegacyCardBatch(definition registry.Definition, batchSize int) (int, error) {
+	tx, err := m.db.Beginx()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var rows []credentialRow
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE brand IS NULL OR last_four IS NULL ORDER BY id LIMIT $1 FOR UPDATE", credentialColumns(definition), definition.Table)
+	if err := tx.Select(&rows, query, batchSize); err != nil {
+		return 0, err
+	}
+
+	for _, row := range rows {
+		key, err := m.openEnvelope(row.envelope)
+		if err != nil {
+			return 0, fmt.Errorf("read legacy card %s: %w", row.ID, err)
+		}
+		attributes, err := decodeAttributes(row.Attributes)
+		if err != nil {
+			return 0, err
+		}
+		attributes, err = openAttributes(key, definition, attributes)
+		if err != nil {
+			return 0, fmt.Errorf("read legacy card %s: %w", row.ID, err)
+		}
+		// a number stored before it was validated gets the unknown brand, the row is not read again
+		number := card.Normalize(attributes["card_number"].(string))
+		update := fmt.Sprintf("UPDATE %s SET brand = $1, last_four = $2 WHERE id = $3", definition.Table)
+		if _, err := tx.Exec(update, string(card.DetectBrand(number)), card.LastFour(number), row.ID); err != nil {
+			return 0, err
+		}
+	}
+	return len(rows), tx.Commit()
+}
+
+type envelopeBackfill struct {
+	database  Sql
+	batchSize int
+}
+
+// NewEnvelopeBackfill wraps SealLegacyCredentials in a repository so that the Cycle runs it in bootstrap
+// mode, right after the migrations. Reads of a row without an envelope fail until it has run.
+func NewEnvelopeBackfill(database Sql, batchSize int) infrastructure.Repository {
+	return envelopeBackfill{
+		database:  database,
+		batchSize: batchSize,
+	}
+}
+
+func (e envelopeBackfill) Setup() error {
+	sealed, err := e.database.SealLegacyCredentials(e.batchSize)
+	if err == nil && sealed > 0 {
+		optique.Info(fmt.Sprintf("Envelope backfill sealed %d credentials", sealed))
+	}
+	return err
+}
+
+func (e envelopeBackfill) Shutdown() error {
+	return nil
+}
+
+type cardBackfill struct {
+	database  Sql
+	batchSize int
+}
+
+// NewCardBackfill wraps CompleteLegacyCards in a repository so that the Cycle runs it in bootstrap mode,
+// after the envelope backfill
+func NewCardBackfill(database Sql, batchSize int) infrastructure.Repository {
+	return cardBackfill{
+		database:  database,
+		batchSize: batchSize,
+	}
+}
+
+func (c cardBackfill) Setup() error {
+	completed, err := c.database.CompleteLegacyCards(c.batchSize)
+	if err == nil && completed > 0 {
+		optique.Info(fmt.Sprintf("Card backfill set the brand and last digits of %d cards", completed))
+	}
+	return err
+}
+
+func (c cardBackfill) Shutdown() error {
+	return nil
+}
+
+type outboxBackfill struct {
+	database  Sql
+	batchSize int
+}
+
+// NewOutboxBackfill wraps ReencodeLegacyOutbox in a repository so that the Cycle runs it in bootstrap
+// mode, right after the migrations, and no pending event keeps secrets longer than needed
+func NewOutboxBackfill(database Sql, batchSize int) infrastructure.Repository {
+	return outboxBackfill{
+		database:  database,
+		batchSize: batchSize,
+	}
+}
+
+func (o outboxBackfill) Setup() error {
+	rewritten, err := o.database.ReencodeLegacyOutbox(o.batchSize)
+	if err == nil && rewritten > 0 {
+		optique.Info(fmt.Sprintf("Outbox backfill re-encoded %d events without their secrets", rewritten))
+	}
+	return err
+}
+
+func (o outboxBackfill) Shutdown() error {
+	return nil
+}
@@ -0,0 +1,67 @@
+package sql
+
+import (
+	"fmt"
+
+	"github.com/DO-2K23-26/polypass-microservices/credentials/registry"
+	"github.com/DO-2K23-26/polypass-microservices/credentials/types"
+	"github.com/jmoiron/sqlx"
+)
+
+// WriteCredentials applies creates, updates and deletes of any type in one transaction. It returns the
+// written credential and the error of each operation, or an error of the transaction itself. In atomic
+// mode the first failure rolls the whole batch back and the next operations are not tried. Otherwise
+// each operation runs in a savepoint and only the failed ones are rolled back. The events of the
+// operations are queued in the transaction, so only committed operations produce one.
+func (m sql) WriteCredentials(operations []types.CredentialOperation, atomic bool) ([]types.GenericCredential, []error, error) {
+	written := make([]types.GenericCredential, len(operations))
+	errs := make([]error, len(operations))
+
+	tx, err := m.db.Beginx()
+	if err != nil {
+		return written, errs, err
+	}
+	defer tx.Rollback()
+
+	for i, operation := range operations {
+		if !atomic {
+			if _, err := tx.Exec("SAVEPOINT operation"); err != nil {
+				return written, errs, err
+			}
+		}
+		written[i], errs[i] = m.writeCredential(tx, operation)
+		switch {
+		case errs[i] != nil && atomic:
+			return written, errs, nil
+		case errs[i] != nil:
+			_, err = tx.Exec("ROLLBACK TO SAVEPOINT operation")
+		case !atomic:
+			_, err = tx.Exec("RELEASE SAVEPOINT operation")
+		}
+		if err != nil {
+			return written, errs, err
+		}
+	}
+	return written, errs, tx.Commit()
+}
+
+func (m sql) writeCredential(tx *sqlx.Tx, operation types.CredentialOperation) (types.GenericCredential, error) {
+	credential := operation.Credential
+	switch operation.Kind {
+	case types.CredentialOperationCreate:
+		return m.createCredential(tx, credential)
+	case types.CredentialOperationUpdate:
+		return m.updateCredential(tx, credential)
+	case types.CredentialOperationDelete:
+		definition, err := registry.Get(credential.Type)
+		if err != nil {
+			return credential, err
+		}
+		deleted, err := m.deleteCredentials(tx, definition, []string{credential.ID}, credential.UpdatedBy)
+		if err == nil && len(deleted) == 0 {
+			err = ERR_NOT_FOUND
+		}
+		return credential, err
+	}
+	return credential, fmt.Errorf("unknown operation %s", operation.Kind)
+}
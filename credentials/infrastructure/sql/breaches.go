@@ -0,0 +1,46 @@
+package sql
+
+import (
+	"time"
+
+	"github.com/DO-2K23-26/polypass-microservices/credentials/types"
+	"github.com/lib/pq"
+)
+
+// SavePasswordBreaches replaces the previous result of each credential
+func (m sql) SavePasswordBreaches(breaches []types.PasswordBreach) error {
+	tx, err := m.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, breach := range breaches {
+		_, err := tx.Exec(`
+            INSERT INTO password_breaches (credential_id, occurrences, checked_at)
+            VALUES ($1, $2, $3)
+            ON CONFLICT (credential_id) DO UPDATE
+            SET occurrences = EXCLUDED.occurrences,
+                checked_at  = EXCLUDED.checked_at
+        `, breach.CredentialID, breach.Occurrences, time.Now())
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (m sql) GetPasswordBreaches(ids []string) ([]types.PasswordBreach, error) {
+	var breaches []types.PasswordBreach
+	err := m.db.Select(&breaches, `
+        SELECT credential_id, occurrences, occurrences > 0 AS compromised, checked_at
+        FROM password_breaches
+        WHERE credential_id = ANY($1)
+    `, pq.Array(ids))
+	return breaches, err
+}
+
+func (m sql) DeletePasswordBreaches(ids []string) error {
+	_, err := m.db.Exec("DELETE FROM password_breaches WHERE credential_id = ANY($1)", pq.Array(ids))
+	return err
+}
@@ -0,0 +1,72 @@
+package sql
+
+import (
+	"errors"
+
+	"github.com/DO-2K23-26/polypass-microservices/credentials/infrastructure/encryption"
+	"github.com/DO-2K23-26/polypass-microservices/credentials/registry"
+)
+
+// envelope is the wrapped data key stored next to the secret fields of every credential row
+type envelope struct {
+	DataKey []byte  `db:"data_key"`
+	KeyID   *string `db:"key_id"`
+}
+
+func newEnvelope(key *encryption.DataKey) envelope {
+	return envelope{
+		DataKey: key.Wrapped,
+		KeyID:   &key.KeyID,
+	}
+}
+
+// ERR_MISSING_ENVELOPE is returned for a row written before encryption was enabled that was not sealed
+// yet, see SealLegacyCredentials
+var ERR_MISSING_ENVELOPE error = errors.New("credential has no data key, run the envelope backfill")
+
+func (m sql) openEnvelope(e envelope) (*encryption.DataKey, error) {
+	if e.DataKey == nil || e.KeyID == nil {
+		return nil, ERR_MISSING_ENVELOPE
+	}
+	return m.encryptor.OpenDataKey(e.DataKey, *e.KeyID)
+}
+
+// sealAttributes encrypts the secret attributes of a definition, the others are kept as they are
+func sealAttributes(key *encryption.DataKey, definition registry.Definition, attributes map[string]any) (map[string]any, error) {
+	sealed := make(map[string]any, len(attributes))
+	for name, value := range attributes {
+		attribute, ok := definition.Attribute(name)
+		if !ok || !attribute.Secret {
+			sealed[name] = value
+			continue
+		}
+		ciphertext, err := key.Encrypt(name, attribute.Format(value))
+		if err != nil {
+			return nil, err
+		}
+		sealed[name] = ciphertext
+	}
+	return sealed, nil
+}
+
+// openAttributes decrypts the secret attributes read from the database and converts every attribute
+// to its kind. A nil key reads plaintext values, it is only given by the envelope backfill.
+func openAttributes(key *encryption.DataKey, definition registry.Definition, attributes map[string]any) (map[string]any, error) {
+	opened := make(map[string]any, len(definition.Attributes))
+	for _, attribute := range definition.Attributes {
+		value := attributes[attribute.Name]
+		if ciphertext, ok := value.(string); ok && key != nil && attribute.Secret {
+			plaintext, err := key.Decrypt(attribute.Name, ciphertext)
+			if err != nil {
+				return nil, err
+			}
+			value = plaintext
+		}
+		converted, err := attribute.Convert(value)
+		if err != nil {
+			return nil, err
+		}
+		opened[attribute.Name] = converted
+	}
+	return opened, nil
+}
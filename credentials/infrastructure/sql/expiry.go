@@ -0,0 +1,70 @@
+package sql
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/DO-2K23-26/polypass-microservices/credentials/registry"
+	"github.com/DO-2K23-26/polypass-microservices/credentials/types"
+)
+
+// GetExpiringCredentials returns the credentials of every type expiring before the given time, expired
+// ones included. The expiry is the earliest of expires_at and the expiry declared by the type.
+func (m sql) GetExpiringCredentials(before time.Time) ([]types.CredentialExpiry, error) {
+	var expiring []types.CredentialExpiry
+	for _, definition := range registry.All() {
+		var credentials []types.CredentialExpiry
+		err := m.db.Select(&credentials, fmt.Sprintf(`
+            SELECT id, title, expires_at
+            FROM (SELECT id, COALESCE(title, '') AS title, %s AS expires_at FROM %s WHERE deleted_at IS NULL) credentials
+            WHERE expires_at < $1
+        `, expiryColumn(definition), definition.Table), before)
+		if err != nil {
+			return nil, err
+		}
+		for _, credential := range credentials {
+			credential.Type = definition.Type
+			expiring = append(expiring, credential)
+		}
+	}
+	return expiring, nil
+}
+
+// expiryColumn is the SQL expression of the expiry of a credential of the type
+func expiryColumn(definition registry.Definition) string {
+	if definition.Expiry == "" {
+		return "expires_at"
+	}
+	return fmt.Sprintf("LEAST(expires_at, %s)", definition.Expiry)
+}
+
+// NotifyCredentialExpiry queues the expiry event of a credential once per window and expiry date, the
+// notice and the event are written in the same transaction.
+func (m sql) NotifyCredentialExpiry(expiry types.CredentialExpiry) (bool, error) {
+	tx, err := m.db.Beginx()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+        INSERT INTO credential_expiry_notices (credential_id, expires_at, window_days)
+        VALUES ($1, $2, $3)
+        ON CONFLICT DO NOTHING
+    `, expiry.CredentialID, expiry.ExpiresAt, expiry.WindowDays)
+	if err != nil {
+		return false, err
+	}
+	if inserted, err := result.RowsAffected(); err != nil || inserted == 0 {
+		return false, err
+	}
+
+	topic := "credential_expiring"
+	if expiry.WindowDays == 0 {
+		topic = "credential_expired"
+	}
+	if err := m.enqueueMessage(tx, topic, expiry); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
@@ -0,0 +1,89 @@
+package sql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4/source"
+)
+
+var ERR_MIGRATIONS_PENDING error = errors.New("migrations are pending")
+var ERR_MIGRATIONS_DIRTY error = errors.New("the last migration failed")
+
+// Kafka metadata is requested with this timeout when the context has no deadline
+const defaultKafkaTimeout = 2 * time.Second
+
+// Ping checks that a connection of the pool reaches the database
+func (m sql) Ping(ctx context.Context) error {
+	return m.db.PingContext(ctx)
+}
+
+// CheckMigrations checks that the schema is at the latest version of the migrations directory, so that
+// an instance is not given traffic before it is migrated
+func (m sql) CheckMigrations(ctx context.Context) error {
+	var current struct {
+		Version uint `db:"version"`
+		Dirty   bool `db:"dirty"`
+	}
+	if err := m.db.GetContext(ctx, &current, "SELECT version, dirty FROM schema_migrations LIMIT 1"); err != nil {
+		return fmt.Errorf("migration version: %w", err)
+	}
+	if current.Dirty {
+		return fmt.Errorf("%w: version %d", ERR_MIGRATIONS_DIRTY, current.Version)
+	}
+
+	latest, err := m.latestMigration()
+	if err != nil {
+		return err
+	}
+	if current.Version < latest {
+		return fmt.Errorf("%w: version %d, latest %d", ERR_MIGRATIONS_PENDING, current.Version, latest)
+	}
+	return nil
+}
+
+// latestMigration is the version of the last migration of the migrations directory
+func (m sql) latestMigration() (uint, error) {
+	driver, err := source.Open(fmt.Sprintf("file://%s", m.migrations))
+	if err != nil {
+		return 0, err
+	}
+	defer driver.Close()
+
+	version, err := driver.First()
+	if err != nil {
+		return 0, err
+	}
+	for {
+		next, err := driver.Next(version)
+		if errors.Is(err, fs.ErrNotExist) {
+			return version, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		version = next
+	}
+}
+
+// PingKafka requests the metadata of the cluster, which fails when no broker answers
+func (m sql) PingKafka(ctx context.Context) error {
+	timeout := defaultKafkaTimeout
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline)
+	}
+	if timeout <= 0 {
+		return context.DeadlineExceeded
+	}
+	metadata, err := m.producer.GetMetadata(nil, false, int(timeout.Milliseconds()))
+	if err != nil {
+		return err
+	}
+	if len(metadata.Brokers) == 0 {
+		return errors.New("no Kafka broker")
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package sql
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/DO-2K23-26/polypass-microservices/credentials/registry"
+	"github.com/DO-2K23-26/polypass-microservices/credentials/types"
+	"github.com/lib/pq"
+)
+
+type sortColumn struct {
+	expression string
+	// cast of the sort key of a cursor, it is given as text
+	cast string
+}
+
+// missing values are sorted first, as the smallest ones
+var sortColumns = map[types.CredentialSort]sortColumn{
+	types.CredentialSortTitle:      {"COALESCE(title, '')", "text"},
+	types.CredentialSortCreatedAt:  {"COALESCE(created_at, '-infinity')", "timestamp"},
+	types.CredentialSortUpdatedAt:  {"COALESCE(updated_at, '-infinity')", "timestamp"},
+	types.CredentialSortLastReadAt: {"COALESCE(last_read_at, '-infinity')", "timestamp"},
+}
+
+// ListCredentials returns the ids of a page of credentials with the sort key of each one. Every type
+// is listed in one query, the tables of the types are joined with UNION ALL. The page starts after the
+// cursor, compared on the sort key then the id so that pages stay stable while credentials are written.
+func (m sql) ListCredentials(query types.CredentialQuery, after *types.CredentialCursor) ([]types.CredentialListEntry, error) {
+	column, ok := sortColumns[query.Sort]
+	if !ok {
+		return nil, fmt.Errorf("credentials cannot be sorted by %s", query.Sort)
+	}
+
+	var args []any
+	arg := func(value any) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	conditions := []string{"deleted_at IS NULL"}
+	if query.Owner != nil {
+		conditions = append(conditions, fmt.Sprintf("owner = %s", arg(*query.Owner)))
+	}
+	if query.TitlePrefix != "" {
+		conditions = append(conditions, fmt.Sprintf("title ILIKE %s || '%%'", arg(escapeLike(query.TitlePrefix))))
+	}
+	if query.CreatedAfter != nil {
+		conditions = append(conditions, fmt.Sprintf("created_at >= %s", arg(*query.CreatedAfter)))
+	}
+	if query.CreatedBefore != nil {
+		conditions = append(conditions, fmt.Sprintf("created_at < %s", arg(*query.CreatedBefore)))
+	}
+	if query.UpdatedAfter != nil {
+		conditions = append(conditions, fmt.Sprintf("updated_at >= %s", arg(*query.UpdatedAfter)))
+	}
+	if query.UpdatedBefore != nil {
+		conditions = append(conditions, fmt.Sprintf("updated_at < %s", arg(*query.UpdatedBefore)))
+	}
+	if len(query.CustomFields) > 0 {
+		conditions = append(conditions, fmt.Sprintf("custom_fields ?& %s::text[]", arg(pq.Array(query.CustomFields))))
+	}
+
+	names := make([]string, 0, len(query.Attributes))
+	for name := range query.Attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	values := make(map[string]string, len(names))
+	for _, name := range names {
+		values[name] = arg(query.Attributes[name])
+	}
+	window := ""
+	if query.Expiry == types.ExpiryStatusExpiring {
+		window = arg(query.ExpiringWithin.Seconds())
+	}
+
+	var selects []string
+	for _, definition := range registry.All() {
+		if query.Type != "" && definition.Type != query.Type {
+			continue
+		}
+		where, searchable := append([]string(nil), conditions...), true
+		for _, name := range names {
+			attribute, ok := definition.Attribute(name)
+			if !ok || attribute.Secret {
+				searchable = false
+				break
+			}
+			// strings are compared without case, numbers as numbers, the service checked that they parse
+			if attribute.Kind == registry.KindString {
+				where = append(where, fmt.Sprintf("lower(%s) = lower(%s)", name, values[name]))
+			} else {
+				where = append(where, fmt.Sprintf("%s = %s::bigint", name, values[name]))
+			}
+		}
+		if !searchable {
+			continue
+		}
+		if condition := expiryCondition(query.Expiry, expiryColumn(definition), window); condition != "" {
+			where = append(where, condition)
+		}
+		selects = append(selects, fmt.Sprintf("SELECT id, '%s' AS type, %s AS sort_value FROM %s WHERE %s",
+			definition.Type, column.expression, definition.Table, strings.Join(where, " AND ")))
+	}
+	entries := []types.CredentialListEntry{}
+	if len(selects) == 0 {
+		return entries, nil
+	}
+
+	order, compare := "ASC", ">"
+	if query.Descending {
+		order, compare = "DESC", "<"
+	}
+	cursor := ""
+	if after != nil {
+		cursor = fmt.Sprintf("WHERE (sort_value, id) %s (%s::%s, %s::uuid)", compare, arg(after.SortKey), column.cast, arg(after.ID))
+	}
+	statement := fmt.Sprintf(`
+        SELECT id, type, sort_value::text AS sort_key
+        FROM (%s) credentials
+        %s
+        ORDER BY sort_value %s, id %s
+        LIMIT %s
+    `, strings.Join(selects, " UNION ALL "), cursor, order, order, arg(query.Limit))
+	if err := m.db.Select(&entries, statement, args...); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// expiryCondition filters on the expiry expression of a type, window is the placeholder of the
+// expiring window in seconds
+func expiryCondition(status types.ExpiryStatus, expiry string, window string) string {
+	switch status {
+	case types.ExpiryStatusExpired:
+		return fmt.Sprintf("%s < LOCALTIMESTAMP", expiry)
+	case types.ExpiryStatusExpiring:
+		return fmt.Sprintf("%[1]s >= LOCALTIMESTAMP AND %[1]s < LOCALTIMESTAMP + %[2]s * interval '1 second'", expiry, window)
+	case types.ExpiryStatusValid:
+		return fmt.Sprintf("(%[1]s IS NULL OR %[1]s >= LOCALTIMESTAMP)", expiry)
+	case types.ExpiryStatusNone:
+		return fmt.Sprintf("%s IS NULL", expiry)
+	}
+	return ""
+}
+
+// escapeLike escapes the wildcards of a LIKE pattern
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
+}
@@ -0,0 +1,125 @@
+package sql
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/DO-2K23-26/polypass-microservices/credentials/infrastructure/sql")
+
+var (
+	queryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "credentials_db_query_duration_seconds",
+		Help:    "Duration of the Postgres queries, by statement (select, insert, update, delete...) and status",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"statement", "status"})
+	produceDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "credentials_kafka_produce_duration_seconds",
+		Help:    "Time from the produce of a Kafka message to its delivery report, by topic and status",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"topic", "status"})
+	produceFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "credentials_kafka_produce_failures_total",
+		Help: "Kafka messages that were not delivered, by topic",
+	}, []string{"topic"})
+)
+
+func outcome(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "ok"
+}
+
+// instrumentedConnector times every query of the pool. Migrations use their own connection and are
+// not timed.
+type instrumentedConnector struct {
+	driver.Connector
+}
+
+func (c instrumentedConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return instrumentedConn{conn}, nil
+}
+
+// instrumentedConn forwards to the pq connection, which implements every interface below
+type instrumentedConn struct {
+	driver.Conn
+}
+
+func (c instrumentedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	done := observeQuery(ctx, query)
+	rows, err := c.Conn.(driver.QueryerContext).QueryContext(ctx, query, args)
+	done(err)
+	return rows, err
+}
+
+func (c instrumentedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	done := observeQuery(ctx, query)
+	result, err := c.Conn.(driver.ExecerContext).ExecContext(ctx, query, args)
+	done(err)
+	return result, err
+}
+
+func (c instrumentedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	return c.Conn.(driver.ConnPrepareContext).PrepareContext(ctx, query)
+}
+
+func (c instrumentedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	return c.Conn.(driver.ConnBeginTx).BeginTx(ctx, opts)
+}
+
+func (c instrumentedConn) Ping(ctx context.Context) error {
+	return c.Conn.(driver.Pinger).Ping(ctx)
+}
+
+func (c instrumentedConn) ResetSession(ctx context.Context) error {
+	return c.Conn.(driver.SessionResetter).ResetSession(ctx)
+}
+
+func (c instrumentedConn) IsValid() bool {
+	return c.Conn.(driver.Validator).IsValid()
+}
+
+// observeQuery starts timing a query, the returned function records it. A span is only started under
+// the span of a caller, queries are not traces of their own.
+func observeQuery(ctx context.Context, query string) func(error) {
+	start, statement := time.Now(), statementOf(query)
+	var span trace.Span
+	if trace.SpanContextFromContext(ctx).IsValid() {
+		_, span = tracer.Start(ctx, "db "+statement, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+			attribute.String("db.system", "postgresql"),
+			attribute.String("db.operation.name", statement),
+		))
+	}
+	return func(err error) {
+		queryDuration.WithLabelValues(statement, outcome(err)).Observe(time.Since(start).Seconds())
+		if span != nil {
+			if err != nil {
+				span.SetStatus(codes.Error, err.Error())
+			}
+			span.End()
+		}
+	}
+}
+
+// statementOf is the first keyword of a query, in lower case, e.g. select or insert
+func statementOf(query string) string {
+	fields := strings.Fields(query)
+	if len(fields) == 0 {
+		return "unknown"
+	}
+	return strings.ToLower(fields[0])
+}
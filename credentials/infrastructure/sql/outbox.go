@@ -0,0 +1,285 @@
+package sql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/DO-2K23-26/polypass-microservices/credentials/registry"
+	"github.com/confluentinc/confluent-kafka-go/kafka"
+	"github.com/jmoiron/sqlx"
+	"github.com/linkedin/goavro"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// longest wait before a failed message is retried
+const maxOutboxBackoff = 5 * time.Minute
+
+// longest wait for the delivery report of a message, the relay holds the locks of its batch meanwhile
+const publishTimeout = 30 * time.Second
+
+// ERR_PUBLISH_TIMEOUT is returned by publish when Kafka did not report the delivery in time
+var ERR_PUBLISH_TIMEOUT error = errors.New("no delivery report from kafka")
+
+type outboxMessage struct {
+	ID       int64  `db:"id"`
+	Topic    string `db:"topic"`
+	Key      string `db:"key"`
+	Payload  []byte `db:"payload"`
+	Attempts int    `db:"attempts"`
+	// Legacy payloads were encoded with the secret attributes, see ReencodeLegacyOutbox
+	Legacy bool `db:"legacy_payload"`
+}
+
+// enqueueMessage writes a credential event to the outbox, given a transaction it is only published if
+// the transaction commits. The relay publishes it to Kafka, see RelayOutbox.
+func (m sql) enqueueMessage(db sqlx.Execer, topic string, cred interface{}) error {
+	key, payload, err := m.encodeMessage(cred)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec("INSERT INTO outbox (topic, key, payload) VALUES ($1, $2, $3)", topic, key, payload)
+	return err
+}
+
+// RelayOutbox publishes the oldest pending messages to Kafka, in order, and returns how many were sent.
+// The messages are locked while they are published so that several instances can relay together. A
+// failed message is retried later with an exponential backoff and stops the batch, so that messages
+// are not published out of order.
+func (m sql) RelayOutbox(batchSize int) (int, error) {
+	tx, err := m.db.Beginx()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var messages []outboxMessage
+	err = tx.Select(&messages, `
+        SELECT id, topic, key, payload, attempts, legacy_payload
+        FROM outbox
+        WHERE sent_at IS NULL AND next_attempt_at <= now()
+        ORDER BY id
+        LIMIT $1
+        FOR UPDATE SKIP LOCKED
+    `, batchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, message := range messages {
+		if message.Legacy {
+			if message.Payload, err = m.reencodeLegacyMessage(tx, message); err != nil {
+				return sent, err
+			}
+		}
+		if err := m.publish(message); err != nil {
+			// the message may still be delivered, it is left pending and published again by a later batch
+			if errors.Is(err, ERR_PUBLISH_TIMEOUT) {
+				return sent, fmt.Errorf("publish of outbox message %d to %s: %w", message.ID, message.Topic, err)
+			}
+			backoff := min(time.Duration(1<<min(message.Attempts, 16))*time.Second, maxOutboxBackoff)
+			_, updateErr := tx.Exec(`
+                UPDATE outbox SET attempts = attempts + 1, last_error = $2, next_attempt_at = now() + $3 * interval '1 second'
+                WHERE id = $1
+            `, message.ID, err.Error(), backoff.Seconds())
+			if updateErr != nil {
+				return sent, updateErr
+			}
+			if commitErr := tx.Commit(); commitErr != nil {
+				return sent, commitErr
+			}
+			return sent, fmt.Errorf("publish of outbox message %d to %s failed: %w", message.ID, message.Topic, err)
+		}
+		if _, err := tx.Exec("UPDATE outbox SET sent_at = now(), attempts = attempts + 1, last_error = NULL WHERE id = $1", message.ID); err != nil {
+			return sent, err
+		}
+		sent++
+	}
+	return sent, tx.Commit()
+}
+
+// ReencodeLegacyOutbox rewrites the pending messages encoded before secret attributes were left out of
+// the events, and returns how many were rewritten. Each payload is decoded with the schema it was written
+// with and encoded again without its secret attributes, so that no pending event is lost.
+func (m sql) ReencodeLegacyOutbox(batchSize int) (int, error) {
+	if batchSize <= 0 {
+		batchSize = defaultRotationBatchSize
+	}
+	total := 0
+	for {
+		rewritten, err := m.reencodeLegacyBatch(batchSize)
+		if err != nil {
+			return total, err
+		}
+		total += rewritten
+		if rewritten < batchSize {
+			return total, nil
+		}
+	}
+}
+
+func (m sql) reencodeLegacyBatch(batchSize int) (int, error) {
+	tx, err := m.db.Beginx()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var messages []outboxMessage
+	err = tx.Select(&messages, `
+        SELECT id, topic, key, payload, attempts, legacy_payload
+        FROM outbox
+        WHERE legacy_payload
+        ORDER BY id
+        LIMIT $1
+        FOR UPDATE SKIP LOCKED
+    `, batchSize)
+	if err != nil {
+		return 0, err
+	}
+	for _, message := range messages {
+		if _, err := m.reencodeLegacyMessage(tx, message); err != nil {
+			return 0, err
+		}
+	}
+	return len(messages), tx.Commit()
+}
+
+// reencodeLegacyMessage stores the payload of a legacy message without its secret attributes and returns it
+func (m sql) reencodeLegacyMessage(tx *sqlx.Tx, message outboxMessage) ([]byte, error) {
+	var definition registry.Definition
+	for _, candidate := range registry.All() {
+		if candidate.Record == message.Key {
+			definition = candidate
+		}
+	}
+	if definition.Record == "" {
+		return nil, fmt.Errorf("outbox message %d: no credential type for record %s", message.ID, message.Key)
+	}
+
+	legacySchema, err := m.loadSchema("legacy/" + definition.Schema)
+	if err != nil {
+		return nil, err
+	}
+	legacyCodec, err := goavro.NewCodec(legacySchema)
+	if err != nil {
+		return nil, err
+	}
+	native, _, err := legacyCodec.NativeFromBinary(message.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("outbox message %d: decode legacy payload: %w", message.ID, err)
+	}
+	record, ok := native.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("outbox message %d: legacy payload is not a record", message.ID)
+	}
+	attributes, _ := record[definition.AttributesRecord].(map[string]interface{})
+	record[definition.AttributesRecord] = definition.Public(attributes)
+
+	schema, err := m.loadSchema(definition.Schema)
+	if err != nil {
+		return nil, err
+	}
+	codec, err := goavro.NewCodec(schema)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := codec.BinaryFromNative(nil, record)
+	if err != nil {
+		return nil, fmt.Errorf("outbox message %d: encode payload: %w", message.ID, err)
+	}
+	if _, err := tx.Exec("UPDATE outbox SET payload = $2, legacy_payload = false WHERE id = $1", message.ID, payload); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+// PurgeOutbox deletes the messages sent before the given time
+func (m sql) PurgeOutbox(before time.Time) (int64, error) {
+	result, err := m.db.Exec("DELETE FROM outbox WHERE sent_at < $1", before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// publish produces a message and waits for its delivery report, at most publishTimeout. The trace context of the publish is
+// written to the headers of the message, so that consumers continue the trace.
+func (m sql) publish(message outboxMessage) (err error) {
+	ctx, span := tracer.Start(context.Background(), message.Topic+" publish", trace.WithSpanKind(trace.SpanKindProducer), trace.WithAttributes(
+		attribute.String("messaging.system", "kafka"),
+		attribute.String("messaging.destination.name", message.Topic),
+		attribute.Int64("outbox.id", message.ID),
+	))
+	start := time.Now()
+	defer func() {
+		produceDuration.WithLabelValues(message.Topic, outcome(err)).Observe(time.Since(start).Seconds())
+		if err != nil {
+			produceFailures.WithLabelValues(message.Topic).Inc()
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
+
+	kafkaMessage := &kafka.Message{
+		TopicPartition: kafka.TopicPartition{Topic: &message.Topic, Partition: kafka.PartitionAny},
+		Key:            []byte(message.Key),
+		Value:          message.Payload,
+	}
+	otel.GetTextMapPropagator().Inject(ctx, headerCarrier{kafkaMessage})
+
+	// the channel is not closed, a late delivery report is still written to it after a timeout
+	dc := make(chan kafka.Event, 1)
+	if err := m.producer.Produce(kafkaMessage, dc); err != nil {
+		return err
+	}
+	var ev kafka.Event
+	select {
+	case ev = <-dc:
+	case <-time.After(publishTimeout):
+		return ERR_PUBLISH_TIMEOUT
+	}
+	if km, ok := ev.(*kafka.Message); ok && km.TopicPartition.Error != nil {
+		return km.TopicPartition.Error
+	}
+	log.Printf("[INFO] Message produit sur Kafka — topic: %s | key: %s | outbox id: %d", message.Topic, message.Key, message.ID)
+	return nil
+}
+
+// headerCarrier reads and writes the trace context in the headers of a Kafka message
+type headerCarrier struct {
+	message *kafka.Message
+}
+
+func (c headerCarrier) Get(key string) string {
+	for _, header := range c.message.Headers {
+		if header.Key == key {
+			return string(header.Value)
+		}
+	}
+	return ""
+}
+
+func (c headerCarrier) Set(key string, value string) {
+	for i, header := range c.message.Headers {
+		if header.Key == key {
+			c.message.Headers[i].Value = []byte(value)
+			return
+		}
+	}
+	c.message.Headers = append(c.message.Headers, kafka.Header{Key: key, Value: []byte(value)})
+}
+
+func (c headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(c.message.Headers))
+	for _, header := range c.message.Headers {
+		keys = append(keys, header.Key)
+	}
+	return keys
+}
@@ -0,0 +1,20 @@
+package sql
+
+import "github.com/lib/pq"
+
+// GetCredentialOwners returns the owner of credentials of any type, trashed ones included. Unknown ids
+// are left out, credentials without owner are given a nil one.
+func (m sql) GetCredentialOwners(ids []string) (map[string]*string, error) {
+	var rows []struct {
+		ID    string  `db:"id"`
+		Owner *string `db:"owner"`
+	}
+	if err := m.db.Select(&rows, "SELECT id, owner FROM credentials WHERE id = ANY($1)", pq.Array(ids)); err != nil {
+		return nil, err
+	}
+	owners := make(map[string]*string, len(rows))
+	for _, row := range rows {
+		owners[row.ID] = row.Owner
+	}
+	return owners, nil
+}
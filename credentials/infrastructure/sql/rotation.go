@@ -0,0 +1,153 @@
+package sql
+
+import (
+	dbsql "database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/DO-2K23-26/polypass-microservices/credentials/infrastructure"
+	"github.com/optique-dev/optique"
+)
+
+const defaultRotationBatchSize = 100
+
+// wrappedKeyTables are the tables holding data keys, credentials also covers every inherited table
+var wrappedKeyTables = []string{"credentials", "credential_versions"}
+
+type keyRotation struct {
+	ID          int        `db:"id"`
+	KeyID       string     `db:"key_id"`
+	Total       int        `db:"total"`
+	Rotated     int        `db:"rotated"`
+	StartedAt   *time.Time `db:"started_at"`
+	CompletedAt *time.Time `db:"completed_at"`
+}
+
+type wrappedDataKey struct {
+	ID      string `db:"id"`
+	DataKey []byte `db:"data_key"`
+	KeyID   string `db:"key_id"`
+}
+
+// RotateMasterKey re-wraps the data key of every credential and credential version with a new
+// master key version. Rows are selected on the parent credentials table so that every inherited
+// table is covered, and they are processed in batches committed one by one: an interrupted
+// rotation is resumed by the next run instead of starting over with yet another master key.
+func (m sql) RotateMasterKey(batchSize int) error {
+	if batchSize <= 0 {
+		batchSize = defaultRotationBatchSize
+	}
+
+	rotation, err := m.startKeyRotation()
+	if err != nil {
+		return err
+	}
+
+	for _, table := range wrappedKeyTables {
+		for {
+			rotated, err := m.rewrapDataKeys(table, rotation, batchSize)
+			if err != nil {
+				return err
+			}
+			rotation.Rotated += rotated
+			optique.Info(fmt.Sprintf("Key rotation %d: %d/%d data keys re-wrapped with %s", rotation.ID, rotation.Rotated, rotation.Total, rotation.KeyID))
+			if rotated < batchSize {
+				break
+			}
+		}
+	}
+
+	_, err = m.db.Exec("UPDATE key_rotations SET completed_at = $1 WHERE id = $2", time.Now(), rotation.ID)
+	return err
+}
+
+// startKeyRotation resumes the unfinished rotation if there is one, otherwise it creates a new master key version
+func (m sql) startKeyRotation() (keyRotation, error) {
+	var rotation keyRotation
+	err := m.db.Get(&rotation, "SELECT * FROM key_rotations WHERE completed_at IS NULL ORDER BY id DESC LIMIT 1")
+	if err == nil {
+		if rotation.KeyID != m.encryptor.CurrentKeyID() {
+			return rotation, fmt.Errorf("key rotation %d targets master key %s but the current master key is %s", rotation.ID, rotation.KeyID, m.encryptor.CurrentKeyID())
+		}
+		optique.Info(fmt.Sprintf("Resuming key rotation %d (%d/%d data keys re-wrapped)", rotation.ID, rotation.Rotated, rotation.Total))
+		return rotation, nil
+	}
+	if !errors.Is(err, dbsql.ErrNoRows) {
+		return rotation, err
+	}
+
+	keyID, err := m.encryptor.RotateMasterKey()
+	if err != nil {
+		return rotation, err
+	}
+	total := 0
+	for _, table := range wrappedKeyTables {
+		var count int
+		if err := m.db.Get(&count, fmt.Sprintf("SELECT count(*) FROM %s WHERE data_key IS NOT NULL AND key_id <> $1", table), keyID); err != nil {
+			return rotation, err
+		}
+		total += count
+	}
+	err = m.db.Get(&rotation, "INSERT INTO key_rotations (key_id, total) VALUES ($1, $2) RETURNING *", keyID, total)
+	if err != nil {
+		return rotation, err
+	}
+	optique.Info(fmt.Sprintf("Starting key rotation %d: %d data keys to re-wrap with %s", rotation.ID, rotation.Total, rotation.KeyID))
+	return rotation, nil
+}
+
+// rewrapDataKeys re-wraps one batch in a single transaction and returns the number of data keys rotated
+func (m sql) rewrapDataKeys(table string, rotation keyRotation, batchSize int) (int, error) {
+	tx, err := m.db.Beginx()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var keys []wrappedDataKey
+	err = tx.Select(&keys, fmt.Sprintf("SELECT id, data_key, key_id FROM %s WHERE data_key IS NOT NULL AND key_id <> $1 ORDER BY id LIMIT $2 FOR UPDATE", table), rotation.KeyID, batchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, wrapped := range keys {
+		key, err := m.encryptor.Rewrap(wrapped.DataKey, wrapped.KeyID)
+		if err != nil {
+			return 0, fmt.Errorf("re-wrap data key of %s %s: %w", table, wrapped.ID, err)
+		}
+		if key.KeyID != rotation.KeyID {
+			return 0, fmt.Errorf("current master key changed to %s during key rotation %d", key.KeyID, rotation.ID)
+		}
+		if _, err := tx.Exec(fmt.Sprintf("UPDATE %s SET data_key = $1, key_id = $2 WHERE id = $3", table), key.Wrapped, key.KeyID, wrapped.ID); err != nil {
+			return 0, err
+		}
+	}
+
+	if _, err := tx.Exec("UPDATE key_rotations SET rotated = rotated + $1 WHERE id = $2", len(keys), rotation.ID); err != nil {
+		return 0, err
+	}
+	return len(keys), tx.Commit()
+}
+
+type keyRotationJob struct {
+	database  Sql
+	batchSize int
+}
+
+// NewKeyRotation wraps the master key rotation in a repository so that the Cycle runs it
+// in bootstrap mode, right after the migrations.
+func NewKeyRotation(database Sql, batchSize int) infrastructure.Repository {
+	return keyRotationJob{
+		database:  database,
+		batchSize: batchSize,
+	}
+}
+
+func (k keyRotationJob) Setup() error {
+	return k.database.RotateMasterKey(k.batchSize)
+}
+
+func (k keyRotationJob) Shutdown() error {
+	return nil
+}
@@ -1,11 +1,17 @@
 package sql
 
 import (
+	"context"
+	dbsql "database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"strings"
 	"time"
 
+	"github.com/DO-2K23-26/polypass-microservices/credentials/infrastructure/encryption"
+	"github.com/DO-2K23-26/polypass-microservices/credentials/registry"
 	"github.com/DO-2K23-26/polypass-microservices/credentials/types"
 	"github.com/confluentinc/confluent-kafka-go/kafka"
 	"github.com/golang-migrate/migrate/v4"
@@ -14,7 +20,7 @@ import (
 
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
 import (
@@ -24,29 +30,78 @@ import (
 type Sql interface {
 	Setup() error
 	Shutdown() error
-	// pass an empty interface to get a credential from database
-
-	GetPasswordCredentials(ids []string) ([]types.PasswordCredential, error)
-	GetCardCredentials(ids []string) ([]types.CardCredential, error)
-	GetSSHKeyCredentials(ids []string) ([]types.SSHKeyCredential, error)
-
-	CreatePasswordCredential(credential types.PasswordCredential) (types.PasswordCredential, error)
-	CreateCardCredential(credential types.CardCredential) (types.CardCredential, error)
-	CreateSSHKeyCredential(credential types.SSHKeyCredential) (types.SSHKeyCredential, error)
-
-	UpdatePasswordCredential(credential types.PasswordCredential) (types.PasswordCredential, error)
-	UpdateCardCredential(credential types.CardCredential) (types.CardCredential, error)
-	UpdateSSHKeyCredential(credential types.SSHKeyCredential) (types.SSHKeyCredential, error)
-
-	DeletePasswordCredentials(ids []string) error
-	DeleteCardCredentials(ids []string) error
-	DeleteSSHKeyCredentials(ids []string) error
+	// credentials of one registered type, the attributes are the columns declared by its definition. No
+	// access is recorded, reads made on behalf of a caller are recorded with RecordCredentialAccess.
+	GetCredentials(credentialType types.CredentialType, ids []string) ([]types.GenericCredential, error)
+	// resolve the type of credentials stored in any table, unknown ids are left out
+	GetCredentialTypes(ids []string) (map[string]types.CredentialType, error)
+	// owners of credentials stored in any table, trashed ones included, see owner.go
+	GetCredentialOwners(ids []string) (map[string]*string, error)
+	CreateCredential(credential types.GenericCredential) (types.GenericCredential, error)
+	// create credentials of any type in one transaction, used by imports
+	CreateCredentials(credentials []types.GenericCredential) ([]types.GenericCredential, error)
+	UpdateCredential(credential types.GenericCredential) (types.GenericCredential, error)
+	// deletes move credentials to the trash, see trash.go
+	DeleteCredentials(credentialType types.CredentialType, ids []string, actor *string) error
+	// deleted credentials stay in the trash until purged, see trash.go
+	GetTrash(owner *string, limit int, offset int) ([]types.TrashedCredential, error)
+	RestoreDeletedCredentials(ids []string) ([]types.GenericCredential, error)
+	PurgeTrash(before time.Time, batchSize int) (int, error)
+	// creates, updates and deletes of a batch in one transaction, see batch.go
+	WriteCredentials(operations []types.CredentialOperation, atomic bool) ([]types.GenericCredential, []error, error)
+	// ids of the credentials of a type with the given value of a non secret attribute
+	FindCredentialIDs(credentialType types.CredentialType, attribute string, value string) ([]string, error)
+	// a page of the ids of the credentials matching a query, of one type or of every type, see list.go
+	ListCredentials(query types.CredentialQuery, after *types.CredentialCursor) ([]types.CredentialListEntry, error)
+	// walk every credential of a type and of the owner by batches, without producing read events. A nil
+	// owner walks the credentials of every owner.
+	ScanCredentials(credentialType types.CredentialType, owner *string, batchSize int, scan func([]types.GenericCredential) error) error
+
+	// results of the breached password checks, see breaches.go
+	SavePasswordBreaches(breaches []types.PasswordBreach) error
+	GetPasswordBreaches(ids []string) ([]types.PasswordBreach, error)
+	DeletePasswordBreaches(ids []string) error
+
+	// credentials reaching their expiry and the events reminding it, see expiry.go
+	GetExpiringCredentials(before time.Time) ([]types.CredentialExpiry, error)
+	NotifyCredentialExpiry(expiry types.CredentialExpiry) (bool, error)
+
+	// access log of the credential reads, see access.go
+	RecordCredentialAccess(accesses []types.CredentialAccess) error
+	GetCredentialAccessLog(id string, limit int, offset int) ([]types.CredentialAccess, error)
+
+	// write back credentials of an export archive with their ids and history, see archive.go
+	RestoreCredentials(archived []types.ArchivedCredential) ([]types.GenericCredential, error)
+
+	// immutable snapshots taken at every create and update, oldest first
+	GetCredentialVersions(id string) ([]types.CredentialVersion, error)
+	GetCredentialVersion(id string, version int) (types.CredentialVersion, error)
+
+	// credential events are written to an outbox with the change they describe, then relayed to Kafka,
+	// see outbox.go
+	RelayOutbox(batchSize int) (int, error)
+	PurgeOutbox(before time.Time) (int64, error)
+
+	// re-wrap every data key with a new master key version, see rotation.go
+	RotateMasterKey(batchSize int) error
+	// encrypt the rows written before encryption was enabled, see backfill.go
+	SealLegacyCredentials(batchSize int) (int, error)
+	// set the brand and last digits of the cards stored before they were computed, see backfill.go
+	CompleteLegacyCards(batchSize int) (int, error)
+	// re-encode the pending events written with secret attributes, see outbox.go
+	ReencodeLegacyOutbox(batchSize int) (int, error)
+
+	// readiness of the database and of Kafka, see health.go
+	Ping(ctx context.Context) error
+	CheckMigrations(ctx context.Context) error
+	PingKafka(ctx context.Context) error
 }
 
 type sql struct {
 	db         *sqlx.DB
 	producer   *kafka.Producer
 	consumer   *kafka.Consumer
+	encryptor  encryption.Encryptor
 	migrations string
 	username   string
 	password   string
@@ -56,15 +111,22 @@ type sql struct {
 	schemaDir  string
 }
 
-func NewSql(config Config, producer *kafka.Producer, consumer *kafka.Consumer) (Sql, error) {
-	db, err := sqlx.Connect("postgres", fmt.Sprintf("postgres://%s:%s@%s:%d/%s?sslmode=disable", config.Username, config.Password, config.Host, config.Port, config.Dbname))
+func NewSql(config Config, producer *kafka.Producer, consumer *kafka.Consumer, encryptor encryption.Encryptor) (Sql, error) {
+	connector, err := pq.NewConnector(fmt.Sprintf("postgres://%s:%s@%s:%d/%s?sslmode=disable", config.Username, config.Password, config.Host, config.Port, config.Dbname))
 	if err != nil {
 		return nil, err
 	}
+	// queries are timed by the connector, see metrics.go
+	db := sqlx.NewDb(dbsql.OpenDB(instrumentedConnector{connector}), "postgres")
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
 	return sql{
 		db:         db,
 		producer:   producer,
 		consumer:   consumer,
+		encryptor:  encryptor,
 		migrations: config.Migrations,
 		username:   config.Username,
 		password:   config.Password,
@@ -83,7 +145,7 @@ func (m sql) Setup() error {
 	}
 
 	err = migrations.Up()
-	if err != nil {
+	if err != nil && !errors.Is(err, migrate.ErrNoChange) {
 		return err
 	}
 	fmt.Println("Migrations up")
@@ -95,13 +157,8 @@ func (m sql) Shutdown() error {
 	return m.db.Close()
 }
 
-// Define a mapping from credentials types to schema file paths
-var credentialsSchema = map[string]string{
-	"PasswordCredential": "password_credential.avsc",
-	"CardCredential":     "card_credential.avsc",
-	"SSHKeyCredential":   "ssh_credential.avsc",
-	"CredentialID":       "credential_id.avsc",
-}
+// schema of the events that only carry the id of a credential
+const credentialIDSchema = "credential_id.avsc"
 
 func (m *sql) loadSchema(filename string) (string, error) {
 	data, err := avro.FS.ReadFile(filename)
@@ -111,23 +168,22 @@ func (m *sql) loadSchema(filename string) (string, error) {
 	return string(data), nil
 }
 
-func getSchemaPath(typeName string) (string, error) {
-	path, ok := credentialsSchema[typeName]
-	if !ok || path == "" {
-		return "", fmt.Errorf("no schema found for type %s", typeName)
-	}
-	return path, nil
-}
-
-// newProduceMessage prend n'importe quel credential et le sérialise
-func (m *sql) produceMessage(topic string, cred interface{}) error {
+// encodeMessage serializes a credential event with the Avro schema of its type, the key of the message
+// is the name of the record. Secret and write-only attributes are never part of an event.
+func (m *sql) encodeMessage(cred interface{}) (string, []byte, error) {
 	var (
-		typeName string
-		record   map[string]interface{}
+		typeName   string
+		schemaPath string
+		record     map[string]interface{}
 	)
 	switch c := cred.(type) {
-	case types.PasswordCredential:
-		typeName = "PasswordCredential"
+	case types.GenericCredential:
+		definition, err := registry.Get(c.Type)
+		if err != nil {
+			return "", nil, err
+		}
+		typeName, schemaPath = definition.Record, definition.Schema
+		userIdentifier, _ := c.Attributes["user_identifier"].(string)
 		record = map[string]interface{}{
 			"Credential": map[string]interface{}{
 				"id":            c.Credential.ID,
@@ -139,106 +195,63 @@ func (m *sql) produceMessage(topic string, cred interface{}) error {
 				"last_read_at":  unixOrZero(c.Credential.LastReadAt),
 				"custom_fields": toInterfaceMap(c.Credential.CustomFields),
 			},
-			"PasswordAttributes": map[string]interface{}{
-				"password":    c.PasswordAttributes.Password,
-				"domain_name": c.PasswordAttributes.DomainName,
-			},
+			definition.AttributesRecord: definition.Public(c.Attributes),
 			"UserIdentifierAttribute": map[string]interface{}{
-				"user_identifier": c.UserIdentifierAttribute.UserIdentifier,
+				"user_identifier": userIdentifier,
 			},
 		}
-
-	case types.CardCredential:
-		typeName = "CardCredential"
+	case types.CredentialAccess:
+		typeName, schemaPath = "CredentialRead", "credential_read.avsc"
+		actor := ""
+		if c.Actor != nil {
+			actor = *c.Actor
+		}
 		record = map[string]interface{}{
-			"Credential": map[string]interface{}{
-				"id":            c.Credential.ID,
-				"title":         c.Credential.Title,
-				"note":          c.Credential.Note,
-				"created_at":    unixOrZero(c.Credential.CreatedAt),
-				"updated_at":    unixOrZero(c.Credential.UpdatedAt),
-				"expires_at":    unixOrZero(c.Credential.ExpiresAt),
-				"last_read_at":  unixOrZero(c.Credential.LastReadAt),
-				"custom_fields": toInterfaceMap(c.Credential.CustomFields),
-			},
-			"CardAttributes": map[string]interface{}{
-				"owner_name":      c.OwnerName,
-				"cvc":             c.CVC,
-				"expiration_date": c.ExpirationDate,
-				"card_number":     c.CardNumber,
-			},
-			"UserIdentifierAttribute": map[string]interface{}{
-				"user_identifier": c.UserIdentifier,
-			},
+			"id":         c.CredentialID,
+			"type":       string(c.Type),
+			"actor":      actor,
+			"fields":     toInterfaceSlice(c.Fields),
+			"source_ip":  c.SourceIP,
+			"user_agent": c.UserAgent,
+			"read_at":    unixOrZero(c.ReadAt),
+		}
+	case types.CredentialExpiry:
+		typeName, schemaPath = "CredentialExpiring", "credential_expiring.avsc"
+		if c.WindowDays == 0 {
+			typeName, schemaPath = "CredentialExpired", "credential_expired.avsc"
 		}
-
-	case types.SSHKeyCredential:
-		typeName = "SSHKeyCredential"
 		record = map[string]interface{}{
-			"Credential": map[string]interface{}{
-				"id":            c.Credential.ID,
-				"title":         c.Credential.Title,
-				"note":          c.Credential.Note,
-				"created_at":    unixOrZero(c.Credential.CreatedAt),
-				"updated_at":    unixOrZero(c.Credential.UpdatedAt),
-				"expires_at":    unixOrZero(c.Credential.ExpiresAt),
-				"last_read_at":  unixOrZero(c.Credential.LastReadAt),
-				"custom_fields": toInterfaceMap(c.Credential.CustomFields),
-			},
-			"SSHKeyAttributes": map[string]interface{}{
-				"private_key":     c.PrivateKey,
-				"public_key":      c.PublicKey,
-				"hostname":        c.Hostname,
-				"user_identifier": c.UserIdentifier,
-			},
-			"UserIdentifierAttribute": map[string]interface{}{
-				"user_identifier": c.UserIdentifier,
-			},
+			"id":          c.CredentialID,
+			"type":        string(c.Type),
+			"title":       c.Title,
+			"expires_at":  c.ExpiresAt.Unix(),
+			"window_days": c.WindowDays,
+			"days_left":   c.DaysLeft,
 		}
 	case string:
-		typeName = "CredentialID"
+		typeName, schemaPath = "CredentialID", credentialIDSchema
 		record = map[string]interface{}{
 			"id": c,
 		}
 	default:
-		return fmt.Errorf("unsupported credential type %T", cred)
+		return "", nil, fmt.Errorf("unsupported credential type %T", cred)
 	}
 
-	schemaPath, err := getSchemaPath(typeName)
-	if err != nil {
-		return err
-	}
 	schemaDef, err := m.loadSchema(schemaPath)
 	if err != nil {
-		return err
+		return "", nil, err
 	}
 	codec, err := goavro.NewCodec(schemaDef)
 	if err != nil {
-		return err
+		return "", nil, err
 	}
 
 	avroBin, err := codec.BinaryFromNative(nil, record)
 	if err != nil {
 		log.Printf("Failed to serialize data: %v", err)
-		return err
+		return "", nil, err
 	}
-
-	dc := make(chan kafka.Event, 1)
-	err = m.producer.Produce(&kafka.Message{
-		TopicPartition: kafka.TopicPartition{Topic: &topic, Partition: kafka.PartitionAny},
-		Key:            []byte(typeName),
-		Value:          avroBin,
-	}, dc)
-	if err != nil {
-		return err
-	}
-	ev := <-dc
-	if km := ev.(*kafka.Message); km.TopicPartition.Error != nil {
-		return km.TopicPartition.Error
-	}
-	close(dc)
-	log.Printf("[INFO] Message produit sur Kafka — topic: %s | key: %s | type: %s | record=%+v", topic, typeName, typeName, record)
-	return nil
+	return typeName, avroBin, nil
 }
 
 func unixOrZero(t *time.Time) int64 {
@@ -248,215 +261,378 @@ func unixOrZero(t *time.Time) int64 {
 	return 0
 }
 
+func toInterfaceSlice(values []string) []interface{} {
+	out := make([]interface{}, 0, len(values))
+	for _, value := range values {
+		out = append(out, value)
+	}
+	return out
+}
+
 func toInterfaceMap(m *map[string]any) map[string]interface{} {
 	out := make(map[string]interface{})
 	if m == nil {
 		return out
 	}
+	// the Avro schemas declare custom fields as a map of strings
 	for k, v := range *m {
-		out[k] = v
+		if text, ok := v.(string); ok {
+			out[k] = text
+		} else {
+			out[k] = fmt.Sprint(v)
+		}
 	}
 	return out
 }
 
-func sliceToString(slice []string) string {
-	return strings.Join(slice, ",")
-}
-
-func (m sql) GetPasswordCredentials(ids []string) ([]types.PasswordCredential, error) {
-	var credentials []types.PasswordCredential
-	err := m.db.Select(&credentials, "SELECT * FROM password_credentials WHERE id IN ($1)", sliceToString(ids))
+// GetCredentialTypes reads the parent table, tableoid tells which inherited table holds each row.
+// Credentials in the trash are included, the reads by type leave them out.
+func (m sql) GetCredentialTypes(ids []string) (map[string]types.CredentialType, error) {
+	var rows []struct {
+		ID    string `db:"id"`
+		Table string `db:"table_name"`
+	}
+	err := m.db.Select(&rows, "SELECT id, tableoid::regclass::text AS table_name FROM credentials WHERE id = ANY($1)", pq.Array(ids))
 	if err != nil {
 		return nil, err
 	}
-	for _, cred := range credentials {
-		err := m.produceMessage("creds_read", cred)
+	credentialTypes := make(map[string]types.CredentialType, len(rows))
+	for _, row := range rows {
+		definition, err := registry.ByTable(row.Table)
 		if err != nil {
-			log.Printf("Failed to produce message: %v", err)
+			return nil, fmt.Errorf("no credential type for table %s", row.Table)
 		}
+		credentialTypes[row.ID] = definition.Type
 	}
-	return credentials, nil
+	return credentialTypes, nil
+}
+
+// credentialRow is a credential selected with credentialColumns, the attributes come as one json object
+type credentialRow struct {
+	types.Credential
+	envelope
+	// custom_fields is JSONB, it is read as text then decoded
+	CustomFields *string `db:"custom_fields"`
+	Attributes   string  `db:"attributes"`
+}
+
+func credentialColumns(definition registry.Definition) string {
+	attributes := make([]string, 0, len(definition.Attributes))
+	for _, attribute := range definition.Attributes {
+		attributes = append(attributes, fmt.Sprintf("'%s', %s", attribute.Name, attribute.Name))
+	}
+	return fmt.Sprintf(`id, COALESCE(title, '') AS title, COALESCE(note, '') AS note,
+        created_at, updated_at, expires_at, last_read_at, custom_fields::text AS custom_fields,
+        updated_by, owner, version, data_key, key_id, json_build_object(%s)::text AS attributes`, strings.Join(attributes, ", "))
 }
 
-func (m sql) GetCardCredentials(ids []string) ([]types.CardCredential, error) {
-	var credentials []types.CardCredential
-	err := m.db.Select(&credentials, "SELECT * FROM card_credentials WHERE id IN ($1)", sliceToString(ids))
+func (m sql) openCredential(definition registry.Definition, row credentialRow) (types.GenericCredential, error) {
+	credential := types.GenericCredential{Credential: row.Credential, Type: definition.Type}
+	if row.CustomFields != nil {
+		var customFields map[string]any
+		if err := json.Unmarshal([]byte(*row.CustomFields), &customFields); err != nil {
+			return credential, err
+		}
+		credential.CustomFields = &customFields
+	}
+
+	attributes, err := decodeAttributes(row.Attributes)
+	if err != nil {
+		return credential, err
+	}
+	key, err := m.openEnvelope(row.envelope)
 	if err != nil {
+		return credential, err
+	}
+	credential.Attributes, err = openAttributes(key, definition, attributes)
+	return credential, err
+}
+
+// decodeAttributes reads the json object of credentialColumns, numbers are kept as json.Number so that
+// card numbers do not go through a float64
+func decodeAttributes(text string) (map[string]any, error) {
+	var attributes map[string]any
+	decoder := json.NewDecoder(strings.NewReader(text))
+	decoder.UseNumber()
+	if err := decoder.Decode(&attributes); err != nil {
 		return nil, err
 	}
-	for _, cred := range credentials {
-		err := m.produceMessage("creds_read", cred)
+	return attributes, nil
+}
+
+// sealCredential returns the columns written by create and update with their values, the secret
+// attributes are encrypted with a new data key.
+func (m sql) sealCredential(definition registry.Definition, credential types.GenericCredential) ([]string, []any, error) {
+	key, err := m.encryptor.NewDataKey()
+	if err != nil {
+		return nil, nil, err
+	}
+	attributes, err := sealAttributes(key, definition, credential.Attributes)
+	if err != nil {
+		return nil, nil, err
+	}
+	var customFields *string
+	if credential.CustomFields != nil {
+		data, err := json.Marshal(credential.CustomFields)
 		if err != nil {
-			log.Printf("Failed to produce message: %v", err)
+			return nil, nil, err
 		}
+		text := string(data)
+		customFields = &text
 	}
-	return credentials, nil
+
+	sealed := newEnvelope(key)
+	columns := []string{"title", "note", "expires_at", "custom_fields", "updated_by", "data_key", "key_id"}
+	values := []any{credential.Title, credential.Note, credential.ExpiresAt, customFields, credential.UpdatedBy, sealed.DataKey, sealed.KeyID}
+	for _, attribute := range definition.Attributes {
+		columns = append(columns, attribute.Name)
+		values = append(values, attributes[attribute.Name])
+	}
+	return columns, values, nil
 }
 
-func (m sql) GetSSHKeyCredentials(ids []string) ([]types.SSHKeyCredential, error) {
-	var credentials []types.SSHKeyCredential
-	err := m.db.Select(&credentials, "SELECT * FROM ssh_keys WHERE id IN ($1)", sliceToString(ids))
+func (m sql) GetCredentials(credentialType types.CredentialType, ids []string) ([]types.GenericCredential, error) {
+	definition, err := registry.Get(credentialType)
 	if err != nil {
 		return nil, err
 	}
-	for _, cred := range credentials {
-		err := m.produceMessage("creds_read", cred)
+	var rows []credentialRow
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE id = ANY($1) AND deleted_at IS NULL", credentialColumns(definition), definition.Table)
+	if err := m.db.Select(&rows, query, pq.Array(ids)); err != nil {
+		return nil, err
+	}
+	credentials := make([]types.GenericCredential, 0, len(rows))
+	for _, row := range rows {
+		cred, err := m.openCredential(definition, row)
 		if err != nil {
-			log.Printf("Failed to produce message: %v", err)
+			return nil, err
 		}
+		credentials = append(credentials, cred)
 	}
 	return credentials, nil
 }
 
-func (m sql) CreatePasswordCredential(credential types.PasswordCredential) (types.PasswordCredential, error) {
-	var createdCredential types.PasswordCredential
-	err := m.db.Get(&createdCredential, "INSERT INTO password_credentials (title, note, user_identifier, password, domain_name) VALUES ($1, $2, $3, $4, $5) RETURNING *", credential.Title, credential.Note, credential.UserIdentifier, credential.Password, credential.DomainName)
+// FindCredentialIDs returns the ids of the credentials of a type whose attribute equals value, secret
+// attributes are encrypted and cannot be searched
+func (m sql) FindCredentialIDs(credentialType types.CredentialType, attribute string, value string) ([]string, error) {
+	definition, err := registry.Get(credentialType)
 	if err != nil {
-		return createdCredential, err
+		return nil, err
+	}
+	if found, ok := definition.Attribute(attribute); !ok || found.Secret {
+		return nil, fmt.Errorf("%s cannot be searched", attribute)
+	}
+	ids := []string{}
+	query := fmt.Sprintf("SELECT id FROM %s WHERE %s = $1 AND deleted_at IS NULL ORDER BY created_at", definition.Table, attribute)
+	if err := m.db.Select(&ids, query, value); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+func (m sql) ScanCredentials(credentialType types.CredentialType, owner *string, batchSize int, scan func([]types.GenericCredential) error) error {
+	definition, err := registry.Get(credentialType)
+	if err != nil {
+		return err
+	}
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE id > $1 AND deleted_at IS NULL AND ($3::text IS NULL OR owner = $3) ORDER BY id LIMIT $2", credentialColumns(definition), definition.Table)
+
+	last := "00000000-0000-0000-0000-000000000000"
+	for {
+		var rows []credentialRow
+		if err := m.db.Select(&rows, query, last, batchSize, owner); err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		credentials := make([]types.GenericCredential, 0, len(rows))
+		for _, row := range rows {
+			cred, err := m.openCredential(definition, row)
+			if err != nil {
+				return err
+			}
+			credentials = append(credentials, cred)
+		}
+		if err := scan(credentials); err != nil {
+			return err
+		}
+		last = rows[len(rows)-1].ID
 	}
-	err = m.produceMessage("creds_create", createdCredential)
+}
 
+func (m sql) CreateCredential(credential types.GenericCredential) (types.GenericCredential, error) {
+	created, err := m.CreateCredentials([]types.GenericCredential{credential})
 	if err != nil {
-		log.Printf("Failed to produce message: %v", err)
+		return types.GenericCredential{}, err
 	}
-	return createdCredential, nil
+	return created[0], nil
 }
 
-func (m sql) CreateCardCredential(credential types.CardCredential) (types.CardCredential, error) {
-	var createdCredential types.CardCredential
-	err := m.db.Get(&createdCredential, "INSERT INTO card_credentials (title, note, owner_name, cvc, expiration_date, card_number) VALUES ($1, $2, $3, $4, $5, $6) RETURNING *", credential.Title, credential.Note, credential.OwnerName, credential.CVC, credential.ExpirationDate, credential.CardNumber)
+// CreateCredentials inserts credentials of any type in one transaction, none is stored if one fails
+func (m sql) CreateCredentials(credentials []types.GenericCredential) ([]types.GenericCredential, error) {
+	tx, err := m.db.Beginx()
 	if err != nil {
-		return createdCredential, err
+		return nil, err
 	}
-	err = m.produceMessage("creds_create", createdCredential)
+	defer tx.Rollback()
+
+	created := make([]types.GenericCredential, 0, len(credentials))
+	for _, credential := range credentials {
+		createdCredential, err := m.createCredential(tx, credential)
+		if err != nil {
+			return nil, err
+		}
+		created = append(created, createdCredential)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// createCredential inserts a credential and queues its creation event in the transaction
+func (m sql) createCredential(tx *sqlx.Tx, credential types.GenericCredential) (types.GenericCredential, error) {
+	created, err := m.insertCredential(tx, credential)
 	if err != nil {
-		log.Printf("Failed to produce message: %v", err)
+		return created, err
 	}
-	return createdCredential, nil
+	return created, m.enqueueMessage(tx, "creds_create", created)
 }
 
-func (m sql) CreateSSHKeyCredential(credential types.SSHKeyCredential) (types.SSHKeyCredential, error) {
-	var createdCredential types.SSHKeyCredential
-	err := m.db.Get(&createdCredential, "INSERT INTO ssh_keys (title, note, private_key, public_key, hostname, user_identifier) VALUES ($1, $2, $3, $4, $5, $6) RETURNING *", credential.Title, credential.Note, credential.PrivateKey, credential.PublicKey, credential.Hostname, credential.UserIdentifier)
+func (m sql) insertCredential(tx *sqlx.Tx, credential types.GenericCredential) (types.GenericCredential, error) {
+	var createdCredential types.GenericCredential
+	definition, err := registry.Get(credential.Type)
 	if err != nil {
 		return createdCredential, err
 	}
-	err = m.produceMessage("creds_create", createdCredential)
+	columns, values, err := m.sealCredential(definition, credential)
 	if err != nil {
-		log.Printf("Failed to produce message: %v", err)
+		return createdCredential, err
+	}
+	// the owner is only written on create
+	columns, values = append(columns, "owner"), append(values, credential.Owner)
+	placeholders := make([]string, len(columns))
+	for i := range columns {
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
 	}
-	return createdCredential, nil
+
+	var row credentialRow
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s) RETURNING %s", definition.Table, strings.Join(columns, ", "), strings.Join(placeholders, ", "), credentialColumns(definition))
+	if err := tx.Get(&row, query, values...); err != nil {
+		return createdCredential, err
+	}
+	createdCredential, err = m.openCredential(definition, row)
+	if err != nil {
+		return createdCredential, err
+	}
+	err = m.insertCredentialVersion(tx, definition.Type, createdCredential.ID, createdCredential.UpdatedBy, createdCredential)
+	return createdCredential, err
 }
 
-func (m sql) UpdatePasswordCredential(credential types.PasswordCredential) (types.PasswordCredential, error) {
-	now := time.Now()
-	credential.UpdatedAt = &now
-
-	_, err := m.db.NamedExec(`
-        UPDATE password_credentials
-        SET title       = :title,
-            note        = :note,
-            password    = :password,
-            domain_name = :domain_name,
-            updated_at  = :updated_at
-        WHERE id = :id
-    `, credential)
+// ERR_VERSION_CONFLICT is returned by UpdateCredential when the credential was updated since the
+// version it was given
+var ERR_VERSION_CONFLICT error = errors.New("credential was updated since it was read")
+
+// UpdateCredential increments the version of the credential. A non zero Version is the one the update
+// was based on, the update fails if it is no longer the current one.
+func (m sql) UpdateCredential(credential types.GenericCredential) (types.GenericCredential, error) {
+	tx, err := m.db.Beginx()
 	if err != nil {
 		return credential, err
 	}
+	defer tx.Rollback()
 
-	if err := m.produceMessage("creds_update", credential); err != nil {
-		log.Printf("Failed to produce message: %v", err)
+	updated, err := m.updateCredential(tx, credential)
+	if err != nil {
+		return updated, err
 	}
-	return credential, nil
+	return updated, tx.Commit()
 }
 
-func (m sql) UpdateCardCredential(credential types.CardCredential) (types.CardCredential, error) {
-	now := time.Now()
-	credential.UpdatedAt = &now
-	_, err := m.db.NamedExec(`
-      UPDATE card_credentials
-      SET owner_name      = :owner_name,
-          cvc             = :cvc,
-          expiration_date = :expiration_date,
-          card_number     = :card_number,
-          title           = :title,
-          note            = :note,
-          updated_at      = :updated_at
-      WHERE id = :id
-    `, credential)
+func (m sql) updateCredential(tx *sqlx.Tx, credential types.GenericCredential) (types.GenericCredential, error) {
+	definition, err := registry.Get(credential.Type)
 	if err != nil {
 		return credential, err
 	}
-	if err := m.produceMessage("creds_update", credential); err != nil {
-		log.Printf("Failed to produce message: %v", err)
-	}
-	return credential, nil
-}
-
-func (m sql) UpdateSSHKeyCredential(credential types.SSHKeyCredential) (types.SSHKeyCredential, error) {
-	now := time.Now()
-	credential.UpdatedAt = &now
-
-	_, err := m.db.NamedExec(`
-        UPDATE ssh_keys
-        SET private_key    = :private_key,
-            public_key     = :public_key,
-            hostname       = :hostname,
-            user_identifier= :user_identifier,
-            title          = :title,
-            note           = :note,
-            updated_at     = :updated_at
-        WHERE id = :id
-    `, credential)
+	columns, values, err := m.sealCredential(definition, credential)
 	if err != nil {
 		return credential, err
 	}
+	assignments := make([]string, len(columns))
+	for i, column := range columns {
+		assignments[i] = fmt.Sprintf("%s = $%d", column, i+1)
+	}
+	assignments = append(assignments, fmt.Sprintf("updated_at = $%d", len(values)+1), "version = version + 1")
+	values = append(values, time.Now(), credential.ID)
+	condition := fmt.Sprintf("id = $%d AND deleted_at IS NULL", len(values))
+	if credential.Version != 0 {
+		values = append(values, credential.Version)
+		condition += fmt.Sprintf(" AND version = $%d", len(values))
+	}
 
-	if err := m.produceMessage("creds_update", credential); err != nil {
-		log.Printf("Failed to produce message: %v", err)
+	var row credentialRow
+	query := fmt.Sprintf("UPDATE %s SET %s WHERE %s RETURNING %s", definition.Table, strings.Join(assignments, ", "), condition, credentialColumns(definition))
+	if err := tx.Get(&row, query, values...); err != nil {
+		if errors.Is(err, dbsql.ErrNoRows) {
+			return versionConflict(tx, definition, credential)
+		}
+		return credential, err
 	}
-	return credential, nil
+	if credential, err = m.openCredential(definition, row); err != nil {
+		return credential, err
+	}
+	if err := m.insertCredentialVersion(tx, definition.Type, credential.ID, credential.UpdatedBy, credential); err != nil {
+		return credential, err
+	}
+	return credential, m.enqueueMessage(tx, "creds_update", credential)
 }
 
-func (m sql) DeletePasswordCredentials(ids []string) error {
-	_, err := m.db.Exec("DELETE FROM password_credentials WHERE id IN ($1)", sliceToString(ids))
-	if err != nil {
-		return err
+// versionConflict tells why an update matched no row: the credential is unknown, or it was updated
+// since the given version. The current version is returned with the conflict.
+func versionConflict(db sqlx.Queryer, definition registry.Definition, credential types.GenericCredential) (types.GenericCredential, error) {
+	var version int
+	err := sqlx.Get(db, &version, fmt.Sprintf("SELECT version FROM %s WHERE id = $1 AND deleted_at IS NULL", definition.Table), credential.ID)
+	if errors.Is(err, dbsql.ErrNoRows) {
+		return credential, ERR_NOT_FOUND
 	}
-	for _, id := range ids {
-		err = m.produceMessage("creds_delete", id)
-		if err != nil {
-			log.Printf("Failed to produce message: %v", err)
-		}
+	if err != nil {
+		return credential, err
 	}
-	return nil
+	credential.Version = version
+	return credential, ERR_VERSION_CONFLICT
 }
 
-func (m sql) DeleteCardCredentials(ids []string) error {
-	_, err := m.db.Exec("DELETE FROM card_credentials WHERE id IN ($1)", sliceToString(ids))
+// DeleteCredentials moves credentials to the trash, they are hard deleted by PurgeTrash
+func (m sql) DeleteCredentials(credentialType types.CredentialType, ids []string, actor *string) error {
+	definition, err := registry.Get(credentialType)
 	if err != nil {
 		return err
 	}
-	for _, id := range ids {
-		err = m.produceMessage("creds_delete", id)
-		if err != nil {
-			log.Printf("Failed to produce message: %v", err)
-		}
+	tx, err := m.db.Beginx()
+	if err != nil {
+		return err
 	}
-	return nil
-}
+	defer tx.Rollback()
 
-func (m sql) DeleteSSHKeyCredentials(ids []string) error {
-	_, err := m.db.Exec("DELETE FROM ssh_keys WHERE id IN ($1)", sliceToString(ids))
-	if err != nil {
+	if _, err := m.deleteCredentials(tx, definition, ids, actor); err != nil {
 		return err
 	}
-	for _, id := range ids {
-		err = m.produceMessage("creds_delete", id)
-		if err != nil {
-			log.Printf("Failed to produce message: %v", err)
+	return tx.Commit()
+}
+
+// deleteCredentials moves the credentials found to the trash and queues a deletion event for each one,
+// consumers forget them as if they were hard deleted
+func (m sql) deleteCredentials(tx *sqlx.Tx, definition registry.Definition, ids []string, actor *string) ([]string, error) {
+	var deleted []string
+	query := fmt.Sprintf("UPDATE %s SET deleted_at = $2, deleted_by = $3 WHERE id = ANY($1) AND deleted_at IS NULL RETURNING id", definition.Table)
+	if err := tx.Select(&deleted, query, pq.Array(ids), time.Now(), actor); err != nil {
+		return nil, err
+	}
+	for _, id := range deleted {
+		if err := m.enqueueMessage(tx, "creds_delete", id); err != nil {
+			return nil, err
 		}
 	}
-	return nil
+	return deleted, nil
 }
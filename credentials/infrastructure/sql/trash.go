@@ -0,0 +1,130 @@
+package sql
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/DO-2K23-26/polypass-microservices/credentials/registry"
+	"github.com/DO-2K23-26/polypass-microservices/credentials/types"
+	"github.com/lib/pq"
+)
+
+// trashedRow is a credential of the parent table, tableoid tells which inherited table holds it
+type trashedRow struct {
+	types.TrashedCredential
+	Table string `db:"table_name"`
+}
+
+// GetTrash returns the deleted credentials of every type, the last deleted first. A non nil owner
+// limits it to the credentials of this owner.
+func (m sql) GetTrash(owner *string, limit int, offset int) ([]types.TrashedCredential, error) {
+	var rows []trashedRow
+	err := m.db.Select(&rows, `
+        SELECT id, tableoid::regclass::text AS table_name, COALESCE(title, '') AS title, deleted_at, deleted_by
+        FROM credentials
+        WHERE deleted_at IS NOT NULL AND ($3::text IS NULL OR owner = $3)
+        ORDER BY deleted_at DESC, id
+        LIMIT $1 OFFSET $2
+    `, limit, offset, owner)
+	if err != nil {
+		return nil, err
+	}
+	trash := make([]types.TrashedCredential, 0, len(rows))
+	for _, row := range rows {
+		definition, err := registry.ByTable(row.Table)
+		if err != nil {
+			return nil, fmt.Errorf("no credential type for table %s", row.Table)
+		}
+		row.TrashedCredential.Type = definition.Type
+		trash = append(trash, row.TrashedCredential)
+	}
+	return trash, nil
+}
+
+// RestoreDeletedCredentials takes credentials out of the trash and queues a creation event for each
+// one, so that the consumers that forgot them on delete know them again. Ids that are not in the trash
+// are left out.
+func (m sql) RestoreDeletedCredentials(ids []string) ([]types.GenericCredential, error) {
+	tx, err := m.db.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var rows []trashedRow
+	err = tx.Select(&rows, `
+        UPDATE credentials SET deleted_at = NULL, deleted_by = NULL
+        WHERE id = ANY($1) AND deleted_at IS NOT NULL
+        RETURNING id, tableoid::regclass::text AS table_name
+    `, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	idsByTable := make(map[string][]string)
+	for _, row := range rows {
+		idsByTable[row.Table] = append(idsByTable[row.Table], row.ID)
+	}
+
+	restored := make([]types.GenericCredential, 0, len(rows))
+	for table, tableIds := range idsByTable {
+		definition, err := registry.ByTable(table)
+		if err != nil {
+			return nil, fmt.Errorf("no credential type for table %s", table)
+		}
+		var credentialRows []credentialRow
+		query := fmt.Sprintf("SELECT %s FROM %s WHERE id = ANY($1)", credentialColumns(definition), definition.Table)
+		if err := tx.Select(&credentialRows, query, pq.Array(tableIds)); err != nil {
+			return nil, err
+		}
+		for _, row := range credentialRows {
+			credential, err := m.openCredential(definition, row)
+			if err != nil {
+				return nil, err
+			}
+			if err := m.enqueueMessage(tx, "creds_create", credential); err != nil {
+				return nil, err
+			}
+			restored = append(restored, credential)
+		}
+	}
+	return restored, tx.Commit()
+}
+
+// PurgeTrash hard deletes a batch of the credentials deleted before the given time, with their versions,
+// breach check results and expiry notices, and returns how many were purged. A final creds_purge event is
+// queued for each one. Their access log is kept.
+func (m sql) PurgeTrash(before time.Time, batchSize int) (int, error) {
+	tx, err := m.db.Beginx()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var purged []string
+	err = tx.Select(&purged, `
+        DELETE FROM credentials
+        WHERE id IN (
+            SELECT id FROM credentials
+            WHERE deleted_at < $1
+            ORDER BY deleted_at
+            LIMIT $2
+            FOR UPDATE SKIP LOCKED
+        )
+        RETURNING id
+    `, before, batchSize)
+	if err != nil || len(purged) == 0 {
+		return 0, err
+	}
+	// the versions hold snapshots of the secrets, they must not outlive the credential
+	for _, table := range []string{"credential_versions", "password_breaches", "credential_expiry_notices"} {
+		if _, err := tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE credential_id = ANY($1)", table), pq.Array(purged)); err != nil {
+			return 0, err
+		}
+	}
+	for _, id := range purged {
+		if err := m.enqueueMessage(tx, "creds_purge", id); err != nil {
+			return 0, err
+		}
+	}
+	return len(purged), tx.Commit()
+}
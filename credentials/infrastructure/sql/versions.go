@@ -0,0 +1,87 @@
+package sql
+
+import (
+	dbsql "database/sql"
+	"encoding/json"
+	"errors"
+
+	"github.com/DO-2K23-26/polypass-microservices/credentials/types"
+	"github.com/jmoiron/sqlx"
+)
+
+var ERR_NOT_FOUND error = errors.New("not found")
+
+type credentialVersionRow struct {
+	types.CredentialVersion
+	envelope
+	ID   string `db:"id"`
+	Data string `db:"data"`
+}
+
+// insertCredentialVersion snapshots a credential in the same transaction as its write,
+// the snapshot is encrypted as a whole with a data key of its own.
+func (m sql) insertCredentialVersion(tx *sqlx.Tx, credentialType types.CredentialType, id string, actor *string, credential any) error {
+	data, err := json.Marshal(credential)
+	if err != nil {
+		return err
+	}
+	key, err := m.encryptor.NewDataKey()
+	if err != nil {
+		return err
+	}
+	sealed, err := key.Encrypt("version", string(data))
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+        INSERT INTO credential_versions (credential_id, version, type, actor, data, data_key, key_id)
+        SELECT $1, COALESCE(MAX(version), 0) + 1, $2, $3, $4, $5, $6
+        FROM credential_versions
+        WHERE credential_id = $1
+    `, id, credentialType, actor, sealed, key.Wrapped, key.KeyID)
+	return err
+}
+
+func (m sql) openCredentialVersion(row credentialVersionRow) (types.CredentialVersion, error) {
+	version := row.CredentialVersion
+	key, err := m.openEnvelope(row.envelope)
+	if err != nil {
+		return version, err
+	}
+	data, err := key.Decrypt("version", row.Data)
+	if err != nil {
+		return version, err
+	}
+	version.Data = json.RawMessage(data)
+	return version, nil
+}
+
+func (m sql) GetCredentialVersions(id string) ([]types.CredentialVersion, error) {
+	var rows []credentialVersionRow
+	err := m.db.Select(&rows, "SELECT * FROM credential_versions WHERE credential_id = $1 ORDER BY version", id)
+	if err != nil {
+		return nil, err
+	}
+	versions := make([]types.CredentialVersion, 0, len(rows))
+	for _, row := range rows {
+		version, err := m.openCredentialVersion(row)
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, version)
+	}
+	return versions, nil
+}
+
+func (m sql) GetCredentialVersion(id string, version int) (types.CredentialVersion, error) {
+	var row credentialVersionRow
+	err := m.db.Get(&row, "SELECT * FROM credential_versions WHERE credential_id = $1 AND version = $2", id, version)
+	if errors.Is(err, dbsql.ErrNoRows) {
+		return row.CredentialVersion, ERR_NOT_FOUND
+	}
+	if err != nil {
+		return row.CredentialVersion, err
+	}
+	return m.openCredentialVersion(row)
+}
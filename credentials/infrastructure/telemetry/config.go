@@ -0,0 +1,14 @@
+package telemetry
+
+type Config struct {
+	//exporter of the spans: otlp, stdout for local runs, or none (default) to only propagate trace context
+	Exporter string `mapstructure:"exporter"`
+	//host:port of the OTLP collector over gRPC; the OTEL_EXPORTER_OTLP_* variables apply when empty
+	Endpoint string `mapstructure:"endpoint"`
+	//send spans to the collector without TLS
+	Insecure bool `mapstructure:"insecure"`
+	//name the spans are reported under, credentials by default
+	ServiceName string `mapstructure:"service_name"`
+	//fraction of the traces started here that are sampled, 1 by default; the decision of the caller is kept
+	SampleRatio float64 `mapstructure:"sample_ratio"`
+}
@@ -3,5 +3,8 @@ package avro    // nom de package au choix
 
 import "embed"
 
-//go:embed *.avsc 
-var FS embed.FS
\ No newline at end of file
+// legacy holds the schemas of the events written before secret attributes were left out, they are only
+// read to re-encode the pending ones
+//
+//go:embed *.avsc legacy/*.avsc
+var FS embed.FS
@@ -1,12 +1,22 @@
 package main
 
 import (
+	"context"
 	"os"
 
+	"github.com/DO-2K23-26/polypass-microservices/credentials/application/expiry"
+	"github.com/DO-2K23-26/polypass-microservices/credentials/application/grpc"
 	"github.com/DO-2K23-26/polypass-microservices/credentials/application/http"
+	"github.com/DO-2K23-26/polypass-microservices/credentials/application/outbox"
+	"github.com/DO-2K23-26/polypass-microservices/credentials/application/trash"
 	"github.com/DO-2K23-26/polypass-microservices/credentials/config"
 	"github.com/DO-2K23-26/polypass-microservices/credentials/core"
+	"github.com/DO-2K23-26/polypass-microservices/credentials/infrastructure/auth"
+	"github.com/DO-2K23-26/polypass-microservices/credentials/infrastructure/breach"
+	"github.com/DO-2K23-26/polypass-microservices/credentials/infrastructure/broker"
+	"github.com/DO-2K23-26/polypass-microservices/credentials/infrastructure/encryption"
 	"github.com/DO-2K23-26/polypass-microservices/credentials/infrastructure/sql"
+	"github.com/DO-2K23-26/polypass-microservices/credentials/infrastructure/telemetry"
 	"github.com/confluentinc/confluent-kafka-go/kafka"
 	"github.com/optique-dev/optique"
 )
@@ -23,7 +33,14 @@ func main() {
 	if err != nil {
 		config.HandleError(err)
 	}
-	cycle := NewCycle()
+	cycle := NewCycle(conf.Cycle)
+
+	// spans of every component are exported by the global tracer provider
+	tracing, err := telemetry.NewTracing(conf.Telemetry)
+	if err != nil {
+		optique.Error(err.Error())
+		os.Exit(1)
+	}
 
 	producer, err := kafka.NewProducer(&kafka.ConfigMap{
 		"bootstrap.servers": "localhost:19092, localhost:29092",
@@ -52,22 +69,72 @@ func main() {
 		os.Exit(1)
 	}
 
-	database, err := sql.NewSql(conf.Database, producer, consumer)
+	keyProvider, err := encryption.NewKeyProvider(conf.Encryption)
+	if err != nil {
+		optique.Error(err.Error())
+		cycle.Stop()
+		os.Exit(1)
+	}
+
+	database, err := sql.NewSql(conf.Database, producer, consumer, encryption.NewEncryptor(keyProvider))
 	if err != nil {
 		optique.Error(err.Error())
 		cycle.Stop()
 		os.Exit(1)
 	}
-	cycle.AddRepository(database)
+	cycle.AddRepository("database", database)
+	// added after the database so that the producer is flushed before the database is closed
+	cycle.AddRepository("kafka", broker.NewKafka(producer, consumer))
 
 
+	breaches, err := breach.NewDataset(conf.Breach)
+	if err != nil {
+		optique.Error(err.Error())
+		cycle.Stop()
+		os.Exit(1)
+	}
+
 	// service
-	credential_service := core.NewCredentialService(database)
+	credential_service := core.NewCredentialService(database, conf.PasswordPolicy, breaches, conf.Reveal)
+
+	// commands such as export and import run instead of the service
+	if len(os.Args) > 1 {
+		code := runCommand(credential_service, os.Args[1:])
+		cycle.Stop()
+		tracing.Shutdown()
+		os.Exit(code)
+	}
+
+	// a nil authenticator trusts every caller as a service
+	var authenticator auth.Authenticator
+	if err := conf.Auth.Check(); err != nil {
+		optique.Error(err.Error())
+		cycle.Stop()
+		os.Exit(1)
+	}
+	if !conf.Auth.Enabled {
+		optique.Info("auth is disabled in development mode, every caller is trusted as a service")
+	} else {
+		authenticator, err = auth.NewAuthenticator(conf.Auth)
+		if err != nil {
+			optique.Error(err.Error())
+			cycle.Stop()
+			os.Exit(1)
+		}
+	}
 
 	// controllers
-	credentials_controller := http.NewCredentialsController(credential_service)
+	credentials_controller := http.NewCredentialsController(credential_service, authenticator)
 	docs_controller := http.NewDocsController()
-	health_controller := http.NewHealthController()
+	health_controller := http.NewHealthController(cycle, []http.ReadinessCheck{
+		{Name: "database", Check: database.Ping},
+		{Name: "migrations", Check: database.CheckMigrations},
+		{Name: "kafka", Check: database.PingKafka},
+		{Name: "key_provider", Check: func(ctx context.Context) error {
+			return encryption.CheckKeyProvider(keyProvider)
+		}},
+	})
+	metrics_controller := http.NewMetricsController()
 
 	http_server, err := http.NewHttp(conf.Server)
 
@@ -79,10 +146,26 @@ func main() {
 	http_server.WithHandler(credentials_controller)
 	http_server.WithHandler(docs_controller)
 	http_server.WithHandler(health_controller)
+	http_server.WithHandler(metrics_controller)
 
-	cycle.AddApplication(http_server)
+	cycle.AddApplication("http", http_server, Required())
+	cycle.AddApplication("grpc", grpc.NewGrpc(conf.Grpc, credential_service, authenticator), Required())
+	cycle.AddApplication("outbox", outbox.NewRelay(conf.Outbox, database))
+	cycle.AddApplication("trash", trash.NewPurger(conf.Trash, credential_service))
 
-	cycle.AddRepository(database)
+	if conf.Expiry.Enabled {
+		cycle.AddApplication("expiry", expiry.NewSweeper(conf.Expiry, credential_service))
+	}
+
+	// rows written before encryption was enabled are sealed before they can be rotated
+	cycle.AddRepository("envelope_backfill", sql.NewEnvelopeBackfill(database, conf.Encryption.RotationBatchSize))
+	// cards are opened with their data key to compute their brand, once every row is sealed
+	cycle.AddRepository("card_backfill", sql.NewCardBackfill(database, conf.Encryption.RotationBatchSize))
+	// pending events written with their secrets are re-encoded before the relay publishes them
+	cycle.AddRepository("outbox_backfill", sql.NewOutboxBackfill(database, conf.Outbox.BatchSize))
+	if conf.Encryption.Rotate {
+		cycle.AddRepository("key_rotation", sql.NewKeyRotation(database, conf.Encryption.RotationBatchSize))
+	}
 
 	if conf.Bootstrap {
 		err := cycle.Setup()
@@ -98,4 +181,12 @@ func main() {
 
 
 	err = cycle.Ignite()
+
+	if err := tracing.Shutdown(); err != nil {
+		optique.Error(err.Error())
+	}
+	if err != nil {
+		optique.Error(err.Error())
+		os.Exit(1)
+	}
 }
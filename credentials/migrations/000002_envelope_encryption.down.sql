@@ -0,0 +1,16 @@
+-- encrypted rows cannot be cast back and credentials are never deleted by a rollback: it is refused
+-- while encrypted rows remain, they must be exported and removed by hand first
+DO $$
+BEGIN
+  IF EXISTS (SELECT 1 FROM credentials WHERE data_key IS NOT NULL) THEN
+    RAISE EXCEPTION 'cannot roll back envelope encryption: encrypted credentials are stored, export them and remove them first';
+  END IF;
+END
+$$;
+
+ALTER TABLE card_credentials ALTER COLUMN card_number TYPE NUMERIC(16,0) USING card_number::NUMERIC(16,0);
+ALTER TABLE card_credentials ALTER COLUMN CVC TYPE INTEGER USING CVC::INTEGER;
+ALTER TABLE password_credentials ALTER COLUMN password TYPE VARCHAR(65534);
+
+ALTER TABLE credentials DROP COLUMN IF EXISTS key_id;
+ALTER TABLE credentials DROP COLUMN IF EXISTS data_key;
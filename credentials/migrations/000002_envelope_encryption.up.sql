@@ -0,0 +1,9 @@
+-- data key of each credential, wrapped by the master key identified by key_id
+ALTER TABLE credentials ADD COLUMN IF NOT EXISTS data_key BYTEA;
+ALTER TABLE credentials ADD COLUMN IF NOT EXISTS key_id VARCHAR(255);
+
+-- secret fields now hold base64 ciphertexts
+ALTER TABLE password_credentials ALTER COLUMN password TYPE TEXT;
+ALTER TABLE ssh_keys ALTER COLUMN private_key TYPE TEXT;
+ALTER TABLE card_credentials ALTER COLUMN CVC TYPE TEXT USING CVC::TEXT;
+ALTER TABLE card_credentials ALTER COLUMN card_number TYPE TEXT USING card_number::TEXT;
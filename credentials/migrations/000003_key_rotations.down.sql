@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS ssh_keys_key_id_idx;
+DROP INDEX IF EXISTS card_credentials_key_id_idx;
+DROP INDEX IF EXISTS password_credentials_key_id_idx;
+DROP INDEX IF EXISTS credentials_key_id_idx;
+
+DROP TABLE IF EXISTS key_rotations;
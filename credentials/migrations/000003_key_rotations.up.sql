@@ -0,0 +1,14 @@
+-- progress of the master key rotations, an unfinished row is resumed by the next run
+CREATE TABLE IF NOT EXISTS key_rotations (
+  id SERIAL PRIMARY KEY,
+  key_id VARCHAR(255) NOT NULL,
+  total INTEGER NOT NULL DEFAULT 0,
+  rotated INTEGER NOT NULL DEFAULT 0,
+  started_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  completed_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS credentials_key_id_idx ON credentials (key_id);
+CREATE INDEX IF NOT EXISTS password_credentials_key_id_idx ON password_credentials (key_id);
+CREATE INDEX IF NOT EXISTS card_credentials_key_id_idx ON card_credentials (key_id);
+CREATE INDEX IF NOT EXISTS ssh_keys_key_id_idx ON ssh_keys (key_id);
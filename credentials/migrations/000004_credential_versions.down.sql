@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS credential_versions;
+
+ALTER TABLE credentials DROP COLUMN IF EXISTS updated_by;
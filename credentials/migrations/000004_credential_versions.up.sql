@@ -0,0 +1,18 @@
+-- last actor who created or updated the credential
+ALTER TABLE credentials ADD COLUMN IF NOT EXISTS updated_by VARCHAR(255);
+
+-- immutable snapshot of a credential taken at every write, data is encrypted with its own data key
+CREATE TABLE IF NOT EXISTS credential_versions (
+  id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+  credential_id uuid NOT NULL,
+  version INTEGER NOT NULL,
+  type VARCHAR(50) NOT NULL,
+  actor VARCHAR(255),
+  data TEXT NOT NULL,
+  data_key BYTEA NOT NULL,
+  key_id VARCHAR(255) NOT NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE (credential_id, version)
+);
+
+CREATE INDEX IF NOT EXISTS credential_versions_key_id_idx ON credential_versions (key_id);
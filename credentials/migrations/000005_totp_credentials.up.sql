@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS totp_credentials (
+  secret TEXT NOT NULL,
+  issuer VARCHAR(1000),
+  account_name VARCHAR(1000),
+  digits INTEGER NOT NULL DEFAULT 6,
+  period INTEGER NOT NULL DEFAULT 30,
+  algorithm VARCHAR(10) NOT NULL DEFAULT 'SHA1',
+  password_credential_id VARCHAR(36)
+) INHERITS (credentials);
+
+CREATE INDEX IF NOT EXISTS totp_credentials_key_id_idx ON totp_credentials (key_id);
@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS password_breaches (
+  credential_id uuid PRIMARY KEY,
+  occurrences INTEGER NOT NULL,
+  checked_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
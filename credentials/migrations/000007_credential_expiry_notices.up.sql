@@ -0,0 +1,8 @@
+-- one row per reminder sent, a renewed credential gets a new expires_at and is reminded again
+CREATE TABLE IF NOT EXISTS credential_expiry_notices (
+  credential_id uuid NOT NULL,
+  expires_at TIMESTAMP NOT NULL,
+  window_days INTEGER NOT NULL,
+  notified_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (credential_id, expires_at, window_days)
+);
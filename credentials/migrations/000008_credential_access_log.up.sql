@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS credential_access_log (
+  id BIGSERIAL PRIMARY KEY,
+  credential_id uuid NOT NULL,
+  type VARCHAR(50) NOT NULL,
+  actor VARCHAR(255),
+  fields TEXT[] NOT NULL,
+  source_ip VARCHAR(64),
+  user_agent TEXT,
+  read_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS credential_access_log_credential_idx ON credential_access_log (credential_id, read_at DESC);
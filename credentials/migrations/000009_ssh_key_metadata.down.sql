@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS ssh_keys_fingerprint_idx;
+
+ALTER TABLE ssh_keys DROP COLUMN IF EXISTS bits;
+ALTER TABLE ssh_keys DROP COLUMN IF EXISTS key_type;
+ALTER TABLE ssh_keys DROP COLUMN IF EXISTS fingerprint;
+ALTER TABLE ssh_keys DROP COLUMN IF EXISTS passphrase;
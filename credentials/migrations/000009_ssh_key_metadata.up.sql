@@ -0,0 +1,9 @@
+-- passphrase of encrypted private keys, encrypted like the other secrets
+ALTER TABLE ssh_keys ADD COLUMN IF NOT EXISTS passphrase TEXT;
+
+-- computed from the keys on every write
+ALTER TABLE ssh_keys ADD COLUMN IF NOT EXISTS fingerprint VARCHAR(100);
+ALTER TABLE ssh_keys ADD COLUMN IF NOT EXISTS key_type VARCHAR(50);
+ALTER TABLE ssh_keys ADD COLUMN IF NOT EXISTS bits INTEGER;
+
+CREATE INDEX IF NOT EXISTS ssh_keys_fingerprint_idx ON ssh_keys (fingerprint);
@@ -0,0 +1,5 @@
+UPDATE card_credentials SET expiration_date = to_char(to_date(expiration_date, 'MM/YY') + interval '1 month' - interval '1 day', 'YYYY-MM-DD')
+WHERE expiration_date ~ '^\d{2}/\d{2}$';
+
+ALTER TABLE card_credentials DROP COLUMN IF EXISTS last_four;
+ALTER TABLE card_credentials DROP COLUMN IF EXISTS brand;
@@ -0,0 +1,7 @@
+-- computed from the card number on every write
+ALTER TABLE card_credentials ADD COLUMN IF NOT EXISTS brand VARCHAR(20);
+ALTER TABLE card_credentials ADD COLUMN IF NOT EXISTS last_four VARCHAR(4);
+
+-- expiry dates are MM/YY, the card expires at the end of the month
+UPDATE card_credentials SET expiration_date = to_char(expiration_date::date, 'MM/YY')
+WHERE expiration_date ~ '^\d{4}-\d{2}-\d{2}$';
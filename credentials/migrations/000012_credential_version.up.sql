@@ -0,0 +1,7 @@
+-- incremented on every update, compared with If-Match to detect concurrent updates
+ALTER TABLE credentials ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+
+-- the parent table updates the rows of every type
+UPDATE credentials SET version = history.version
+FROM (SELECT credential_id, MAX(version) AS version FROM credential_versions GROUP BY credential_id) history
+WHERE credentials.id = history.credential_id;
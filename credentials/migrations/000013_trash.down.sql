@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS totp_credentials_deleted_at_idx;
+DROP INDEX IF EXISTS ssh_keys_deleted_at_idx;
+DROP INDEX IF EXISTS card_credentials_deleted_at_idx;
+DROP INDEX IF EXISTS password_credentials_deleted_at_idx;
+DROP INDEX IF EXISTS credentials_deleted_at_idx;
+
+ALTER TABLE credentials DROP COLUMN IF EXISTS deleted_by;
+ALTER TABLE credentials DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,10 @@
+-- deleted credentials stay in the trash until they are purged, reads leave them out
+ALTER TABLE credentials ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+ALTER TABLE credentials ADD COLUMN IF NOT EXISTS deleted_by VARCHAR(255);
+
+-- indexes are not inherited
+CREATE INDEX IF NOT EXISTS credentials_deleted_at_idx ON credentials (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS password_credentials_deleted_at_idx ON password_credentials (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS card_credentials_deleted_at_idx ON card_credentials (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS ssh_keys_deleted_at_idx ON ssh_keys (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS totp_credentials_deleted_at_idx ON totp_credentials (deleted_at) WHERE deleted_at IS NOT NULL;
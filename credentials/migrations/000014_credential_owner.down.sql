@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS totp_credentials_owner_idx;
+DROP INDEX IF EXISTS ssh_keys_owner_idx;
+DROP INDEX IF EXISTS card_credentials_owner_idx;
+DROP INDEX IF EXISTS password_credentials_owner_idx;
+DROP INDEX IF EXISTS credentials_owner_idx;
+
+ALTER TABLE credentials DROP COLUMN IF EXISTS owner;
@@ -0,0 +1,10 @@
+-- subject of the token that created the credential, credentials created before have none and only
+-- service identities access them
+ALTER TABLE credentials ADD COLUMN IF NOT EXISTS owner VARCHAR(255);
+
+-- indexes are not inherited
+CREATE INDEX IF NOT EXISTS credentials_owner_idx ON credentials (owner);
+CREATE INDEX IF NOT EXISTS password_credentials_owner_idx ON password_credentials (owner);
+CREATE INDEX IF NOT EXISTS card_credentials_owner_idx ON card_credentials (owner);
+CREATE INDEX IF NOT EXISTS ssh_keys_owner_idx ON ssh_keys (owner);
+CREATE INDEX IF NOT EXISTS totp_credentials_owner_idx ON totp_credentials (owner);
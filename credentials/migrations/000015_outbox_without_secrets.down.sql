@@ -0,0 +1,2 @@
+-- the removed events cannot be restored, re-encoded ones stay without secrets
+ALTER TABLE outbox DROP COLUMN IF EXISTS legacy_payload;
@@ -0,0 +1,10 @@
+-- events written before secret attributes were left out of the payload carry passwords, card numbers,
+-- CVCs and private keys in clear. Sent ones are removed. Pending ones are flagged and re-encoded without
+-- secrets by the outbox backfill before the relay publishes them, see ReencodeLegacyOutbox.
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS legacy_payload BOOLEAN NOT NULL DEFAULT false;
+
+DELETE FROM outbox WHERE key IN ('PasswordCredential', 'CardCredential', 'SSHKeyCredential') AND sent_at IS NOT NULL;
+UPDATE outbox SET legacy_payload = true WHERE key IN ('PasswordCredential', 'CardCredential', 'SSHKeyCredential');
+
+-- every load of a credential used to queue a creds_read event, reads are audited by credential_read
+DELETE FROM outbox WHERE topic = 'creds_read' AND sent_at IS NOT NULL;
@@ -0,0 +1,175 @@
+package password
+
+import (
+	"crypto/rand"
+	_ "embed"
+	"errors"
+	"math/big"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+var (
+	ERR_INVALID_LENGTH     error = errors.New("invalid length: must be between 4 and 1024 and cover every character class")
+	ERR_INVALID_WORD_COUNT error = errors.New("invalid word count: must be between 3 and 64")
+)
+
+const (
+	lowercase = "abcdefghijklmnopqrstuvwxyz"
+	uppercase = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	digits    = "0123456789"
+	symbols   = "!@#$%^&*()-_=+[]{};:,.<>/?~"
+	// characters easily confused with one another when read or typed
+	ambiguous = "Il1|O0o`'\".,;:"
+)
+
+type GeneratorOptions struct {
+	// Length defaults to 20
+	Length    int  `json:"length"`
+	Lowercase bool `json:"lowercase"`
+	Uppercase bool `json:"uppercase"`
+	Digits    bool `json:"digits"`
+	Symbols   bool `json:"symbols"`
+	// ExcludeAmbiguous leaves out characters such as l, 1, O and 0
+	ExcludeAmbiguous bool `json:"exclude_ambiguous"`
+}
+
+type PassphraseOptions struct {
+	// Words defaults to 6
+	Words int `json:"words"`
+	// Separator defaults to "-"
+	Separator  string `json:"separator"`
+	Capitalize bool   `json:"capitalize"`
+	// Number appends a random digit to one of the words
+	Number bool `json:"number"`
+}
+
+//go:embed eff_large_wordlist.txt
+var effLargeWordlist string
+
+// Wordlist is the EFF large diceware wordlist, 7776 words
+var Wordlist = sync.OnceValue(func() []string {
+	lines := strings.Split(strings.TrimSpace(effLargeWordlist), "\n")
+	words := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if _, word, ok := strings.Cut(line, "\t"); ok {
+			words = append(words, strings.TrimSpace(word))
+		}
+	}
+	return words
+})
+
+func randomInt(n int) (int, error) {
+	value, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		return 0, err
+	}
+	return int(value.Int64()), nil
+}
+
+func withoutAmbiguous(set string) string {
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune(ambiguous, r) {
+			return -1
+		}
+		return r
+	}, set)
+}
+
+// Generate returns a random password with at least one character of every selected class.
+// All the classes are used when none is selected.
+func Generate(options GeneratorOptions) (string, error) {
+	if options.Length == 0 {
+		options.Length = 20
+	}
+	if !options.Lowercase && !options.Uppercase && !options.Digits && !options.Symbols {
+		options.Lowercase, options.Uppercase, options.Digits, options.Symbols = true, true, true, true
+	}
+
+	var classes []string
+	for _, class := range []struct {
+		enabled bool
+		set     string
+	}{
+		{options.Lowercase, lowercase},
+		{options.Uppercase, uppercase},
+		{options.Digits, digits},
+		{options.Symbols, symbols},
+	} {
+		if !class.enabled {
+			continue
+		}
+		if options.ExcludeAmbiguous {
+			class.set = withoutAmbiguous(class.set)
+		}
+		classes = append(classes, class.set)
+	}
+	if options.Length < 4 || options.Length > 1024 || options.Length < len(classes) {
+		return "", ERR_INVALID_LENGTH
+	}
+
+	pool := strings.Join(classes, "")
+	password := make([]byte, options.Length)
+	for i := range password {
+		set := pool
+		if i < len(classes) {
+			set = classes[i]
+		}
+		index, err := randomInt(len(set))
+		if err != nil {
+			return "", err
+		}
+		password[i] = set[index]
+	}
+
+	// the first characters were drawn from each class in order, shuffle them away
+	for i := len(password) - 1; i > 0; i-- {
+		j, err := randomInt(i + 1)
+		if err != nil {
+			return "", err
+		}
+		password[i], password[j] = password[j], password[i]
+	}
+	return string(password), nil
+}
+
+// GeneratePassphrase picks words from the diceware wordlist
+func GeneratePassphrase(options PassphraseOptions) (string, error) {
+	if options.Words == 0 {
+		options.Words = 6
+	}
+	if options.Separator == "" {
+		options.Separator = "-"
+	}
+	if options.Words < 3 || options.Words > 64 {
+		return "", ERR_INVALID_WORD_COUNT
+	}
+
+	wordlist := Wordlist()
+	words := make([]string, options.Words)
+	for i := range words {
+		index, err := randomInt(len(wordlist))
+		if err != nil {
+			return "", err
+		}
+		words[i] = wordlist[index]
+		if options.Capitalize {
+			runes := []rune(words[i])
+			runes[0] = unicode.ToUpper(runes[0])
+			words[i] = string(runes)
+		}
+	}
+	if options.Number {
+		word, err := randomInt(len(words))
+		if err != nil {
+			return "", err
+		}
+		digit, err := randomInt(10)
+		if err != nil {
+			return "", err
+		}
+		words[word] += digits[digit : digit+1]
+	}
+	return strings.Join(words, options.Separator), nil
+}
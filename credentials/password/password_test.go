@@ -0,0 +1,173 @@
+package password
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	tests := []struct {
+		name    string
+		options GeneratorOptions
+		length  int
+		classes []string
+		err     error
+	}{
+		{"defaults", GeneratorOptions{}, 20, []string{lowercase, uppercase, digits, symbols}, nil},
+		{"digits only", GeneratorOptions{Length: 6, Digits: true}, 6, []string{digits}, nil},
+		{"letters", GeneratorOptions{Length: 32, Lowercase: true, Uppercase: true}, 32, []string{lowercase, uppercase}, nil},
+		{"every class at the minimum length", GeneratorOptions{Length: 4}, 4, []string{lowercase, uppercase, digits, symbols}, nil},
+		{"maximum length", GeneratorOptions{Length: 1024, Symbols: true}, 1024, []string{symbols}, nil},
+		{"too short", GeneratorOptions{Length: 3, Digits: true}, 0, nil, ERR_INVALID_LENGTH},
+		{"too long", GeneratorOptions{Length: 1025}, 0, nil, ERR_INVALID_LENGTH},
+		{"negative", GeneratorOptions{Length: -1}, 0, nil, ERR_INVALID_LENGTH},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			generated, err := Generate(tt.options)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("error = %v, want %v", err, tt.err)
+			}
+			if tt.err != nil {
+				return
+			}
+			if len(generated) != tt.length {
+				t.Fatalf("length = %d, want %d", len(generated), tt.length)
+			}
+			pool := strings.Join(tt.classes, "")
+			if strings.Trim(generated, pool) != "" {
+				t.Fatalf("%q has characters outside of %q", generated, pool)
+			}
+			for _, class := range tt.classes {
+				if !strings.ContainsAny(generated, class) {
+					t.Fatalf("%q has no character of %q", generated, class)
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateExcludeAmbiguous(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		generated, err := Generate(GeneratorOptions{Length: 128, ExcludeAmbiguous: true})
+		if err != nil {
+			t.Fatalf("generate: %v", err)
+		}
+		if strings.ContainsAny(generated, ambiguous) {
+			t.Fatalf("%q has ambiguous characters", generated)
+		}
+	}
+}
+
+func TestGeneratePassphrase(t *testing.T) {
+	tests := []struct {
+		name      string
+		options   PassphraseOptions
+		words     int
+		separator string
+		err       error
+	}{
+		// a few words of the list have a dash, the default separator cannot split them back
+		{"default word count", PassphraseOptions{Separator: " "}, 6, " ", nil},
+		{"minimum", PassphraseOptions{Words: 3, Separator: " "}, 3, " ", nil},
+		{"maximum", PassphraseOptions{Words: 64, Separator: "."}, 64, ".", nil},
+		{"capitalized", PassphraseOptions{Words: 5, Separator: " ", Capitalize: true}, 5, " ", nil},
+		{"with a number", PassphraseOptions{Words: 4, Separator: "_", Number: true}, 4, "_", nil},
+		{"too few words", PassphraseOptions{Words: 2}, 0, "", ERR_INVALID_WORD_COUNT},
+		{"too many words", PassphraseOptions{Words: 65}, 0, "", ERR_INVALID_WORD_COUNT},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			passphrase, err := GeneratePassphrase(tt.options)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("error = %v, want %v", err, tt.err)
+			}
+			if tt.err != nil {
+				return
+			}
+			words := strings.Split(passphrase, tt.separator)
+			if len(words) != tt.words {
+				t.Fatalf("%q has %d words, want %d", passphrase, len(words), tt.words)
+			}
+			numbers := 0
+			for _, word := range words {
+				if tt.options.Number && strings.ContainsAny(word[len(word)-1:], digits) {
+					numbers++
+					word = word[:len(word)-1]
+				}
+				if tt.options.Capitalize && !strings.ContainsAny(word[:1], uppercase) {
+					t.Fatalf("%q is not capitalized", word)
+				}
+				if !slices.Contains(Wordlist(), strings.ToLower(word)) {
+					t.Fatalf("%q is not in the wordlist", word)
+				}
+			}
+			if tt.options.Number && numbers != 1 {
+				t.Fatalf("%q has %d numbers, want 1", passphrase, numbers)
+			}
+		})
+	}
+}
+
+func TestGeneratePassphraseDefaultSeparator(t *testing.T) {
+	passphrase, err := GeneratePassphrase(PassphraseOptions{Words: 3})
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	if strings.Count(passphrase, "-") < 2 {
+		t.Fatalf("%q is not separated by dashes", passphrase)
+	}
+}
+
+func TestWordlist(t *testing.T) {
+	if words := Wordlist(); len(words) != 7776 {
+		t.Fatalf("wordlist has %d words, want 7776", len(words))
+	}
+}
+
+func TestEstimate(t *testing.T) {
+	tests := []struct {
+		name     string
+		password string
+		context  []string
+		score    int
+		flag     string
+	}{
+		{"empty", "", nil, 0, ""},
+		{"common", "password", nil, 0, FlagCommonPassword},
+		{"common with capitals", "Password", nil, 0, FlagCommonPassword},
+		{"too short", "x7#K", nil, 0, FlagTooShort},
+		{"sequence", "abcdefgh", nil, 0, FlagSequence},
+		{"keyboard row", "asdfghjkl", nil, 0, FlagKeyboardPattern},
+		{"repeated characters", "aaaaaaaaaaaa", nil, 0, FlagRepeatedCharacters},
+		{"context reuse", "examplecorp", []string{"examplecorp"}, 0, FlagContextReuse},
+		{"random", "G7#vQ2!mZ9@rT4$wK8", nil, 4, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			strength := Estimate(tt.password, tt.context...)
+			if strength.Score != tt.score {
+				t.Fatalf("score = %d (%.1f bits), want %d", strength.Score, strength.Entropy, tt.score)
+			}
+			if tt.flag != "" && !slices.Contains(strength.Flags, tt.flag) {
+				t.Fatalf("flags = %v, want %s", strength.Flags, tt.flag)
+			}
+			if tt.flag == "" && len(strength.Flags) != 0 {
+				t.Fatalf("flags = %v, want none", strength.Flags)
+			}
+		})
+	}
+}
+
+func TestEstimateDictionaryWords(t *testing.T) {
+	words := Estimate("correct-horse-battery-staple")
+	random := Estimate("q8#Rt2!xLm9$vB4&wZ6^nP3@kD7")
+	if !slices.Contains(words.Flags, FlagDictionaryWord) {
+		t.Fatalf("flags = %v, want %s", words.Flags, FlagDictionaryWord)
+	}
+	if words.Entropy >= random.Entropy {
+		t.Fatalf("words entropy %.1f is not below the %.1f of a random password", words.Entropy, random.Entropy)
+	}
+}
@@ -0,0 +1,293 @@
+package password
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+const (
+	FlagCommonPassword     = "common_password"
+	FlagDictionaryWord     = "dictionary_word"
+	FlagSequence           = "sequence"
+	FlagKeyboardPattern    = "keyboard_pattern"
+	FlagRepeatedCharacters = "repeated_characters"
+	FlagContextReuse       = "context_reuse"
+	FlagTooShort           = "too_short"
+)
+
+// Strength is an estimation of how hard a password is to guess
+type Strength struct {
+	// Entropy in bits, once the patterns found in the password are accounted for
+	Entropy float64 `json:"entropy"`
+	// Score from 0 (very weak) to 4 (very strong)
+	Score int      `json:"score"`
+	Flags []string `json:"flags"`
+}
+
+// passwords that top every leak, they are guessed before anything else
+var commonPasswords = []string{
+	"123456", "123456789", "12345678", "password", "qwerty", "123123", "111111", "1234567", "12345",
+	"1234567890", "000000", "abc123", "password1", "iloveyou", "azerty", "qwerty123", "1q2w3e4r",
+	"admin", "letmein", "welcome", "monkey", "dragon", "football", "baseball", "sunshine", "princess",
+	"master", "shadow", "superman", "trustno1", "passw0rd", "p@ssw0rd", "654321", "666666", "121212",
+	"qwertyuiop", "azertyuiop", "zaq12wsx", "soleil", "motdepasse", "doudou", "loulou", "chouchou",
+	"changeme", "secret", "login", "starwars", "hello", "freedom", "whatever", "qazwsx", "ninja",
+	"mustang", "access", "michael", "jordan", "batman", "charlie", "donald", "computer", "internet",
+}
+
+var keyboardRows = []string{
+	"1234567890", "qwertyuiop", "asdfghjkl", "zxcvbnm",
+	"azertyuiop", "qsdfghjklm", "wxcvbn",
+}
+
+var leet = strings.NewReplacer("0", "o", "1", "l", "3", "e", "4", "a", "5", "s", "7", "t", "@", "a", "$", "s", "!", "i")
+
+var dictionary = sync.OnceValue(func() map[string]bool {
+	words := Wordlist()
+	set := make(map[string]bool, len(words))
+	for _, word := range words {
+		if len(word) >= 4 {
+			set[word] = true
+		}
+	}
+	return set
+})
+
+// match is a part of the password explained by a pattern, it costs its own entropy instead of the
+// entropy of its characters
+type match struct {
+	start, end int
+	entropy    float64
+	flag       string
+}
+
+// Estimate computes the entropy of a password, context holds values the password should not reuse,
+// e.g. the title or the domain of the credential
+func Estimate(password string, context ...string) Strength {
+	runes := []rune(password)
+	lower := []rune(strings.ToLower(password))
+	if len(lower) != len(runes) {
+		lower = runes
+	}
+	strength := Strength{Flags: []string{}}
+	if len(runes) == 0 {
+		return strength
+	}
+
+	for _, common := range commonPasswords {
+		if string(lower) == common {
+			strength.Entropy = round(math.Log2(float64(len(commonPasswords))))
+			strength.Flags = append(strength.Flags, FlagCommonPassword)
+			return strength
+		}
+	}
+
+	bits := math.Log2(float64(poolSize(runes)))
+	var matches []match
+	matches = append(matches, dictionaryMatches(runes, lower)...)
+	matches = append(matches, sequenceMatches(lower)...)
+	matches = append(matches, keyboardMatches(lower)...)
+	matches = append(matches, repeatMatches(lower, bits)...)
+	matches = append(matches, contextMatches(lower, context)...)
+
+	// best[i] is the lowest entropy explaining the first i characters
+	best := make([]float64, len(runes)+1)
+	used := make([]*match, len(runes)+1)
+	sort.Slice(matches, func(i, j int) bool { return matches[i].end < matches[j].end })
+	next := 0
+	for i := 1; i <= len(runes); i++ {
+		best[i] = best[i-1] + bits
+		used[i] = nil
+		for ; next < len(matches) && matches[next].end == i; next++ {
+			m := &matches[next]
+			if cost := best[m.start] + m.entropy; cost < best[i] {
+				best[i], used[i] = cost, m
+			}
+		}
+	}
+
+	flags := map[string]bool{}
+	for i := len(runes); i > 0; {
+		if used[i] == nil {
+			i--
+			continue
+		}
+		flags[used[i].flag] = true
+		i = used[i].start
+	}
+	if len(runes) < 8 {
+		flags[FlagTooShort] = true
+	}
+	for flag := range flags {
+		strength.Flags = append(strength.Flags, flag)
+	}
+	sort.Strings(strength.Flags)
+
+	strength.Entropy = round(best[len(runes)])
+	strength.Score = score(strength.Entropy)
+	return strength
+}
+
+func score(entropy float64) int {
+	switch {
+	case entropy < 28:
+		return 0
+	case entropy < 36:
+		return 1
+	case entropy < 60:
+		return 2
+	case entropy < 80:
+		return 3
+	default:
+		return 4
+	}
+}
+
+func round(entropy float64) float64 {
+	return math.Round(entropy*10) / 10
+}
+
+// poolSize is the size of the alphabet an attacker has to try for the classes found in the password
+func poolSize(runes []rune) int {
+	var lower, upper, digit, symbol, other bool
+	for _, r := range runes {
+		switch {
+		case r >= 'a' && r <= 'z':
+			lower = true
+		case r >= 'A' && r <= 'Z':
+			upper = true
+		case r >= '0' && r <= '9':
+			digit = true
+		case r < unicode.MaxASCII:
+			symbol = true
+		default:
+			other = true
+		}
+	}
+	size := 0
+	for _, class := range []struct {
+		found bool
+		size  int
+	}{{lower, 26}, {upper, 26}, {digit, 10}, {symbol, 33}, {other, 100}} {
+		if class.found {
+			size += class.size
+		}
+	}
+	return size
+}
+
+func dictionaryMatches(runes []rune, lower []rune) []match {
+	words := dictionary()
+	unleeted := []rune(leet.Replace(string(lower)))
+	if len(unleeted) != len(lower) {
+		unleeted = lower
+	}
+	wordEntropy := math.Log2(float64(len(Wordlist())))
+
+	var matches []match
+	for i := range unleeted {
+		for j := i + 4; j <= len(unleeted); j++ {
+			if !words[string(unleeted[i:j])] {
+				continue
+			}
+			entropy := wordEntropy
+			// capitals and substitutions only add a guess or two per word
+			if string(runes[i:j]) != string(lower[i:j]) {
+				entropy++
+			}
+			if string(unleeted[i:j]) != string(lower[i:j]) {
+				entropy++
+			}
+			matches = append(matches, match{i, j, entropy, FlagDictionaryWord})
+		}
+	}
+	return matches
+}
+
+// sequenceMatches finds runs of at least 3 characters that follow each other, e.g. abc, 789 or zyx
+func sequenceMatches(lower []rune) []match {
+	var matches []match
+	for i := 0; i+2 < len(lower); {
+		delta := lower[i+1] - lower[i]
+		j := i + 1
+		for j < len(lower) && (delta == 1 || delta == -1) && lower[j]-lower[j-1] == delta && isAlphanumeric(lower[j]) {
+			j++
+		}
+		if j-i >= 3 && isAlphanumeric(lower[i]) {
+			matches = append(matches, match{i, j, math.Log2(36) + math.Log2(float64(j-i)) + 1, FlagSequence})
+			i = j
+			continue
+		}
+		i++
+	}
+	return matches
+}
+
+// keyboardMatches finds runs of at least 4 keys next to each other on a row of a qwerty or azerty keyboard
+func keyboardMatches(lower []rune) []match {
+	var matches []match
+	for _, row := range keyboardRows {
+		for i := 0; i < len(lower); {
+			j := i + 1
+			for j < len(lower) && adjacent(row, lower[j-1], lower[j]) {
+				j++
+			}
+			if j-i >= 4 {
+				matches = append(matches, match{i, j, math.Log2(47) + math.Log2(float64(j-i)) + 1, FlagKeyboardPattern})
+			}
+			i = j
+		}
+	}
+	return matches
+}
+
+func adjacent(row string, a rune, b rune) bool {
+	i, j := strings.IndexRune(row, a), strings.IndexRune(row, b)
+	return i >= 0 && j >= 0 && (i-j == 1 || j-i == 1)
+}
+
+func repeatMatches(lower []rune, bits float64) []match {
+	var matches []match
+	for i := 0; i < len(lower); {
+		j := i + 1
+		for j < len(lower) && lower[j] == lower[i] {
+			j++
+		}
+		if j-i >= 3 {
+			matches = append(matches, match{i, j, bits + math.Log2(float64(j-i)), FlagRepeatedCharacters})
+		}
+		i = j
+	}
+	return matches
+}
+
+// contextMatches finds the parts of the context, split on anything that is not a letter or a digit
+func contextMatches(lower []rune, context []string) []match {
+	var matches []match
+	password := string(lower)
+	for _, value := range context {
+		tokens := strings.FieldsFunc(strings.ToLower(value), func(r rune) bool { return !isAlphanumeric(r) })
+		for _, token := range tokens {
+			if len([]rune(token)) < 4 {
+				continue
+			}
+			for offset := 0; ; {
+				index := strings.Index(password[offset:], token)
+				if index < 0 {
+					break
+				}
+				start := len([]rune(password[:offset+index]))
+				matches = append(matches, match{start, start + len([]rune(token)), 1, FlagContextReuse})
+				offset += index + len(token)
+			}
+		}
+	}
+	return matches
+}
+
+func isAlphanumeric(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,79 @@
+package registry
+
+import (
+	"time"
+
+	"github.com/DO-2K23-26/polypass-microservices/credentials/card"
+	"github.com/DO-2K23-26/polypass-microservices/credentials/types"
+)
+
+func init() {
+	Register(Definition{
+		Type:             types.CredentialTypeCard,
+		Path:             "card",
+		Table:            "card_credentials",
+		Schema:           "card_credential.avsc",
+		Record:           "CardCredential",
+		AttributesRecord: "CardAttributes",
+		Attributes: []Attribute{
+			{Name: "owner_name", Kind: KindString, Required: true},
+			{Name: "cvc", Kind: KindString, Required: true, Secret: true},
+			// MM/YY, the card can be used until the end of the month
+			{Name: "expiration_date", Kind: KindString, Required: true},
+			{Name: "card_number", Kind: KindString, Required: true, Secret: true, Mask: maskCardNumber},
+			// computed from the card number, see completeCard
+			{Name: "brand", Kind: KindString},
+			{Name: "last_four", Kind: KindString},
+		},
+		Identity:    []string{"card_number"},
+		Complete:    completeCard,
+		Validate:    validateCard,
+		ValidateNew: validateNewCard,
+		Expiry:      `CASE WHEN expiration_date ~ '^\d{2}/\d{2}$' THEN to_date(expiration_date, 'MM/YY') + interval '1 month' END`,
+	})
+}
+
+// completeCard normalizes the card number and expiry date then sets the brand and last digits
+func completeCard(attributes map[string]any) error {
+	number := card.Normalize(attributes["card_number"].(string))
+	attributes["card_number"] = number
+	attributes["brand"] = string(card.DetectBrand(number))
+	attributes["last_four"] = card.LastFour(number)
+
+	expiry, err := card.ParseExpiry(attributes["expiration_date"].(string))
+	if err != nil {
+		return err
+	}
+	attributes["expiration_date"] = expiry.String()
+	return nil
+}
+
+func validateCard(attributes map[string]any) error {
+	number := attributes["card_number"].(string)
+	if err := card.Validate(number); err != nil {
+		return err
+	}
+	if err := card.ValidateCVC(card.DetectBrand(number), attributes["cvc"].(string)); err != nil {
+		return err
+	}
+	_, err := card.ParseExpiry(attributes["expiration_date"].(string))
+	return err
+}
+
+// validateNewCard refuses to store a card that has already expired, the expiry sweeper reports the
+// cards that expire once stored
+func validateNewCard(attributes map[string]any) error {
+	expiry, err := card.ParseExpiry(attributes["expiration_date"].(string))
+	if err != nil {
+		return err
+	}
+	if expiry.Expired(time.Now()) {
+		return card.ERR_EXPIRED
+	}
+	return nil
+}
+
+func maskCardNumber(value any) any {
+	number, _ := value.(string)
+	return card.Mask(number)
+}
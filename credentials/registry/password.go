@@ -0,0 +1,20 @@
+package registry
+
+import "github.com/DO-2K23-26/polypass-microservices/credentials/types"
+
+func init() {
+	Register(Definition{
+		Type:             types.CredentialTypePassword,
+		Path:             "password",
+		Table:            "password_credentials",
+		Schema:           "password_credential.avsc",
+		Record:           "PasswordCredential",
+		AttributesRecord: "PasswordAttributes",
+		Attributes: []Attribute{
+			{Name: "user_identifier", Kind: KindString},
+			{Name: "password", Kind: KindString, Required: true, Secret: true},
+			{Name: "domain_name", Kind: KindString, Required: true},
+		},
+		Identity: []string{"domain_name", "user_identifier"},
+	})
+}
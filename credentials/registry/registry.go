@@ -0,0 +1,351 @@
+package registry
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/DO-2K23-26/polypass-microservices/credentials/types"
+)
+
+var ERR_INVALID_CREDENTIAL_TYPE error = errors.New("invalid credential type")
+
+type AttributeKind string
+
+const (
+	KindString AttributeKind = "string"
+	KindInt    AttributeKind = "int"
+	KindLong   AttributeKind = "long"
+)
+
+// Attribute is a type-specific field of a credential
+type Attribute struct {
+	// Name is both the column of the table and the json field
+	Name string
+	Kind AttributeKind
+	// Required attributes cannot be left empty
+	Required bool
+	// Secret attributes are encrypted at rest and masked unless explicitly revealed
+	Secret bool
+	// WriteOnly attributes are never returned by the service, e.g. TOTP seeds
+	WriteOnly bool
+	// Default replaces a missing value, the zero value of the kind is used when it is nil
+	Default any
+	// Mask replaces the value in responses unless the credential is revealed, e.g. to show the last
+	// digits of a card number only. Secret attributes without Mask are replaced by SecretMask.
+	Mask func(value any) any
+}
+
+// Definition declares everything the generic layers need to know about a credential type
+type Definition struct {
+	Type types.CredentialType
+	// Path is the segment used by the HTTP routes, e.g. /credentials/sshkey
+	Path string
+	// Table is the table inheriting from credentials where the type is stored
+	Table string
+	// Schema is the Avro schema file of the Kafka events, see interfaces/credentials
+	Schema string
+	// Record is the name of the Avro record, also used as key of the Kafka messages
+	Record string
+	// AttributesRecord is the field of the Avro record holding the attributes
+	AttributesRecord string
+	Attributes       []Attribute
+	// Complete sets the attributes computed from the others, e.g. the fingerprint of an SSH key, it
+	// runs before Validate
+	Complete func(attributes map[string]any) error
+	// Validate checks the rules specific to the type, the attributes are already normalized
+	Validate func(attributes map[string]any) error
+	// ValidateNew checks the rules that only apply when a credential is created, e.g. that a card has
+	// not expired yet. Updates, version restores and imports keep credentials that no longer pass them.
+	ValidateNew func(attributes map[string]any) error
+	// Expiry is an SQL expression over the columns of Table giving a type-specific expiry, it is
+	// combined with expires_at by the expiry sweeper
+	Expiry string
+	// Identity are the attributes telling two credentials of the type apart, an import skips the
+	// credentials whose identity is already stored
+	Identity []string
+}
+
+var (
+	mu          sync.RWMutex
+	definitions []Definition
+)
+
+// Register makes a credential type available to the service, it panics if the type, path or table is already taken
+func Register(definition Definition) {
+	mu.Lock()
+	defer mu.Unlock()
+	for _, registered := range definitions {
+		if registered.Type == definition.Type || registered.Path == definition.Path || registered.Table == definition.Table {
+			panic(fmt.Sprintf("credential type %s registered twice", definition.Type))
+		}
+	}
+	definitions = append(definitions, definition)
+}
+
+// All returns the registered definitions in registration order
+func All() []Definition {
+	mu.RLock()
+	defer mu.RUnlock()
+	return append([]Definition(nil), definitions...)
+}
+
+func Get(credentialType types.CredentialType) (Definition, error) {
+	return find(func(d Definition) bool { return d.Type == credentialType })
+}
+
+func ByPath(path string) (Definition, error) {
+	return find(func(d Definition) bool { return d.Path == path })
+}
+
+func ByTable(table string) (Definition, error) {
+	return find(func(d Definition) bool { return d.Table == table })
+}
+
+func find(match func(Definition) bool) (Definition, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+	for _, definition := range definitions {
+		if match(definition) {
+			return definition, nil
+		}
+	}
+	return Definition{}, ERR_INVALID_CREDENTIAL_TYPE
+}
+
+func (d Definition) Attribute(name string) (Attribute, bool) {
+	for _, attribute := range d.Attributes {
+		if attribute.Name == name {
+			return attribute, true
+		}
+	}
+	return Attribute{}, false
+}
+
+func (d Definition) IsSecret(name string) bool {
+	attribute, ok := d.Attribute(name)
+	return ok && attribute.Secret
+}
+
+func (d Definition) IsWriteOnly(name string) bool {
+	attribute, ok := d.Attribute(name)
+	return ok && attribute.WriteOnly
+}
+
+// Visible returns the attributes without the write-only ones
+func (d Definition) Visible(attributes map[string]any) map[string]any {
+	visible := make(map[string]any, len(attributes))
+	for name, value := range attributes {
+		if !d.IsWriteOnly(name) {
+			visible[name] = value
+		}
+	}
+	return visible
+}
+
+// Public returns the attributes without the secret and write-only ones, it is what may leave the service
+// outside of a response, e.g. in Kafka events
+func (d Definition) Public(attributes map[string]any) map[string]any {
+	public := make(map[string]any, len(attributes))
+	for name, value := range attributes {
+		if attribute, ok := d.Attribute(name); ok && (attribute.Secret || attribute.WriteOnly) {
+			continue
+		}
+		public[name] = value
+	}
+	return public
+}
+
+// Masked returns the attributes with the secret ones replaced by their mask, attributes declaring no
+// Mask of their own are hidden entirely
+func (d Definition) Masked(attributes map[string]any) map[string]any {
+	masked := make(map[string]any, len(attributes))
+	for name, value := range attributes {
+		if attribute, ok := d.Attribute(name); ok && attribute.IsMasked() {
+			value = attribute.MaskValue(value)
+		}
+		masked[name] = value
+	}
+	return masked
+}
+
+// IsMasked tells whether an attribute is hidden behind a mask unless revealed
+func (d Definition) IsMasked(name string) bool {
+	attribute, ok := d.Attribute(name)
+	return ok && attribute.IsMasked()
+}
+
+// IdentityOf renders the identity of normalized attributes, it is empty when the type declares none
+func (d Definition) IdentityOf(attributes map[string]any) string {
+	if len(d.Identity) == 0 {
+		return ""
+	}
+	values := make([]string, 0, len(d.Identity)+1)
+	values = append(values, string(d.Type))
+	for _, name := range d.Identity {
+		attribute, _ := d.Attribute(name)
+		values = append(values, strings.ToLower(attribute.Format(attributes[name])))
+	}
+	return strings.Join(values, "\x00")
+}
+
+// Normalize converts the attributes to the Go type of their kind, missing attributes are set to their
+// default and unknown ones are dropped
+func (d Definition) Normalize(attributes map[string]any) (map[string]any, error) {
+	normalized := make(map[string]any, len(d.Attributes))
+	for _, attribute := range d.Attributes {
+		value, err := attribute.Convert(attributes[attribute.Name])
+		if err != nil {
+			return nil, err
+		}
+		normalized[attribute.Name] = value
+	}
+	return normalized, nil
+}
+
+// Check verifies that required attributes are set, completes the computed ones then applies the rules
+// of the type
+func (d Definition) Check(attributes map[string]any) error {
+	for _, attribute := range d.Attributes {
+		if attribute.Required && reflect.ValueOf(attributes[attribute.Name]).IsZero() {
+			return fmt.Errorf("%s cannot be empty", strings.ReplaceAll(attribute.Name, "_", " "))
+		}
+	}
+	if d.Complete != nil {
+		if err := d.Complete(attributes); err != nil {
+			return err
+		}
+	}
+	if d.Validate == nil {
+		return nil
+	}
+	return d.Validate(attributes)
+}
+
+// CheckNew applies the rules of the type reserved to new credentials, the attributes are already checked
+func (d Definition) CheckNew(attributes map[string]any) error {
+	if d.ValidateNew == nil {
+		return nil
+	}
+	return d.ValidateNew(attributes)
+}
+
+// SecretMask replaces the secret attributes that declare no Mask in responses
+const SecretMask = "********"
+
+func (a Attribute) IsMasked() bool {
+	return a.Secret || a.Mask != nil
+}
+
+// MaskValue hides a value of the attribute, empty values stay empty so that clients can tell them apart
+func (a Attribute) MaskValue(value any) any {
+	if a.Mask != nil {
+		return a.Mask(value)
+	}
+	if value == nil || reflect.ValueOf(value).IsZero() {
+		return value
+	}
+	return SecretMask
+}
+
+// Convert turns a decoded value (json, database) into the Go type of the attribute kind
+func (a Attribute) Convert(value any) (any, error) {
+	if value == nil || (value == "" && a.Default != nil) {
+		return a.fallback(), nil
+	}
+	if text, ok := value.(string); ok {
+		if a.Kind == KindString {
+			return text, nil
+		}
+		return a.Parse(text)
+	}
+
+	var number int64
+	switch v := value.(type) {
+	case json.Number:
+		n, err := v.Int64()
+		if err != nil {
+			return nil, fmt.Errorf("%s must be an integer", a.Name)
+		}
+		number = n
+	case int:
+		number = int64(v)
+	case int32:
+		number = int64(v)
+	case int64:
+		number = v
+	case float64:
+		if v != math.Trunc(v) {
+			return nil, fmt.Errorf("%s must be an integer", a.Name)
+		}
+		number = int64(v)
+	default:
+		return nil, fmt.Errorf("%s has an invalid type %T", a.Name, value)
+	}
+
+	switch a.Kind {
+	case KindString:
+		// e.g. card numbers stored as numbers by older versions
+		return strconv.FormatInt(number, 10), nil
+	case KindInt:
+		if number < math.MinInt32 || number > math.MaxInt32 {
+			return nil, fmt.Errorf("%s is out of range", a.Name)
+		}
+		return int(number), nil
+	default:
+		return number, nil
+	}
+}
+
+// Format renders a normalized value as text, it is what gets encrypted for secret attributes
+func (a Attribute) Format(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case int:
+		return strconv.Itoa(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// Parse reads a value rendered by Format
+func (a Attribute) Parse(text string) (any, error) {
+	switch a.Kind {
+	case KindInt:
+		value, err := strconv.Atoi(text)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be an integer", a.Name)
+		}
+		return value, nil
+	case KindLong:
+		value, err := strconv.ParseInt(text, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be an integer", a.Name)
+		}
+		return value, nil
+	default:
+		return text, nil
+	}
+}
+
+func (a Attribute) fallback() any {
+	if a.Default != nil {
+		return a.Default
+	}
+	switch a.Kind {
+	case KindInt:
+		return 0
+	case KindLong:
+		return int64(0)
+	default:
+		return ""
+	}
+}
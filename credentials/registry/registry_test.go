@@ -0,0 +1,218 @@
+package registry
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/DO-2K23-26/polypass-microservices/credentials/card"
+	"github.com/DO-2K23-26/polypass-microservices/credentials/types"
+)
+
+func mustGet(t *testing.T, credentialType types.CredentialType) Definition {
+	t.Helper()
+	definition, err := Get(credentialType)
+	if err != nil {
+		t.Fatalf("get %s: %v", credentialType, err)
+	}
+	return definition
+}
+
+func TestLookup(t *testing.T) {
+	for _, definition := range All() {
+		if byPath, err := ByPath(definition.Path); err != nil || byPath.Type != definition.Type {
+			t.Fatalf("ByPath(%s) = %s, %v", definition.Path, byPath.Type, err)
+		}
+		if byTable, err := ByTable(definition.Table); err != nil || byTable.Type != definition.Type {
+			t.Fatalf("ByTable(%s) = %s, %v", definition.Table, byTable.Type, err)
+		}
+	}
+	if _, err := Get("passkey"); !errors.Is(err, ERR_INVALID_CREDENTIAL_TYPE) {
+		t.Fatalf("Get(passkey) error = %v, want %v", err, ERR_INVALID_CREDENTIAL_TYPE)
+	}
+}
+
+func TestAttributeConvert(t *testing.T) {
+	tests := []struct {
+		name      string
+		attribute Attribute
+		value     any
+		want      any
+		valid     bool
+	}{
+		{"string", Attribute{Kind: KindString}, "hello", "hello", true},
+		{"nil string", Attribute{Kind: KindString}, nil, "", true},
+		{"nil with default", Attribute{Kind: KindInt, Default: 6}, nil, 6, true},
+		{"empty with default", Attribute{Kind: KindString, Default: "SHA1"}, "", "SHA1", true},
+		{"number as string", Attribute{Kind: KindString}, float64(4111111111111111), "4111111111111111", true},
+		{"json number", Attribute{Kind: KindInt}, json.Number("8"), 8, true},
+		{"json float", Attribute{Kind: KindInt}, json.Number("8.5"), nil, false},
+		{"float", Attribute{Kind: KindInt}, float64(30), 30, true},
+		{"fractional float", Attribute{Kind: KindInt}, 30.5, nil, false},
+		{"int out of range", Attribute{Kind: KindInt}, int64(1) << 40, nil, false},
+		{"long", Attribute{Kind: KindLong}, int64(1) << 40, int64(1) << 40, true},
+		{"int from text", Attribute{Kind: KindInt}, "4096", 4096, true},
+		{"int from invalid text", Attribute{Kind: KindInt}, "many", nil, false},
+		{"unsupported type", Attribute{Kind: KindString}, true, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, err := tt.attribute.Convert(tt.value)
+			if (err == nil) != tt.valid {
+				t.Fatalf("Convert(%v) error = %v, want valid %v", tt.value, err, tt.valid)
+			}
+			if tt.valid && value != tt.want {
+				t.Fatalf("Convert(%v) = %#v, want %#v", tt.value, value, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	definition := mustGet(t, types.CredentialTypeTOTP)
+	normalized, err := definition.Normalize(map[string]any{
+		"secret":  "JBSWY3DPEHPK3PXP",
+		"digits":  json.Number("8"),
+		"unknown": "dropped",
+	})
+	if err != nil {
+		t.Fatalf("normalize: %v", err)
+	}
+	want := map[string]any{
+		"secret":                 "JBSWY3DPEHPK3PXP",
+		"issuer":                 "",
+		"account_name":           "",
+		"digits":                 8,
+		"period":                 30,
+		"algorithm":              "SHA1",
+		"password_credential_id": "",
+	}
+	if !reflect.DeepEqual(normalized, want) {
+		t.Fatalf("normalized = %v, want %v", normalized, want)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name           string
+		credentialType types.CredentialType
+		attributes     map[string]any
+		valid          bool
+	}{
+		{"password", types.CredentialTypePassword, map[string]any{"password": "hunter2", "domain_name": "example.com"}, true},
+		{"password without password", types.CredentialTypePassword, map[string]any{"domain_name": "example.com"}, false},
+		{"password without domain", types.CredentialTypePassword, map[string]any{"password": "hunter2"}, false},
+		{"totp", types.CredentialTypeTOTP, map[string]any{"secret": "JBSWY3DPEHPK3PXP"}, true},
+		{"totp with an invalid secret", types.CredentialTypeTOTP, map[string]any{"secret": "not base32!"}, false},
+		{"totp with 10 digits", types.CredentialTypeTOTP, map[string]any{"secret": "JBSWY3DPEHPK3PXP", "digits": 10}, false},
+		{"card", types.CredentialTypeCard, map[string]any{"owner_name": "Alice", "card_number": "4111 1111 1111 1111", "cvc": "123", "expiration_date": "09/30"}, true},
+		{"card with a wrong checksum", types.CredentialTypeCard, map[string]any{"owner_name": "Alice", "card_number": "4111111111111112", "cvc": "123", "expiration_date": "09/30"}, false},
+		{"amex with a 3 digits cvc", types.CredentialTypeCard, map[string]any{"owner_name": "Alice", "card_number": "378282246310005", "cvc": "123", "expiration_date": "09/30"}, false},
+		{"card with an invalid expiry", types.CredentialTypeCard, map[string]any{"owner_name": "Alice", "card_number": "4111111111111111", "cvc": "123", "expiration_date": "2030"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			definition := mustGet(t, tt.credentialType)
+			attributes, err := definition.Normalize(tt.attributes)
+			if err != nil {
+				t.Fatalf("normalize: %v", err)
+			}
+			if err := definition.Check(attributes); (err == nil) != tt.valid {
+				t.Fatalf("check error = %v, want valid %v", err, tt.valid)
+			}
+		})
+	}
+}
+
+func TestCheckCompletesCard(t *testing.T) {
+	definition := mustGet(t, types.CredentialTypeCard)
+	attributes, err := definition.Normalize(map[string]any{
+		"owner_name":      "Alice",
+		"card_number":     "5555-5555-5555-4444",
+		"cvc":             "123",
+		"expiration_date": "9/2030",
+	})
+	if err != nil {
+		t.Fatalf("normalize: %v", err)
+	}
+	if err := definition.Check(attributes); err != nil {
+		t.Fatalf("check: %v", err)
+	}
+	for name, want := range map[string]any{
+		"card_number":     "5555555555554444",
+		"brand":           "mastercard",
+		"last_four":       "4444",
+		"expiration_date": "09/30",
+	} {
+		if attributes[name] != want {
+			t.Fatalf("%s = %v, want %v", name, attributes[name], want)
+		}
+	}
+
+	masked := definition.Masked(attributes)
+	if masked["card_number"] != "************4444" || masked["cvc"] != SecretMask || masked["owner_name"] != "Alice" {
+		t.Fatalf("masked = %v", masked)
+	}
+	public := definition.Public(attributes)
+	if _, ok := public["card_number"]; ok {
+		t.Fatalf("public attributes leak the card number: %v", public)
+	}
+	if _, ok := public["cvc"]; ok {
+		t.Fatalf("public attributes leak the cvc: %v", public)
+	}
+}
+
+func TestCheckNewCard(t *testing.T) {
+	definition := mustGet(t, types.CredentialTypeCard)
+	tests := []struct {
+		expiry string
+		err    error
+	}{
+		{"12/99", nil},
+		{"01/20", card.ERR_EXPIRED},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expiry, func(t *testing.T) {
+			attributes := map[string]any{"expiration_date": tt.expiry}
+			if err := definition.CheckNew(attributes); !errors.Is(err, tt.err) {
+				t.Fatalf("check new error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+	// the other types have no rule reserved to new credentials
+	if err := mustGet(t, types.CredentialTypePassword).CheckNew(map[string]any{}); err != nil {
+		t.Fatalf("check new password: %v", err)
+	}
+}
+
+func TestVisibility(t *testing.T) {
+	definition := mustGet(t, types.CredentialTypeTOTP)
+	attributes := map[string]any{"secret": "JBSWY3DPEHPK3PXP", "issuer": "Example", "digits": 6}
+
+	if _, ok := definition.Visible(attributes)["secret"]; ok {
+		t.Fatal("visible attributes keep the write-only secret")
+	}
+	if _, ok := definition.Public(attributes)["secret"]; ok {
+		t.Fatal("public attributes keep the write-only secret")
+	}
+	if masked := definition.Masked(attributes); masked["secret"] != SecretMask || masked["issuer"] != "Example" {
+		t.Fatalf("masked = %v", masked)
+	}
+	if masked := definition.Masked(map[string]any{"secret": ""}); masked["secret"] != "" {
+		t.Fatalf("empty secret masked as %v", masked["secret"])
+	}
+}
+
+func TestIdentityOf(t *testing.T) {
+	definition := mustGet(t, types.CredentialTypePassword)
+	a := definition.IdentityOf(map[string]any{"domain_name": "Example.com", "user_identifier": "Alice", "password": "one"})
+	b := definition.IdentityOf(map[string]any{"domain_name": "example.com", "user_identifier": "alice", "password": "two"})
+	c := definition.IdentityOf(map[string]any{"domain_name": "example.com", "user_identifier": "bob", "password": "one"})
+	if a != b {
+		t.Fatalf("identity is case sensitive: %q != %q", a, b)
+	}
+	if a == c {
+		t.Fatalf("different users share the identity %q", a)
+	}
+}
@@ -0,0 +1,48 @@
+package registry
+
+import (
+	"github.com/DO-2K23-26/polypass-microservices/credentials/sshkey"
+	"github.com/DO-2K23-26/polypass-microservices/credentials/types"
+)
+
+func init() {
+	Register(Definition{
+		Type:             types.CredentialTypeSSHKey,
+		Path:             "sshkey",
+		Table:            "ssh_keys",
+		Schema:           "ssh_credential.avsc",
+		Record:           "SSHKeyCredential",
+		AttributesRecord: "SSHKeyAttributes",
+		Attributes: []Attribute{
+			{Name: "private_key", Kind: KindString, Required: true, Secret: true},
+			// derived from the private key when left empty
+			{Name: "public_key", Kind: KindString},
+			{Name: "hostname", Kind: KindString, Required: true},
+			{Name: "user_identifier", Kind: KindString},
+			// decrypts the private key when it is encrypted
+			{Name: "passphrase", Kind: KindString, Secret: true},
+			// computed from the keys, see completeSSHKey
+			{Name: "fingerprint", Kind: KindString},
+			{Name: "key_type", Kind: KindString},
+			{Name: "bits", Kind: KindInt},
+		},
+		Identity: []string{"fingerprint"},
+		Complete: completeSSHKey,
+	})
+}
+
+// completeSSHKey checks that the keys form a pair and sets their metadata
+func completeSSHKey(attributes map[string]any) error {
+	privateKey, _ := attributes["private_key"].(string)
+	publicKey, _ := attributes["public_key"].(string)
+	passphrase, _ := attributes["passphrase"].(string)
+	publicKey, metadata, err := sshkey.Inspect(privateKey, publicKey, passphrase)
+	if err != nil {
+		return err
+	}
+	attributes["public_key"] = publicKey
+	attributes["fingerprint"] = metadata.Fingerprint
+	attributes["key_type"] = metadata.KeyType
+	attributes["bits"] = metadata.Bits
+	return nil
+}
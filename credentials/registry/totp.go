@@ -0,0 +1,55 @@
+package registry
+
+import (
+	"github.com/DO-2K23-26/polypass-microservices/credentials/totp"
+	"github.com/DO-2K23-26/polypass-microservices/credentials/types"
+)
+
+func init() {
+	Register(Definition{
+		Type:             types.CredentialTypeTOTP,
+		Path:             "totp",
+		Table:            "totp_credentials",
+		Schema:           "totp_credential.avsc",
+		Record:           "TOTPCredential",
+		AttributesRecord: "TOTPAttributes",
+		Attributes: []Attribute{
+			{Name: "secret", Kind: KindString, Required: true, Secret: true, WriteOnly: true},
+			{Name: "issuer", Kind: KindString},
+			{Name: "account_name", Kind: KindString},
+			{Name: "digits", Kind: KindInt, Default: totp.DefaultDigits},
+			{Name: "period", Kind: KindInt, Default: totp.DefaultPeriod},
+			{Name: "algorithm", Kind: KindString, Default: totp.DefaultAlgorithm},
+			// the password credential the codes are the second factor of, if any
+			{Name: "password_credential_id", Kind: KindString},
+		},
+		Identity: []string{"issuer", "account_name"},
+		Validate: func(attributes map[string]any) error {
+			return TOTPKey(attributes).Validate()
+		},
+	})
+}
+
+// TOTPKey reads the generator parameters from normalized totp attributes
+func TOTPKey(attributes map[string]any) totp.Key {
+	key := totp.Key{}
+	key.Secret, _ = attributes["secret"].(string)
+	key.Issuer, _ = attributes["issuer"].(string)
+	key.AccountName, _ = attributes["account_name"].(string)
+	key.Digits, _ = attributes["digits"].(int)
+	key.Period, _ = attributes["period"].(int)
+	key.Algorithm, _ = attributes["algorithm"].(string)
+	return key
+}
+
+// TOTPAttributes is the reverse of TOTPKey, used when importing otpauth URIs
+func TOTPAttributes(key totp.Key) map[string]any {
+	return map[string]any{
+		"secret":       key.Secret,
+		"issuer":       key.Issuer,
+		"account_name": key.AccountName,
+		"digits":       key.Digits,
+		"period":       key.Period,
+		"algorithm":    key.Algorithm,
+	}
+}
@@ -0,0 +1,250 @@
+// Package sshkey generates SSH key pairs and inspects the keys stored in ssh key credentials: it
+// parses OpenSSH and PEM keys, encrypted or not, checks that a public key matches its private key and
+// computes their SHA256 fingerprint.
+package sshkey
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+var (
+	ERR_INVALID_PRIVATE_KEY error = errors.New("invalid private key")
+	ERR_INVALID_PUBLIC_KEY  error = errors.New("invalid public key")
+	ERR_PASSPHRASE_REQUIRED error = errors.New("private key is encrypted: passphrase required")
+	ERR_WRONG_PASSPHRASE    error = errors.New("wrong passphrase for the private key")
+	ERR_KEY_MISMATCH        error = errors.New("public key does not match the private key")
+	ERR_UNSUPPORTED_KEY     error = errors.New("unsupported key type: expected ed25519, ecdsa or rsa")
+	ERR_INVALID_KEY_SIZE    error = errors.New("rsa keys must be 2048, 3072 or 4096 bits")
+	ERR_INVALID_CURVE_SIZE  error = errors.New("ecdsa keys must be 256, 384 or 521 bits")
+)
+
+const (
+	TypeED25519 = "ed25519"
+	TypeECDSA   = "ecdsa"
+	TypeRSA     = "rsa"
+
+	DefaultRSABits   = 4096
+	DefaultECDSABits = 256
+)
+
+// ecdsaCurves are the NIST curves OpenSSH accepts, by size
+var ecdsaCurves = map[int]elliptic.Curve{
+	256: elliptic.P256(),
+	384: elliptic.P384(),
+	521: elliptic.P521(),
+}
+
+// Metadata describes a key pair, it is stored next to the keys so that credentials can be searched by
+// fingerprint
+type Metadata struct {
+	// Fingerprint is the SHA256 fingerprint as printed by ssh-keygen -l, e.g. SHA256:uNiVztksCsDhcc0u9e8BujQXVUpKZIDTMczCvj3tD2s
+	Fingerprint string `json:"fingerprint"`
+	// KeyType is the SSH name of the key type, e.g. ssh-ed25519 or ssh-rsa
+	KeyType string `json:"key_type"`
+	Bits    int    `json:"bits"`
+}
+
+type GenerateOptions struct {
+	// Type is ed25519, ecdsa or rsa, ed25519 when empty
+	Type string `json:"type"`
+	// Bits is the size of rsa keys, 4096 when zero, or the curve of ecdsa keys, 256 when zero
+	Bits    int    `json:"bits"`
+	Comment string `json:"comment"`
+	// Passphrase encrypts the private key when set
+	Passphrase string `json:"passphrase"`
+}
+
+// KeyPair is a generated key pair, the private key is in OpenSSH format and the public key in
+// authorized_keys format
+type KeyPair struct {
+	PrivateKey string `json:"private_key"`
+	PublicKey  string `json:"public_key"`
+	Metadata
+}
+
+func Generate(options GenerateOptions) (KeyPair, error) {
+	var pair KeyPair
+	var private crypto.PrivateKey
+	switch options.Type {
+	case "", TypeED25519:
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return pair, err
+		}
+		private = key
+	case TypeECDSA:
+		bits := options.Bits
+		if bits == 0 {
+			bits = DefaultECDSABits
+		}
+		curve, ok := ecdsaCurves[bits]
+		if !ok {
+			return pair, ERR_INVALID_CURVE_SIZE
+		}
+		key, err := ecdsa.GenerateKey(curve, rand.Reader)
+		if err != nil {
+			return pair, err
+		}
+		private = key
+	case TypeRSA:
+		bits := options.Bits
+		if bits == 0 {
+			bits = DefaultRSABits
+		}
+		if bits != 2048 && bits != 3072 && bits != 4096 {
+			return pair, ERR_INVALID_KEY_SIZE
+		}
+		key, err := rsa.GenerateKey(rand.Reader, bits)
+		if err != nil {
+			return pair, err
+		}
+		private = key
+	default:
+		return pair, ERR_UNSUPPORTED_KEY
+	}
+
+	var block *pem.Block
+	var err error
+	if options.Passphrase != "" {
+		block, err = ssh.MarshalPrivateKeyWithPassphrase(private, options.Comment, []byte(options.Passphrase))
+	} else {
+		block, err = ssh.MarshalPrivateKey(private, options.Comment)
+	}
+	if err != nil {
+		return pair, err
+	}
+	public, err := publicKeyOf(private)
+	if err != nil {
+		return pair, err
+	}
+
+	pair.PrivateKey = string(pem.EncodeToMemory(block))
+	pair.PublicKey = authorizedKey(public, options.Comment)
+	pair.Metadata, err = metadataOf(public)
+	return pair, err
+}
+
+// Inspect parses a private key, decrypting it with the passphrase if needed, and returns the metadata of
+// the pair. When publicKey is empty it is derived from the private key and returned in authorized_keys
+// format, otherwise it must match the private key.
+func Inspect(privateKey string, publicKey string, passphrase string) (string, Metadata, error) {
+	private, err := ParsePrivateKey(privateKey, passphrase)
+	if err != nil {
+		return publicKey, Metadata{}, err
+	}
+	derived, err := publicKeyOf(private)
+	if err != nil {
+		return publicKey, Metadata{}, err
+	}
+
+	if strings.TrimSpace(publicKey) == "" {
+		publicKey = authorizedKey(derived, "")
+	} else {
+		public, err := ParsePublicKey(publicKey)
+		if err != nil {
+			return publicKey, Metadata{}, err
+		}
+		if string(public.Marshal()) != string(derived.Marshal()) {
+			return publicKey, Metadata{}, ERR_KEY_MISMATCH
+		}
+	}
+	metadata, err := metadataOf(derived)
+	return publicKey, metadata, err
+}
+
+// ParsePrivateKey reads OpenSSH keys and PEM keys (PKCS#1, PKCS#8, SEC 1), encrypted or not
+func ParsePrivateKey(privateKey string, passphrase string) (crypto.PrivateKey, error) {
+	data := []byte(strings.TrimSpace(privateKey) + "\n")
+	key, err := ssh.ParseRawPrivateKey(data)
+	var missing *ssh.PassphraseMissingError
+	if errors.As(err, &missing) {
+		if passphrase == "" {
+			return nil, ERR_PASSPHRASE_REQUIRED
+		}
+		key, err = ssh.ParseRawPrivateKeyWithPassphrase(data, []byte(passphrase))
+		if errors.Is(err, x509.IncorrectPasswordError) {
+			return nil, ERR_WRONG_PASSPHRASE
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ERR_INVALID_PRIVATE_KEY, err)
+	}
+	return key, nil
+}
+
+// ParsePublicKey reads a public key in authorized_keys format or a PEM encoded PKIX public key
+func ParsePublicKey(publicKey string) (ssh.PublicKey, error) {
+	data := []byte(strings.TrimSpace(publicKey))
+	if block, _ := pem.Decode(data); block != nil {
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ERR_INVALID_PUBLIC_KEY, err)
+		}
+		public, err := ssh.NewPublicKey(key)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ERR_INVALID_PUBLIC_KEY, err)
+		}
+		return public, nil
+	}
+	public, _, _, _, err := ssh.ParseAuthorizedKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ERR_INVALID_PUBLIC_KEY, err)
+	}
+	return public, nil
+}
+
+func publicKeyOf(private crypto.PrivateKey) (ssh.PublicKey, error) {
+	var public crypto.PublicKey
+	switch key := private.(type) {
+	case ed25519.PrivateKey:
+		public = key.Public()
+	case *ed25519.PrivateKey:
+		public = key.Public()
+	case *rsa.PrivateKey:
+		public = key.Public()
+	case *ecdsa.PrivateKey:
+		public = key.Public()
+	default:
+		return nil, ERR_UNSUPPORTED_KEY
+	}
+	return ssh.NewPublicKey(public)
+}
+
+func metadataOf(public ssh.PublicKey) (Metadata, error) {
+	metadata := Metadata{Fingerprint: ssh.FingerprintSHA256(public), KeyType: public.Type()}
+	crypto, ok := public.(ssh.CryptoPublicKey)
+	if !ok {
+		return metadata, ERR_UNSUPPORTED_KEY
+	}
+	switch key := crypto.CryptoPublicKey().(type) {
+	case ed25519.PublicKey:
+		metadata.Bits = 256
+	case *rsa.PublicKey:
+		metadata.Bits = key.N.BitLen()
+	case *ecdsa.PublicKey:
+		metadata.Bits = key.Curve.Params().BitSize
+	default:
+		return metadata, ERR_UNSUPPORTED_KEY
+	}
+	return metadata, nil
+}
+
+func authorizedKey(public ssh.PublicKey, comment string) string {
+	line := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(public)))
+	if comment != "" {
+		line += " " + comment
+	}
+	return line
+}
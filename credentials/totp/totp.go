@@ -0,0 +1,150 @@
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ERR_INVALID_SECRET    error = errors.New("invalid TOTP secret: expected base32")
+	ERR_INVALID_ALGORITHM error = errors.New("invalid TOTP algorithm: expected SHA1, SHA256 or SHA512")
+	ERR_INVALID_DIGITS    error = errors.New("invalid TOTP digits: expected 6, 7 or 8")
+	ERR_INVALID_PERIOD    error = errors.New("invalid TOTP period: must be positive")
+	ERR_INVALID_URI       error = errors.New("invalid otpauth URI")
+)
+
+const (
+	DefaultDigits    = 6
+	DefaultPeriod    = 30
+	DefaultAlgorithm = "SHA1"
+)
+
+var algorithms = map[string]func() hash.Hash{
+	"SHA1":   sha1.New,
+	"SHA256": sha256.New,
+	"SHA512": sha512.New,
+}
+
+// Key is the seed of a TOTP generator with its parameters
+type Key struct {
+	Secret      string
+	Issuer      string
+	AccountName string
+	Digits      int
+	Period      int
+	Algorithm   string
+}
+
+// Code is a generated code and how long it stays valid
+type Code struct {
+	Code      string `json:"code"`
+	Remaining int    `json:"remaining"`
+	Period    int    `json:"period"`
+}
+
+// DecodeSecret reads a base32 secret, authenticator apps often drop the padding, use lower case or group by 4
+func DecodeSecret(secret string) ([]byte, error) {
+	normalized := strings.ToUpper(strings.NewReplacer(" ", "", "-", "").Replace(secret))
+	normalized = strings.TrimRight(normalized, "=")
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(normalized)
+	if err != nil || len(key) == 0 {
+		return nil, ERR_INVALID_SECRET
+	}
+	return key, nil
+}
+
+func (k Key) Validate() error {
+	if _, err := DecodeSecret(k.Secret); err != nil {
+		return err
+	}
+	if _, ok := algorithms[k.Algorithm]; !ok {
+		return ERR_INVALID_ALGORITHM
+	}
+	if k.Digits < 6 || k.Digits > 8 {
+		return ERR_INVALID_DIGITS
+	}
+	if k.Period <= 0 {
+		return ERR_INVALID_PERIOD
+	}
+	return nil
+}
+
+// Generate returns the code valid at the given time
+func (k Key) Generate(at time.Time) (Code, error) {
+	if err := k.Validate(); err != nil {
+		return Code{}, err
+	}
+	secret, _ := DecodeSecret(k.Secret)
+
+	counter := uint64(at.Unix()) / uint64(k.Period)
+	var message [8]byte
+	binary.BigEndian.PutUint64(message[:], counter)
+	mac := hmac.New(algorithms[k.Algorithm], secret)
+	mac.Write(message[:])
+	sum := mac.Sum(nil)
+
+	// dynamic truncation, RFC 4226 section 5.3
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	modulo := uint32(1)
+	for i := 0; i < k.Digits; i++ {
+		modulo *= 10
+	}
+
+	return Code{
+		Code:      fmt.Sprintf("%0*d", k.Digits, value%modulo),
+		Remaining: k.Period - int(at.Unix()%int64(k.Period)),
+		Period:    k.Period,
+	}, nil
+}
+
+// ParseURI reads an otpauth://totp/Issuer:account?secret=...&issuer=... URI, missing parameters get their defaults
+func ParseURI(uri string) (Key, error) {
+	key := Key{Digits: DefaultDigits, Period: DefaultPeriod, Algorithm: DefaultAlgorithm}
+
+	parsed, err := url.Parse(uri)
+	if err != nil || parsed.Scheme != "otpauth" {
+		return key, ERR_INVALID_URI
+	}
+	if parsed.Host != "totp" {
+		return key, fmt.Errorf("%w: only totp is supported", ERR_INVALID_URI)
+	}
+
+	label := strings.TrimPrefix(parsed.Path, "/")
+	if issuer, account, ok := strings.Cut(label, ":"); ok {
+		key.Issuer, key.AccountName = strings.TrimSpace(issuer), strings.TrimSpace(account)
+	} else {
+		key.AccountName = label
+	}
+
+	query := parsed.Query()
+	key.Secret = query.Get("secret")
+	if issuer := query.Get("issuer"); issuer != "" {
+		key.Issuer = issuer
+	}
+	if algorithm := query.Get("algorithm"); algorithm != "" {
+		key.Algorithm = strings.ToUpper(algorithm)
+	}
+	if digits := query.Get("digits"); digits != "" {
+		if key.Digits, err = strconv.Atoi(digits); err != nil {
+			return key, ERR_INVALID_DIGITS
+		}
+	}
+	if period := query.Get("period"); period != "" {
+		if key.Period, err = strconv.Atoi(period); err != nil {
+			return key, ERR_INVALID_PERIOD
+		}
+	}
+	return key, key.Validate()
+}
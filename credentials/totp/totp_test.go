@@ -0,0 +1,129 @@
+package totp
+
+import (
+	"encoding/base32"
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestGenerateRFC6238 checks the test vectors of RFC 6238 appendix B, the seed of every algorithm is
+// the ASCII string 1234567890 repeated up to the size of its hash
+func TestGenerateRFC6238(t *testing.T) {
+	seeds := map[string]string{
+		"SHA1":   "12345678901234567890",
+		"SHA256": "12345678901234567890123456789012",
+		"SHA512": "1234567890123456789012345678901234567890123456789012345678901234",
+	}
+
+	tests := []struct {
+		unix      int64
+		algorithm string
+		code      string
+	}{
+		{59, "SHA1", "94287082"},
+		{59, "SHA256", "46119246"},
+		{59, "SHA512", "90693936"},
+		{1111111109, "SHA1", "07081804"},
+		{1111111109, "SHA256", "68084774"},
+		{1111111109, "SHA512", "25091201"},
+		{1111111111, "SHA1", "14050471"},
+		{1111111111, "SHA256", "67062674"},
+		{1111111111, "SHA512", "99943326"},
+		{1234567890, "SHA1", "89005924"},
+		{1234567890, "SHA256", "91819424"},
+		{1234567890, "SHA512", "93441116"},
+		{2000000000, "SHA1", "69279037"},
+		{2000000000, "SHA256", "90698825"},
+		{2000000000, "SHA512", "38618901"},
+		{20000000000, "SHA1", "65353130"},
+		{20000000000, "SHA256", "77737706"},
+		{20000000000, "SHA512", "47863826"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.algorithm+"/"+time.Unix(tt.unix, 0).UTC().Format(time.RFC3339), func(t *testing.T) {
+			key := Key{
+				Secret:    base32.StdEncoding.EncodeToString([]byte(seeds[tt.algorithm])),
+				Digits:    8,
+				Period:    30,
+				Algorithm: tt.algorithm,
+			}
+			code, err := key.Generate(time.Unix(tt.unix, 0))
+			if err != nil {
+				t.Fatalf("generate: %v", err)
+			}
+			if code.Code != tt.code {
+				t.Fatalf("code = %s, want %s", code.Code, tt.code)
+			}
+			if want := 30 - int(tt.unix%30); code.Remaining != want {
+				t.Fatalf("remaining = %d, want %d", code.Remaining, want)
+			}
+		})
+	}
+}
+
+func TestDecodeSecret(t *testing.T) {
+	tests := []struct {
+		name   string
+		secret string
+		err    error
+	}{
+		{"padded", "GEZDGNBVGY3TQOJQ", nil},
+		{"lower case", "gezdgnbvgy3tqojq", nil},
+		{"grouped", "GEZD GNBV GY3T QOJQ", nil},
+		{"dashes", "GEZD-GNBV-GY3T-QOJQ", nil},
+		{"without padding", "GEZDGNBVGY", nil},
+		{"empty", "", ERR_INVALID_SECRET},
+		{"not base32", "GEZDGNBV1!", ERR_INVALID_SECRET},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := DecodeSecret(tt.secret); !errors.Is(err, tt.err) {
+				t.Fatalf("error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestParseURI(t *testing.T) {
+	tests := []struct {
+		name string
+		uri  string
+		key  Key
+		err  error
+	}{
+		{
+			name: "defaults",
+			uri:  "otpauth://totp/alice@example.com?secret=JBSWY3DPEHPK3PXP",
+			key:  Key{Secret: "JBSWY3DPEHPK3PXP", AccountName: "alice@example.com", Digits: 6, Period: 30, Algorithm: "SHA1"},
+		},
+		{
+			name: "issuer in the label",
+			uri:  "otpauth://totp/Example:alice@example.com?secret=JBSWY3DPEHPK3PXP",
+			key:  Key{Secret: "JBSWY3DPEHPK3PXP", Issuer: "Example", AccountName: "alice@example.com", Digits: 6, Period: 30, Algorithm: "SHA1"},
+		},
+		{
+			name: "every parameter",
+			uri:  "otpauth://totp/Example:alice?secret=JBSWY3DPEHPK3PXP&issuer=ACME&algorithm=sha256&digits=8&period=60",
+			key:  Key{Secret: "JBSWY3DPEHPK3PXP", Issuer: "ACME", AccountName: "alice", Digits: 8, Period: 60, Algorithm: "SHA256"},
+		},
+		{name: "other scheme", uri: "https://totp/alice?secret=JBSWY3DPEHPK3PXP", err: ERR_INVALID_URI},
+		{name: "hotp", uri: "otpauth://hotp/alice?secret=JBSWY3DPEHPK3PXP&counter=1", err: ERR_INVALID_URI},
+		{name: "missing secret", uri: "otpauth://totp/alice", err: ERR_INVALID_SECRET},
+		{name: "unknown algorithm", uri: "otpauth://totp/alice?secret=JBSWY3DPEHPK3PXP&algorithm=MD5", err: ERR_INVALID_ALGORITHM},
+		{name: "too many digits", uri: "otpauth://totp/alice?secret=JBSWY3DPEHPK3PXP&digits=10", err: ERR_INVALID_DIGITS},
+		{name: "digits not a number", uri: "otpauth://totp/alice?secret=JBSWY3DPEHPK3PXP&digits=six", err: ERR_INVALID_DIGITS},
+		{name: "zero period", uri: "otpauth://totp/alice?secret=JBSWY3DPEHPK3PXP&period=0", err: ERR_INVALID_PERIOD},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := ParseURI(tt.uri)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("error = %v, want %v", err, tt.err)
+			}
+			if tt.err == nil && key != tt.key {
+				t.Fatalf("key = %+v, want %+v", key, tt.key)
+			}
+		})
+	}
+}
@@ -1,6 +1,9 @@
 package types
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 type Credential struct {
 	ID           string          `json:"id" db:"id"`
@@ -11,6 +14,11 @@ type Credential struct {
 	ExpiresAt    *time.Time      `json:"expires_at" db:"expires_at"`
 	LastReadAt   *time.Time      `json:"last_read_at" db:"last_read_at"`
 	CustomFields *map[string]any `json:"custom_fields" db:"custom_fields"`
+	UpdatedBy    *string         `json:"updated_by" db:"updated_by"`
+	// Owner is the subject that created the credential, only it and service identities access it
+	Owner *string `json:"owner" db:"owner"`
+	// Version is incremented by every update, it is the ETag of the credential
+	Version int `json:"version" db:"version"`
 }
 
 type CardCredential struct {
@@ -20,10 +28,14 @@ type CardCredential struct {
 }
 
 type CardAttributes struct {
-	OwnerName      string `json:"owner_name" db:"owner_name"`
-	CVC            int    `json:"cvc" db:"cvc"`
+	OwnerName string `json:"owner_name" db:"owner_name"`
+	CVC       string `json:"cvc" db:"cvc"`
+	// ExpirationDate is MM/YY
 	ExpirationDate string `json:"expiration_date" db:"expiration_date"`
-	CardNumber     int64  `json:"card_number" db:"card_number"`
+	// CardNumber is a string of 12 to 19 digits, leading zeros matter
+	CardNumber string `json:"card_number" db:"card_number"`
+	Brand      string `json:"brand" db:"brand"`
+	LastFour   string `json:"last_four" db:"last_four"`
 }
 
 type PasswordCredential struct {
@@ -49,6 +61,22 @@ type SSHKeyAttributes struct {
 	Hostname   string `json:"hostname" db:"hostname"`
 }
 
+// TOTPCredential holds an RFC 6238 seed, the secret is never returned, see GET /credentials/totp/{id}/code
+type TOTPCredential struct {
+	Credential
+	TOTPAttributes
+}
+
+type TOTPAttributes struct {
+	Secret               string `json:"secret,omitempty" db:"secret"`
+	Issuer               string `json:"issuer" db:"issuer"`
+	AccountName          string `json:"account_name" db:"account_name"`
+	Digits               int    `json:"digits" db:"digits"`
+	Period               int    `json:"period" db:"period"`
+	Algorithm            string `json:"algorithm" db:"algorithm"`
+	PasswordCredentialID string `json:"password_credential_id" db:"password_credential_id"`
+}
+
 type UserIdentifierAttribute struct {
 	UserIdentifier string `json:"user_identifier" db:"user_identifier"`
 }
@@ -59,15 +87,265 @@ const (
 	CredentialTypeCard     CredentialType = "card"
 	CredentialTypePassword CredentialType = "password"
 	CredentialTypeSSHKey   CredentialType = "ssh_key"
+	CredentialTypeTOTP     CredentialType = "totp"
 )
 
-type CreateCredentialOpts struct {
+// MaskedSecret replaces the value of secret fields that are not explicitly revealed
+const MaskedSecret = "********"
+
+// CredentialVersion is an immutable snapshot of a credential, taken every time it is written
+type CredentialVersion struct {
+	CredentialID string          `json:"credential_id" db:"credential_id"`
+	Version      int             `json:"version" db:"version"`
+	Type         CredentialType  `json:"type" db:"type"`
+	Actor        *string         `json:"actor" db:"actor"`
+	CreatedAt    *time.Time      `json:"created_at" db:"created_at"`
+	Data         json.RawMessage `json:"-" db:"-"`
+}
+
+type CredentialFieldChange struct {
+	Field string `json:"field"`
+	From  any    `json:"from"`
+	To    any    `json:"to"`
+}
+
+type CredentialVersionDiff struct {
+	From    int                     `json:"from"`
+	To      int                     `json:"to"`
+	Changes []CredentialFieldChange `json:"changes"`
+}
+
+// PasswordBreach is the last breach check of a password credential
+type PasswordBreach struct {
+	CredentialID string `json:"credential_id" db:"credential_id"`
+	// Occurrences is the number of times the password appears in the breach dataset
+	Occurrences int        `json:"occurrences" db:"occurrences"`
+	Compromised bool       `json:"compromised" db:"compromised"`
+	CheckedAt   *time.Time `json:"checked_at" db:"checked_at"`
+}
+
+type PasswordBreachScan struct {
+	Scanned     int `json:"scanned"`
+	Compromised int `json:"compromised"`
+}
+
+// CredentialExpiry is a credential that entered an expiry reminder window or expired
+type CredentialExpiry struct {
+	CredentialID string         `json:"credential_id" db:"id"`
+	Type         CredentialType `json:"type" db:"type"`
 	Title        string         `json:"title" db:"title"`
-	Note         string         `json:"note" db:"note"`
-	CustomFields map[string]any `json:"custom_fields" db:"custom_fields"`
-	Type         CredentialType `json:"type" validate:"required"`
-	SSHKeyAttributes
-	PasswordAttributes
-	CardAttributes
-	UserIdentifierAttribute
+	ExpiresAt    time.Time      `json:"expires_at" db:"expires_at"`
+	// WindowDays is the reminder window the credential entered, 0 once it is expired
+	WindowDays int `json:"window_days" db:"-"`
+	DaysLeft   int `json:"days_left" db:"-"`
+}
+
+type ExpirySweep struct {
+	Expiring int `json:"expiring"`
+	Expired  int `json:"expired"`
+}
+
+// AccessContext tells who reads credentials and from where, it is recorded in the access log
+type AccessContext struct {
+	Actor     *string
+	SourceIP  string
+	UserAgent string
+	// Reveal returns the masked attributes in clear, e.g. passwords and card numbers
+	Reveal bool
+	// AuthTime is when the caller last authenticated, if known
+	AuthTime *time.Time
+	// Owner limits the reads to the credentials of this owner, nil reads the credentials of every owner
+	Owner *string
+}
+
+// Principal is the caller of a request. Users only access the credentials they own, service
+// identities hold the service scope and access every credential.
+type Principal struct {
+	Subject  string
+	Service  bool
+	AuthTime *time.Time
+}
+
+// Owner is the owner the principal is limited to, nil for service identities
+func (p Principal) Owner() *string {
+	if p.Service {
+		return nil
+	}
+	return &p.Subject
+}
+
+// CredentialAccess is an entry of the access log, written for every read of a credential
+type CredentialAccess struct {
+	ID           int64          `json:"id"`
+	CredentialID string         `json:"credential_id"`
+	Type         CredentialType `json:"type"`
+	Actor        *string        `json:"actor"`
+	// Fields are the type-specific fields that were returned
+	Fields    []string   `json:"fields"`
+	SourceIP  string     `json:"source_ip"`
+	UserAgent string     `json:"user_agent"`
+	ReadAt    *time.Time `json:"read_at"`
+}
+
+// ImportItem is an entry of an imported export, Index is its position in the file starting at 1
+type ImportItem struct {
+	Index int            `json:"index"`
+	Title string         `json:"title"`
+	Type  CredentialType `json:"type,omitempty"`
+	// ID of the created credential, or of the stored one a duplicate matches when known
+	ID    string `json:"id,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// ImportReport tells what an import created, or would create in dry-run mode, what it skipped as a
+// duplicate and what failed validation
+type ImportReport struct {
+	DryRun     bool         `json:"dry_run"`
+	Created    []ImportItem `json:"created"`
+	Duplicates []ImportItem `json:"duplicates"`
+	Invalid    []ImportItem `json:"invalid"`
+}
+
+// ArchivedCredential is a credential as stored in an export archive, with all its attributes and its
+// whole version history so that an import restores it exactly
+type ArchivedCredential struct {
+	Credential GenericCredential `json:"credential"`
+	Versions   []ArchivedVersion `json:"versions"`
+}
+
+type ArchivedVersion struct {
+	CredentialVersion
+	Data json.RawMessage `json:"data"`
+}
+
+// CredentialSort is the field a listing is ordered by, ties are ordered by id
+type CredentialSort string
+
+const (
+	CredentialSortTitle      CredentialSort = "title"
+	CredentialSortCreatedAt  CredentialSort = "created_at"
+	CredentialSortUpdatedAt  CredentialSort = "updated_at"
+	CredentialSortLastReadAt CredentialSort = "last_read_at"
+)
+
+// ExpiryStatus filters a listing on the expiry of the credentials, see CredentialExpiry for how it is
+// computed
+type ExpiryStatus string
+
+const (
+	// expiry is past
+	ExpiryStatusExpired ExpiryStatus = "expired"
+	// expiry is within CredentialQuery.ExpiringWithin
+	ExpiryStatusExpiring ExpiryStatus = "expiring"
+	// not expired, or without expiry
+	ExpiryStatusValid ExpiryStatus = "valid"
+	// without expiry
+	ExpiryStatusNone ExpiryStatus = "none"
+)
+
+// CredentialQuery lists credentials page by page, empty fields do not filter
+type CredentialQuery struct {
+	// Type is empty to list every type
+	Type CredentialType
+	// Attributes are exact and case-insensitive matches on non secret attributes, e.g. domain_name.
+	// Types without the attribute are left out.
+	Attributes    map[string]string
+	TitlePrefix   string
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	UpdatedAfter  *time.Time
+	UpdatedBefore *time.Time
+	Expiry        ExpiryStatus
+	// ExpiringWithin is the window of ExpiryStatusExpiring
+	ExpiringWithin time.Duration
+	// CustomFields are keys the custom fields must all have
+	CustomFields []string
+	Sort         CredentialSort
+	Descending   bool
+	Limit        int
+	// Cursor is the NextCursor of the previous page
+	Cursor string
+	// Owner limits the listing to the credentials of this owner
+	Owner *string
+}
+
+// CredentialCursor is where a page ends, it is given to clients encoded in CredentialPage.NextCursor
+type CredentialCursor struct {
+	Sort       CredentialSort `json:"sort"`
+	Descending bool           `json:"desc"`
+	// SortKey is the value of the sort field of the last credential, as text
+	SortKey string `json:"key"`
+	ID      string `json:"id"`
+}
+
+// CredentialListEntry is a credential found by a listing, before it is read
+type CredentialListEntry struct {
+	ID      string         `db:"id"`
+	Type    CredentialType `db:"type"`
+	SortKey string         `db:"sort_key"`
+}
+
+type CredentialPage struct {
+	Credentials []GenericCredential `json:"credentials"`
+	// NextCursor is empty on the last page
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+type CredentialOperationKind string
+
+const (
+	CredentialOperationCreate CredentialOperationKind = "create"
+	CredentialOperationUpdate CredentialOperationKind = "update"
+	CredentialOperationDelete CredentialOperationKind = "delete"
+)
+
+// CredentialOperation is a write of a batch. The credential gives the type, and the id of updates and
+// deletes. Updates also give the version they are based on.
+type CredentialOperation struct {
+	Kind       CredentialOperationKind
+	Credential GenericCredential
+}
+
+type CredentialOperationStatus string
+
+const (
+	CredentialOperationCreated  CredentialOperationStatus = "created"
+	CredentialOperationUpdated  CredentialOperationStatus = "updated"
+	CredentialOperationDeleted  CredentialOperationStatus = "deleted"
+	CredentialOperationInvalid  CredentialOperationStatus = "invalid"
+	CredentialOperationNotFound CredentialOperationStatus = "not_found"
+	// the credential was updated since the version of the operation
+	CredentialOperationConflict CredentialOperationStatus = "conflict"
+	CredentialOperationFailed   CredentialOperationStatus = "failed"
+	// the operation was valid but another one failed an atomic batch
+	CredentialOperationRolledBack CredentialOperationStatus = "rolled_back"
+)
+
+// CredentialOperationResult is the outcome of an operation of a batch, Index is its position in the
+// batch starting at 0
+type CredentialOperationResult struct {
+	Index  int                       `json:"index"`
+	Op     CredentialOperationKind   `json:"op"`
+	Type   CredentialType            `json:"type"`
+	ID     string                    `json:"id,omitempty"`
+	Status CredentialOperationStatus `json:"status"`
+	// Version of the written credential, or the current one on a conflict
+	Version int    `json:"version,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+type CredentialBatchReport struct {
+	Atomic    bool                        `json:"atomic"`
+	Succeeded int                         `json:"succeeded"`
+	Failed    int                         `json:"failed"`
+	Results   []CredentialOperationResult `json:"results"`
+}
+
+// TrashedCredential is a deleted credential, it can be restored until it is purged
+type TrashedCredential struct {
+	ID        string         `json:"id" db:"id"`
+	Type      CredentialType `json:"type" db:"-"`
+	Title     string         `json:"title" db:"title"`
+	DeletedAt *time.Time     `json:"deleted_at" db:"deleted_at"`
+	DeletedBy *string        `json:"deleted_by" db:"deleted_by"`
 }
@@ -0,0 +1,88 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// GenericCredential is a credential of any registered type. The fields shared by every type live in
+// the embedded Credential while the type-specific attributes are kept by column name. It is serialized
+// as a flat object: the shared fields, the attributes and the type.
+type GenericCredential struct {
+	Credential
+	Type       CredentialType `json:"type"`
+	Attributes map[string]any `json:"-"`
+}
+
+// credentialFields are the json names of the fields shared by every credential type
+var credentialFields = jsonFields(reflect.TypeOf(Credential{}))
+
+func jsonFields(t reflect.Type) map[string]bool {
+	fields := make(map[string]bool, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			fields[name] = true
+		}
+	}
+	return fields
+}
+
+func (g GenericCredential) MarshalJSON() ([]byte, error) {
+	base, err := json.Marshal(g.Credential)
+	if err != nil {
+		return nil, err
+	}
+	var shared map[string]json.RawMessage
+	if err := json.Unmarshal(base, &shared); err != nil {
+		return nil, err
+	}
+
+	fields := make(map[string]any, len(shared)+len(g.Attributes)+1)
+	for name, value := range g.Attributes {
+		fields[name] = value
+	}
+	for name, value := range shared {
+		fields[name] = value
+	}
+	fields["type"] = g.Type
+	return json.Marshal(fields)
+}
+
+// UnmarshalJSON keeps numbers as json.Number in the attributes, they are converted once the type is known
+func (g *GenericCredential) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &g.Credential); err != nil {
+		return err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var fields map[string]any
+	if err := decoder.Decode(&fields); err != nil {
+		return err
+	}
+
+	g.Attributes = make(map[string]any, len(fields))
+	for name, value := range fields {
+		switch {
+		case name == "type":
+			if credentialType, ok := value.(string); ok {
+				g.Type = CredentialType(credentialType)
+			}
+		case !credentialFields[name]:
+			g.Attributes[name] = value
+		}
+	}
+	return nil
+}
+
+// Decode fills one of the typed credential structs, e.g. PasswordCredential, from the generic credential
+func (g GenericCredential) Decode(target any) error {
+	data, err := json.Marshal(g)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, target)
+}
@@ -2,6 +2,7 @@ package app
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -12,31 +13,56 @@ import (
 
 	avroGeneratedSchema "github.com/DO-2K23-26/polypass-microservices/libs/avro-schemas/generated"
 	"github.com/DO-2K23-26/polypass-microservices/libs/avro-schemas/schemautils"
+	credentials "github.com/DO-2K23-26/polypass-microservices/libs/interfaces/credentials"
 	organization "github.com/DO-2K23-26/polypass-microservices/libs/interfaces/organization"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/encoding/protojson"
 	"gorm.io/gorm"
 )
 
 // FolderCredentialService links folders with credentials using the credential service.
+// Credentials are written through its HTTP API and read through its gRPC API. The Authorization
+// header of the end user is passed through, so that the credential service only gives access to the
+// credentials the user owns.
 type FolderCredentialService struct {
-	db        *gorm.DB
-	host      string
-	client    *http.Client
-	publisher EventPublisher
-	encoder   *schemautils.AvroEncoder
+	db          *gorm.DB
+	host        string
+	client      *http.Client
+	credentials credentials.CredentialServiceClient
+	publisher   EventPublisher
+	encoder     *schemautils.AvroEncoder
 }
 
 // NewFolderCredentialService creates a new FolderCredentialService.
-func NewFolderCredentialService(db *gorm.DB, publisher EventPublisher, encoder *schemautils.AvroEncoder) *FolderCredentialService {
+func NewFolderCredentialService(db *gorm.DB, publisher EventPublisher, encoder *schemautils.AvroEncoder) (*FolderCredentialService, error) {
 	host := os.Getenv("CREDENTIAL_SERVICE_HOST")
 	if host == "" {
 		host = "http://localhost:8080"
 		log.Println("CREDENTIAL_SERVICE_HOST is not set, using default value (http://localhost:8080)")
 	}
-	return &FolderCredentialService{db: db, host: host, client: &http.Client{}, publisher: publisher, encoder: encoder}
+	grpcHost := os.Getenv("CREDENTIAL_SERVICE_GRPC_HOST")
+	if grpcHost == "" {
+		grpcHost = "localhost:4002"
+		log.Println("CREDENTIAL_SERVICE_GRPC_HOST is not set, using default value (localhost:4002)")
+	}
+	conn, err := grpc.NewClient(grpcHost, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, err
+	}
+	return &FolderCredentialService{
+		db:          db,
+		host:        host,
+		client:      &http.Client{},
+		credentials: credentials.NewCredentialServiceClient(conn),
+		publisher:   publisher,
+		encoder:     encoder,
+	}, nil
 }
 
 // List returns paginated credentials for a folder.
-func (s *FolderCredentialService) List(folderID string, credType *string, req *organization.GetCredentialRequest) (*organization.GetCredentialResponse, error) {
+func (s *FolderCredentialService) List(folderID string, credType *string, req *organization.GetCredentialRequest, authorization string) (*organization.GetCredentialResponse, error) {
 	var relations []organization.FolderCredential
 	databaseRef := s.db
 
@@ -72,31 +98,26 @@ func (s *FolderCredentialService) List(folderID string, credType *string, req *o
 	// }
 
 	// creds := make([]map[string]interface{}, 0, end-start)
-	creds := make([]map[string]interface{}, 0, len(relations))
+	ids := make([]string, 0, len(relations))
 	for _, rel := range relations {
-		url := fmt.Sprintf("%s/credentials/%s?ids=%s", s.host, rel.Type, rel.IdCredential)
-		resp, err := s.client.Get(url)
-		if err != nil {
-			return nil, err
-		}
-
-		defer resp.Body.Close()
-
-		if resp.StatusCode != http.StatusOK {
-			b, _ := io.ReadAll(resp.Body)
-			return nil, fmt.Errorf("credential service returned %d: %s", resp.StatusCode, string(b))
-		}
+		ids = append(ids, rel.IdCredential)
+	}
 
-		var data []map[string]interface{}
-		if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
-			return nil, err
-		}
+	// a single call whatever the types, the credential service resolves them itself
+	byID, err := s.getCredentials(ids, authorization)
+	if err != nil {
+		return nil, err
+	}
 
-		if len(data) == 0 {
-			return nil, fmt.Errorf("credential service returned no data for %s", rel.IdCredential)
+	// a credential that was deleted, or that the user does not own, must not hide the rest of the folder
+	creds := make([]map[string]interface{}, 0, len(relations))
+	for _, rel := range relations {
+		credential, ok := byID[rel.IdCredential]
+		if !ok {
+			log.Printf("credential service returned no data for %s of folder %s, skipped", rel.IdCredential, folderID)
+			continue
 		}
-
-		creds = append(creds, data[0])
+		creds = append(creds, credential)
 	}
 
 	if req != nil {
@@ -107,9 +128,15 @@ func (s *FolderCredentialService) List(folderID string, credType *string, req *o
 }
 
 // Create creates a credential via the credential service and stores the link.
-func (s *FolderCredentialService) Create(folderID, credType string, body []byte) (map[string]interface{}, error) {
+func (s *FolderCredentialService) Create(folderID, credType string, body []byte, authorization string) (map[string]interface{}, error) {
 	url := fmt.Sprintf("%s/credentials/%s", s.host, credType)
-	resp, err := s.client.Post(url, "application/json", bytes.NewReader(body))
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	forwardAuthorization(req, authorization)
+	resp, err := s.client.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -148,27 +175,48 @@ func (s *FolderCredentialService) Create(folderID, credType string, body []byte)
 	return credential, nil
 }
 
-// Update updates a credential via the credential service.
-func (s *FolderCredentialService) Update(folderID, credType, credentialID string, body []byte) (map[string]interface{}, error) {
+// CredentialServiceError is a client error answered by the credential service, such as a stale
+// If-Match. Its status, body and ETag are passed through to the caller.
+type CredentialServiceError struct {
+	StatusCode int
+	Body       []byte
+	ETag       string
+}
+
+func (e *CredentialServiceError) Error() string {
+	return fmt.Sprintf("credential service returned %d: %s", e.StatusCode, string(e.Body))
+}
+
+// Update updates a credential via the credential service. ifMatch is the ETag the update is based on,
+// the ETag of the updated credential is returned.
+func (s *FolderCredentialService) Update(folderID, credType, credentialID string, body []byte, ifMatch string, authorization string) (map[string]interface{}, string, error) {
 	url := fmt.Sprintf("%s/credentials/%s/%s", s.host, credType, credentialID)
 	req, err := http.NewRequest(http.MethodPut, url, bytes.NewReader(body))
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 	req.Header.Set("Content-Type", "application/json")
+	if ifMatch != "" {
+		req.Header.Set("If-Match", ifMatch)
+	}
+	forwardAuthorization(req, authorization)
 	resp, err := s.client.Do(req)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
 		b, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("credential service returned %d: %s", resp.StatusCode, string(b))
+		if resp.StatusCode >= 400 && resp.StatusCode < 500 {
+			return nil, "", &CredentialServiceError{StatusCode: resp.StatusCode, Body: b, ETag: resp.Header.Get("ETag")}
+		}
+		return nil, "", fmt.Errorf("credential service returned %d: %s", resp.StatusCode, string(b))
 	}
 	var credential map[string]interface{}
 	if err := json.NewDecoder(resp.Body).Decode(&credential); err != nil {
-		return nil, err
+		return nil, "", err
 	}
+	etag := resp.Header.Get("ETag")
 
 	name, _ := credential["name"].(string)
 	event := avroGeneratedSchema.CredentialEvent{
@@ -178,21 +226,26 @@ func (s *FolderCredentialService) Update(folderID, credType, credentialID string
 	}
 	var buf bytes.Buffer
 	if err := event.Serialize(&buf); err != nil {
-		return nil, err
+		return nil, "", err
 	}
 	if err := s.publisher.Publish("credential-update", buf.Bytes()); err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
-	return credential, nil
+	return credential, etag, nil
 }
 
 // Delete removes credentials via the credential service and unlinks them from the folder.
-func (s *FolderCredentialService) Delete(folderID, credType string, ids []string) error {
+func (s *FolderCredentialService) Delete(folderID, credType string, ids []string, authorization string) error {
 	names := make(map[string]string, len(ids))
 	for _, id := range ids {
 		url := fmt.Sprintf("%s/credentials/%s/%s", s.host, credType, id)
-		resp, err := s.client.Get(url)
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+		forwardAuthorization(req, authorization)
+		resp, err := s.client.Do(req)
 		if err == nil {
 			defer resp.Body.Close()
 			if resp.StatusCode == http.StatusOK {
@@ -215,6 +268,7 @@ func (s *FolderCredentialService) Delete(folderID, credType string, ids []string
 	q := req.URL.Query()
 	q.Set("ids", strings.Join(ids, ","))
 	req.URL.RawQuery = q.Encode()
+	forwardAuthorization(req, authorization)
 
 	resp, err := s.client.Do(req)
 	if err != nil {
@@ -258,7 +312,7 @@ func (s *FolderCredentialService) Delete(folderID, credType string, ids []string
 	return nil
 }
 
-func (s *FolderCredentialService) ListUserCredentials(userID string, credentialType *string) (*[]map[string]interface{}, error) {
+func (s *FolderCredentialService) ListUserCredentials(userID string, credentialType *string, authorization string) (*[]map[string]interface{}, error) {
 	folderListReq := organization.GetFolderRequest{
 		Page:   1,
 		Limit:  10000,
@@ -271,17 +325,39 @@ func (s *FolderCredentialService) ListUserCredentials(userID string, credentialT
 		return nil, err
 	}
 
-	var credentials []map[string]interface{}
+	if len(folders) == 0 {
+		empty := []map[string]interface{}{}
+		return &empty, nil
+	}
+	folderIDs := make([]string, 0, len(folders))
 	for _, folder := range folders {
-		tmpCredentials, err := s.List(folder.Id, credentialType, nil)
-		if err != nil {
-			continue
-		}
-		if tmpCredentials == nil {
-			continue
-		}
+		folderIDs = append(folderIDs, folder.Id)
+	}
 
-		credentials = append(credentials, tmpCredentials.Credentials...)
+	var relations []organization.FolderCredential
+	databaseRef := s.db.Where("id_folder IN ?", folderIDs)
+	if credentialType != nil {
+		databaseRef = databaseRef.Where("type = ?", *credentialType)
+	}
+	if err := databaseRef.Find(&relations).Error; err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(relations))
+	for _, rel := range relations {
+		ids = append(ids, rel.IdCredential)
+	}
+	// the credentials of every folder are fetched in one call
+	byID, err := s.getCredentials(ids, authorization)
+	if err != nil {
+		return nil, err
+	}
+
+	var credentials []map[string]interface{}
+	for _, rel := range relations {
+		if credential, ok := byID[rel.IdCredential]; ok {
+			credentials = append(credentials, credential)
+		}
 	}
 
 	if len(credentials) == 0 {
@@ -291,3 +367,60 @@ func (s *FolderCredentialService) ListUserCredentials(userID string, credentialT
 
 	return &credentials, nil
 }
+
+// forwardAuthorization passes the Authorization header of the end user to the credential service
+func forwardAuthorization(req *http.Request, authorization string) {
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+}
+
+// getCredentials fetches credentials of any type with one BatchGet of the gRPC API, by id. Credentials
+// unknown to the credential service, or not owned by the user, are left out.
+func (s *FolderCredentialService) getCredentials(ids []string, authorization string) (map[string]map[string]interface{}, error) {
+	byID := make(map[string]map[string]interface{}, len(ids))
+	if len(ids) == 0 {
+		return byID, nil
+	}
+	ctx := context.Background()
+	if authorization != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", authorization)
+	}
+	resp, err := s.credentials.BatchGet(ctx, &credentials.BatchGetRequest{Ids: ids})
+	if err != nil {
+		return nil, fmt.Errorf("credential service: %w", err)
+	}
+	for _, credential := range resp.Credentials {
+		fields, err := credentialFields(credential)
+		if err != nil {
+			return nil, err
+		}
+		byID[credential.Id] = fields
+	}
+	return byID, nil
+}
+
+// credentialFields flattens a credential of the gRPC API to the JSON object returned by the HTTP API of
+// the credential service: the shared fields, the attributes of its type and the type.
+func credentialFields(credential *credentials.Credential) (map[string]interface{}, error) {
+	data, err := protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true}.Marshal(credential)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	// the attributes are set in the field named after the type, e.g. password or ssh_key
+	if attributes, ok := fields[credential.Type].(map[string]interface{}); ok {
+		delete(fields, credential.Type)
+		for name, value := range attributes {
+			fields[name] = value
+		}
+	}
+	// unset optional fields are left out by protojson while the HTTP API returns null
+	if _, ok := fields["updated_by"]; !ok {
+		fields["updated_by"] = nil
+	}
+	return fields, nil
+}
@@ -61,7 +61,10 @@ func NewApp() (*App, error) {
 
 	folderService := app.NewFolderService(producer, folderEncoder, db)
 	tagService := app.NewTagService(producer, tagEncoder, db)
-	folderCredentialService := app.NewFolderCredentialService(db, producer, credentialEncoder)
+	folderCredentialService, err := app.NewFolderCredentialService(db, producer, credentialEncoder)
+	if err != nil {
+		return nil, err
+	}
 
 	folderHandler := httpPorts.NewFolderHandler(folderService)
 	tagHandler := httpPorts.NewTagHandler(tagService)
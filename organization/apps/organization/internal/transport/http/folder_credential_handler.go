@@ -2,6 +2,7 @@ package http
 
 import (
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
 	"strconv"
@@ -69,7 +70,7 @@ func (h *FolderCredentialHandler) ListCredentials(w http.ResponseWriter, r *http
 	}
 
 	credTypeStr := credType
-	res, err := h.service.List(folderID, &credTypeStr, &req)
+	res, err := h.service.List(folderID, &credTypeStr, &req, r.Header.Get("Authorization"))
 	if err != nil {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusInternalServerError)
@@ -106,7 +107,7 @@ func (h *FolderCredentialHandler) CreateCredential(w http.ResponseWriter, r *htt
 		return
 	}
 
-	cred, err := h.service.Create(folderID, credType, body)
+	cred, err := h.service.Create(folderID, credType, body, r.Header.Get("Authorization"))
 	if err != nil {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusInternalServerError)
@@ -145,7 +146,18 @@ func (h *FolderCredentialHandler) UpdateCredential(w http.ResponseWriter, r *htt
 		return
 	}
 
-	cred, err := h.service.Update(folderID, credType, credentialID, body)
+	cred, etag, err := h.service.Update(folderID, credType, credentialID, body, r.Header.Get("If-Match"), r.Header.Get("Authorization"))
+	var serviceErr *app.CredentialServiceError
+	if errors.As(err, &serviceErr) {
+		// e.g. 412 when the credential was updated since it was read, with its current version
+		if serviceErr.ETag != "" {
+			w.Header().Set("ETag", serviceErr.ETag)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(serviceErr.StatusCode)
+		w.Write(serviceErr.Body)
+		return
+	}
 	if err != nil {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusInternalServerError)
@@ -153,6 +165,9 @@ func (h *FolderCredentialHandler) UpdateCredential(w http.ResponseWriter, r *htt
 		json.NewEncoder(w).Encode(errBody)
 		return
 	}
+	if etag != "" {
+		w.Header().Set("ETag", etag)
+	}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(cred)
 }
@@ -182,7 +197,7 @@ func (h *FolderCredentialHandler) DeleteCredentials(w http.ResponseWriter, r *ht
 		return
 	}
 
-	if err := h.service.Delete(folderID, credType, ids); err != nil {
+	if err := h.service.Delete(folderID, credType, ids, r.Header.Get("Authorization")); err != nil {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusInternalServerError)
 		errBody := map[string]string{"error": err.Error()}
@@ -220,7 +235,7 @@ func (h *FolderCredentialHandler) ListUserCredentials(w http.ResponseWriter, r *
 		credentialType = &credentialTypeStr
 	}
 
-	res, err := h.service.ListUserCredentials(userId, credentialType)
+	res, err := h.service.ListUserCredentials(userId, credentialType, r.Header.Get("Authorization"))
 	if err != nil {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusInternalServerError)
@@ -2,4 +2,15 @@ module github.com/DO-2K23-26/polypass-microservices/libs/interfaces
 
 go 1.24.3
 
-require github.com/lib/pq v1.10.9
+require (
+	github.com/lib/pq v1.10.9
+	google.golang.org/grpc v1.67.3
+	google.golang.org/protobuf v1.36.1
+)
+
+require (
+	golang.org/x/net v0.28.0 // indirect
+	golang.org/x/sys v0.28.0 // indirect
+	golang.org/x/text v0.17.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20240814211410-ddb44dafa142 // indirect
+)